@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"PDEA/internal/gate"
+)
+
+// faultFlags collects repeated -fault GATE=reason flags.
+type faultFlags map[string]string
+
+func (f faultFlags) String() string { return fmt.Sprint(map[string]string(f)) }
+
+func (f faultFlags) Set(v string) error {
+	id, reason, ok := strings.Cut(v, "=")
+	if !ok || id == "" || reason == "" {
+		return fmt.Errorf("want GATE=reason, got %q", v)
+	}
+	f[id] = reason
+	return nil
+}
+
+// gateSim runs the gate simulator on a TCP port, so the vehicle service can
+// be pointed at it with PDEA_GATE_ADAPTER=tcp for local testing.
+func gateSim(args []string) error {
+	fs := flag.NewFlagSet("gate-sim", flag.ExitOnError)
+	addr := fs.String("addr", ":7070", "address to answer gate commands on")
+	faults := faultFlags{}
+	sim := &gate.Simulator{Log: slog.Default()}
+	fs.DurationVar(&sim.Delay, "delay", 0, "time each gate takes to acknowledge")
+	fs.Var(faults, "fault", "GATE=reason makes GATE answer with a fault; repeatable")
+	fs.Parse(args)
+	for id, reason := range faults {
+		sim.SetFault(id, reason)
+	}
+
+	ln, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return err
+	}
+	slog.Info("gate simulator listening", "addr", ln.Addr().String())
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return sim.Serve(ctx, ln)
+}
@@ -0,0 +1,201 @@
+// Package anpr keeps the plate reads sent by the lane cameras. A read the
+// camera is confident enough about is turned into an entry or exit by the
+// vehicle service; the rest, and reads that could not be acted on, wait in
+// a review queue for an attendant to correct or dismiss.
+package anpr
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"PDEA/internal/audit"
+	"PDEA/internal/parking"
+)
+
+const (
+	// StatusApplied reads created an entry or exit.
+	StatusApplied = "applied"
+	// StatusDuplicate reads saw a vehicle already dealt with, such as a
+	// second read of a car that just entered.
+	StatusDuplicate = "duplicate"
+	// StatusReview reads wait for an attendant, who resolves or dismisses
+	// them.
+	StatusReview    = "review"
+	StatusResolved  = "resolved"
+	StatusDismissed = "dismissed"
+)
+
+var (
+	ErrInvalid  = errors.New("invalid plate read")
+	ErrNotFound = errors.New("plate read not found")
+	ErrReviewed = errors.New("plate read is not waiting for review")
+)
+
+type Read struct {
+	ID         int     `json:"id"`
+	Plate      string  `json:"plate"`
+	Confidence float64 `json:"confidence"`
+	Lane       string  `json:"lane"`
+	// ImageRef points at the camera's picture of the vehicle.
+	ImageRef string    `json:"image_ref,omitempty"`
+	ReadAt   time.Time `json:"read_at"`
+	// GateID and Direction are those of the gate at Lane.
+	GateID    string `json:"gate_id"`
+	Direction string `json:"direction"`
+	Status    string `json:"status"`
+	// Reason says why a read is waiting for review.
+	Reason string `json:"reason,omitempty"`
+	// MatchedPlate is the plate the stay is recorded under, which differs
+	// from Plate when the read was matched fuzzily or corrected.
+	MatchedPlate string `json:"matched_plate,omitempty"`
+	StayID       int    `json:"stay_id,omitempty"`
+	ReviewedBy   string `json:"reviewed_by,omitempty"`
+	// Gate is how the gate answered; it is only set on the response that
+	// opened it.
+	Gate *parking.GateResult `json:"gate,omitempty"`
+}
+
+// Valid checks what the camera sent.
+func (r Read) Valid() error {
+	switch {
+	case Normalize(r.Plate) == "":
+		return fmt.Errorf("%w: plate is required", ErrInvalid)
+	case r.Lane == "":
+		return fmt.Errorf("%w: lane is required", ErrInvalid)
+	case r.Confidence < 0 || r.Confidence > 1:
+		return fmt.Errorf("%w: confidence must be between 0 and 1", ErrInvalid)
+	}
+	return nil
+}
+
+type Service struct {
+	DB    *sql.DB
+	Audit *audit.Logger
+	// MinConfidence is the confidence from which reads are acted on without
+	// review.
+	MinConfidence float64
+}
+
+func New(db *sql.DB, minConfidence float64) *Service {
+	return &Service{DB: db, MinConfidence: minConfidence}
+}
+
+// NewFromEnv reads the threshold from PDEA_ANPR_MIN_CONFIDENCE, 0.9 if
+// unset.
+func NewFromEnv(db *sql.DB) (*Service, error) {
+	minConfidence := 0.9
+	if v := os.Getenv("PDEA_ANPR_MIN_CONFIDENCE"); v != "" {
+		var err error
+		if minConfidence, err = strconv.ParseFloat(v, 64); err != nil || minConfidence < 0 || minConfidence > 1 {
+			return nil, fmt.Errorf("invalid PDEA_ANPR_MIN_CONFIDENCE %q", v)
+		}
+	}
+	return New(db, minConfidence), nil
+}
+
+func (s *Service) Migrate() error {
+	schemaSQL := `
+CREATE TABLE IF NOT EXISTS plate_reads (
+id SERIAL PRIMARY KEY,
+plate TEXT NOT NULL,
+confidence DOUBLE PRECISION NOT NULL,
+lane TEXT NOT NULL,
+image_ref TEXT NOT NULL DEFAULT '',
+read_at TIMESTAMPTZ NOT NULL,
+gate_id TEXT NOT NULL,
+direction TEXT NOT NULL,
+status TEXT NOT NULL,
+reason TEXT NOT NULL DEFAULT '',
+matched_plate TEXT NOT NULL DEFAULT '',
+stay_id INTEGER,
+reviewed_by TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS plate_reads_status ON plate_reads (status, id);
+`
+	_, err := s.DB.Exec(schemaSQL)
+	return err
+}
+
+// Confident reports whether r can be acted on without review.
+func (s *Service) Confident(r Read) bool {
+	return r.Confidence >= s.MinConfidence
+}
+
+const readColumns = `id, plate, confidence, lane, image_ref, read_at, gate_id, direction, status, reason, matched_plate, coalesce(stay_id, 0), reviewed_by`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanRead(row scanner) (Read, error) {
+	var r Read
+	err := row.Scan(&r.ID, &r.Plate, &r.Confidence, &r.Lane, &r.ImageRef, &r.ReadAt, &r.GateID, &r.Direction, &r.Status, &r.Reason, &r.MatchedPlate, &r.StayID, &r.ReviewedBy)
+	if err == sql.ErrNoRows {
+		return r, ErrNotFound
+	}
+	return r, err
+}
+
+func nullInt(n int) any {
+	if n == 0 {
+		return nil
+	}
+	return n
+}
+
+// Save stores a new read with the outcome already decided and sets its ID.
+func (s *Service) Save(r *Read) error {
+	qr := `INSERT INTO plate_reads(plate, confidence, lane, image_ref, read_at, gate_id, direction, status, reason, matched_plate, stay_id, reviewed_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) returning id`
+	return s.DB.QueryRow(qr, r.Plate, r.Confidence, r.Lane, r.ImageRef, r.ReadAt, r.GateID, r.Direction, r.Status, r.Reason, r.MatchedPlate, nullInt(r.StayID), r.ReviewedBy).Scan(&r.ID)
+}
+
+func (s *Service) Get(id int) (Read, error) {
+	return scanRead(s.DB.QueryRow(`select `+readColumns+` from plate_reads where id = $1`, id))
+}
+
+// List returns the latest reads, newest first, optionally only those with
+// status.
+func (s *Service) List(status string, limit int) ([]Read, error) {
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	qr := `select ` + readColumns + ` from plate_reads where ($1 = '' or status = $1) order by id desc limit $2`
+	rows, err := s.DB.Query(qr, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := []Read{}
+	for rows.Next() {
+		r, err := scanRead(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, r)
+	}
+	return res, rows.Err()
+}
+
+// Close takes a read off the review queue with the attendant's outcome.
+// ErrReviewed means it was not, or no longer, waiting for review.
+func (s *Service) Close(r Read) error {
+	qr := `UPDATE plate_reads SET status = $1, matched_plate = $2, stay_id = $3, reviewed_by = $4 where id = $5 and status = $6`
+	res, err := s.DB.Exec(qr, r.Status, r.MatchedPlate, nullInt(r.StayID), r.ReviewedBy, r.ID, StatusReview)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrReviewed
+	}
+	return nil
+}
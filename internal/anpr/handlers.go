@@ -0,0 +1,101 @@
+package anpr
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"PDEA/internal/audit"
+	"PDEA/internal/logging"
+
+	"github.com/gorilla/mux"
+)
+
+// RegisterRoutes adds the read lookups and dismissal. Ingesting and
+// resolving reads is left to the vehicle service, which turns them into
+// entries and exits.
+func (s *Service) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/plate-reads", s.ListHandler).Methods("GET")
+	router.HandleFunc("/api/plate-reads/{id}", s.GetHandler).Methods("GET")
+	router.HandleFunc("/api/plate-reads/{id}/dismiss", s.DismissHandler).Methods("POST")
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	resJson, _ := json.Marshal(v)
+	w.WriteHeader(status)
+	w.Write(resJson)
+}
+
+// WriteError maps the package's errors to responses.
+func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case err == ErrNotFound:
+		http.Error(w, "Plate read not found", http.StatusNotFound)
+	case err == ErrReviewed:
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, ErrInvalid):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		logging.FromContext(r.Context()).Error("plate read failed", "error", err)
+		http.Error(w, "Server error", http.StatusInternalServerError)
+	}
+}
+
+// ReadID parses the {id} path variable, answering 400 if it is not a
+// number.
+func ReadID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid id", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+// ListHandler lists the latest reads; ?status=review gives the review
+// queue.
+func (s *Service) ListHandler(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	res, err := s.List(r.URL.Query().Get("status"), limit)
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *Service) GetHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := ReadID(w, r)
+	if !ok {
+		return
+	}
+	rd, err := s.Get(id)
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, rd)
+}
+
+// DismissHandler drops a read from the review queue without acting on it,
+// for instance a read of a passing vehicle.
+func (s *Service) DismissHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := ReadID(w, r)
+	if !ok {
+		return
+	}
+	before, err := s.Get(id)
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+	rd := before
+	rd.Status, rd.ReviewedBy = StatusDismissed, audit.ActorFromRequest(r)
+	if err = s.Close(rd); err != nil {
+		WriteError(w, r, err)
+		return
+	}
+	s.Audit.Record(r, "dismiss", "plate_read", strconv.Itoa(id), before, rd)
+	writeJSON(w, http.StatusOK, rd)
+}
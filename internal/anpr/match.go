@@ -0,0 +1,89 @@
+package anpr
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+var (
+	ErrNoMatch   = errors.New("no open stay matches the plate read")
+	ErrAmbiguous = errors.New("plate read matches more than one open stay")
+)
+
+// MaxDistance is how many characters a read may get wrong, after folding
+// look-alikes, and still match a plate.
+const MaxDistance = 1
+
+// Normalize uppercases plate and drops everything but letters and digits,
+// so "ka-01 ab 1234" and "KA01AB1234" compare equal.
+func Normalize(plate string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(plate) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// lookalikes folds characters OCR commonly mistakes for one another onto a
+// single one.
+var lookalikes = strings.NewReplacer("O", "0", "Q", "0", "D", "0", "I", "1", "L", "1", "Z", "2", "S", "5", "B", "8", "G", "6")
+
+func fold(plate string) string {
+	return lookalikes.Replace(Normalize(plate))
+}
+
+// Match returns the candidate plate a read most likely is. An exact match
+// after Normalize wins; otherwise look-alike characters are folded and the
+// closest candidate within MaxDistance edits is taken. Several candidates
+// equally close give ErrAmbiguous.
+func Match(read string, candidates []string) (string, error) {
+	n := Normalize(read)
+	for _, c := range candidates {
+		if Normalize(c) == n {
+			return c, nil
+		}
+	}
+	f := fold(read)
+	best, bestDist, tied := "", MaxDistance+1, false
+	for _, c := range candidates {
+		d := distance(f, fold(c))
+		switch {
+		case d < bestDist:
+			best, bestDist, tied = c, d, false
+		case d == bestDist && Normalize(c) != Normalize(best):
+			tied = true
+		}
+	}
+	switch {
+	case best == "":
+		return "", ErrNoMatch
+	case tied:
+		return "", ErrAmbiguous
+	}
+	return best, nil
+}
+
+// distance is the Levenshtein distance between a and b.
+func distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,69 @@
+package anpr
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"KA01AB1234", "KA01AB1234"},
+		{"ka-01 ab 1234", "KA01AB1234"},
+		{" mh.12.de.1433 ", "MH12DE1433"},
+		{"--", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := Normalize(tt.in); got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"ABC", "ABC", 0},
+		{"ABC", "ABD", 1},
+		{"ABC", "AC", 1},
+		{"AC", "ABC", 1},
+		{"ABC", "", 3},
+		{"KA01AB1234", "KA01BA1234", 2},
+	}
+	for _, tt := range tests {
+		if got := distance(tt.a, tt.b); got != tt.want {
+			t.Errorf("distance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		read       string
+		candidates []string
+		want       string
+		err        error
+	}{
+		{"exact", "KA01AB1234", []string{"KA01AB1234", "KA01AB1235"}, "KA01AB1234", nil},
+		{"exact after normalizing", "ka 01 ab-1234", []string{"KA01AB1234"}, "KA01AB1234", nil},
+		{"exact wins over close", "KA01AB1234", []string{"KA01AB1235", "KA01AB1234"}, "KA01AB1234", nil},
+		{"look-alikes folded", "KAO1A81234", []string{"KA01AB1234"}, "KA01AB1234", nil},
+		{"one edit", "KA01AB123", []string{"KA01AB1234"}, "KA01AB1234", nil},
+		{"one substitution", "KA01AB1264", []string{"KA01AB1234", "MH12DE1433"}, "KA01AB1234", nil},
+		{"too far", "KA01AB9999", []string{"KA01AB1234"}, "", ErrNoMatch},
+		{"no candidates", "KA01AB1234", nil, "", ErrNoMatch},
+		{"tied", "KA01AB1230", []string{"KA01AB1231", "KA01AB1232"}, "", ErrAmbiguous},
+		{"same plate twice is not a tie", "KA01AB123", []string{"KA01AB1234", "ka01ab1234"}, "KA01AB1234", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Match(tt.read, tt.candidates)
+			if got != tt.want || err != tt.err {
+				t.Errorf("Match(%q, %q) = %q, %v, want %q, %v", tt.read, tt.candidates, got, err, tt.want, tt.err)
+			}
+		})
+	}
+}
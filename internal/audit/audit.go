@@ -0,0 +1,204 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"PDEA/internal/logging"
+)
+
+type Entry struct {
+	ID       int             `json:"id"`
+	Service  string          `json:"service"`
+	Actor    string          `json:"actor"`
+	Action   string          `json:"action"`
+	Resource string          `json:"resource"`
+	Target   string          `json:"target"`
+	Before   json.RawMessage `json:"before,omitempty"`
+	After    json.RawMessage `json:"after,omitempty"`
+	At       time.Time       `json:"at"`
+}
+
+type Filter struct {
+	Actor    string
+	Resource string
+	From     time.Time
+	To       time.Time
+	Limit    int
+}
+
+type Logger struct {
+	DB      *sql.DB
+	Service string
+}
+
+func New(db *sql.DB, service string) *Logger {
+	return &Logger{DB: db, Service: service}
+}
+
+func (l *Logger) Migrate() error {
+	schemaSQL := `
+CREATE TABLE IF NOT EXISTS audit_log (
+id SERIAL PRIMARY KEY,
+service TEXT NOT NULL,
+actor TEXT NOT NULL,
+action TEXT NOT NULL,
+resource TEXT NOT NULL,
+target TEXT NOT NULL,
+before JSONB,
+after JSONB,
+at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_at ON audit_log (at);
+`
+	_, err := l.DB.Exec(schemaSQL)
+	return err
+}
+
+type actorKey struct{}
+
+// WithActor attaches the authenticated caller to ctx so Record can attribute
+// the change to it.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor attached with WithActor, or "".
+func ActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
+
+func ActorFromRequest(r *http.Request) string {
+	if actor := ActorFromContext(r.Context()); actor != "" {
+		return actor
+	}
+	if actor := r.Header.Get("X-Actor"); actor != "" {
+		return actor
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "anonymous@" + host
+}
+
+// ContextFromRequest returns r's context with the actor resolved as for
+// Record, for handing to code that records with RecordContext.
+func ContextFromRequest(r *http.Request) context.Context {
+	return WithActor(r.Context(), ActorFromRequest(r))
+}
+
+func toJSON(v any) any {
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return string(data)
+}
+
+// Record stores one audit entry. Failures are logged rather than returned so
+// that the change being audited, which has already happened, is still
+// reported to the caller.
+func (l *Logger) Record(r *http.Request, action, resource, target string, before, after any) {
+	l.RecordContext(ContextFromRequest(r), action, resource, target, before, after)
+}
+
+// RecordContext is Record for callers without an *http.Request, such as the
+// gRPC API. The actor is taken from ctx.
+func (l *Logger) RecordContext(ctx context.Context, action, resource, target string, before, after any) {
+	if l == nil {
+		return
+	}
+	actor := ActorFromContext(ctx)
+	if actor == "" {
+		actor = "anonymous"
+	}
+	qr := `INSERT INTO audit_log (service, actor, action, resource, target, before, after, at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8);`
+	_, err := l.DB.Exec(qr, l.Service, actor, action, resource, target, toJSON(before), toJSON(after), time.Now().UTC())
+	if err != nil {
+		logging.FromContext(ctx).Error("audit record failed", "error", err)
+	}
+}
+
+func (l *Logger) Query(f Filter) ([]Entry, error) {
+	if f.Limit <= 0 || f.Limit > 1000 {
+		f.Limit = 100
+	}
+	var from, to any
+	if !f.From.IsZero() {
+		from = f.From
+	}
+	if !f.To.IsZero() {
+		to = f.To
+	}
+	qr := `select id, service, actor, action, resource, target, before, after, at from audit_log
+where ($1 = '' or actor = $1) and ($2 = '' or resource = $2)
+and ($3::timestamptz is null or at >= $3) and ($4::timestamptz is null or at < $4)
+order by at desc, id desc limit $5;`
+	rows, err := l.DB.Query(qr, f.Actor, f.Resource, from, to, f.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []Entry
+	for rows.Next() {
+		var e Entry
+		var before, after []byte
+		if err := rows.Scan(&e.ID, &e.Service, &e.Actor, &e.Action, &e.Resource, &e.Target, &before, &after, &e.At); err != nil {
+			return nil, err
+		}
+		if before != nil {
+			e.Before = before
+		}
+		if after != nil {
+			e.After = after
+		}
+		res = append(res, e)
+	}
+	return res, rows.Err()
+}
+
+// ListHandler serves GET /api/admin/audit. Supported query parameters are
+// actor, resource, from and to (RFC 3339) and limit.
+func (l *Logger) ListHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	f := Filter{Actor: q.Get("actor"), Resource: q.Get("resource")}
+	var err error
+	if v := q.Get("from"); v != "" {
+		if f.From, err = time.Parse(time.RFC3339, v); err != nil {
+			http.Error(w, "Invalid from time, expected RFC 3339", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if f.To, err = time.Parse(time.RFC3339, v); err != nil {
+			http.Error(w, "Invalid to time, expected RFC 3339", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		if f.Limit, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+	res, err := l.Query(f)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("audit query failed", "error", err)
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+	resJson, _ := json.Marshal(res)
+	w.WriteHeader(http.StatusOK)
+	w.Write(resJson)
+}
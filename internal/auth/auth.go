@@ -0,0 +1,178 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"PDEA/internal/audit"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	RoleGate      = "gate"
+	RoleAttendant = "attendant"
+	RoleAdmin     = "admin"
+	RoleAnalyst   = "analyst"
+	// RoleMerchant is for shops that validate parking. It is left out of
+	// AllRoles, so it only reaches routes that name it.
+	RoleMerchant = "merchant"
+
+	// Anonymous in a policy entry opens the route to unauthenticated callers.
+	Anonymous = "*"
+)
+
+var AllRoles = []string{RoleGate, RoleAttendant, RoleAdmin, RoleAnalyst}
+
+func ValidRole(role string) bool {
+	if role == RoleMerchant {
+		return true
+	}
+	for _, r := range AllRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+type Principal struct {
+	Name   string `json:"name"`
+	Role   string `json:"role"`
+	Method string `json:"method"`
+}
+
+func (p Principal) String() string {
+	return fmt.Sprintf("%s:%s", p.Method, p.Name)
+}
+
+type principalKey struct{}
+
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// Policy maps "METHOD /route/template" to the roles allowed to call it.
+// Admins may call every route; routes missing from the policy are admin only.
+type Policy map[string][]string
+
+func (p Policy) Allows(method, template, role string) bool {
+	if role == RoleAdmin {
+		return true
+	}
+	for _, r := range p[method+" "+template] {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+var ErrUnauthenticated = errors.New("missing or invalid credentials")
+
+type Authenticator struct {
+	Keys      *KeyStore
+	JWTSecret []byte
+	Disabled  bool
+	// QueryToken lists the routes, as "METHOD /route/template", that accept
+	// the credential as the access_token query parameter. Browsers'
+	// EventSource cannot set headers, so streams need it; elsewhere it
+	// would only leak credentials into logs and browser history.
+	QueryToken map[string]bool
+}
+
+// NewFromEnv configures authentication from PDEA_JWT_SECRET,
+// PDEA_BOOTSTRAP_ADMIN_KEY and PDEA_AUTH (set to "off" for local development).
+func NewFromEnv(db *sql.DB) (*Authenticator, error) {
+	a := &Authenticator{Keys: NewKeyStore(db), Disabled: os.Getenv("PDEA_AUTH") == "off"}
+	if secret := os.Getenv("PDEA_JWT_SECRET"); secret != "" {
+		a.JWTSecret = []byte(secret)
+	}
+	if err := a.Keys.Migrate(); err != nil {
+		return a, err
+	}
+	if key := os.Getenv("PDEA_BOOTSTRAP_ADMIN_KEY"); key != "" {
+		if err := a.Keys.Ensure("bootstrap-admin", RoleAdmin, key); err != nil {
+			return a, err
+		}
+	}
+	return a, nil
+}
+
+// Authenticate checks the credential of a request to route, given as
+// "METHOD /route/template".
+func (a *Authenticator) Authenticate(r *http.Request, route string) (Principal, error) {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return a.Keys.Lookup(key)
+	}
+	scheme, cred, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok {
+		token := r.URL.Query().Get("access_token")
+		if token == "" || !a.QueryToken[route] {
+			return Principal{}, ErrUnauthenticated
+		}
+		scheme, cred = "apikey", token
+		if strings.Count(token, ".") == 2 {
+			scheme = "bearer"
+		}
+	}
+	return a.AuthenticateCredential(scheme, cred)
+}
+
+// AuthenticateCredential checks an API key (scheme "ApiKey") or a JWT
+// (scheme "Bearer") however it reached the service.
+func (a *Authenticator) AuthenticateCredential(scheme, cred string) (Principal, error) {
+	switch strings.ToLower(scheme) {
+	case "apikey":
+		return a.Keys.Lookup(cred)
+	case "bearer":
+		if a.JWTSecret == nil {
+			return Principal{}, ErrUnauthenticated
+		}
+		c, err := ParseJWT(a.JWTSecret, cred, time.Now())
+		if err != nil || c.Subject == "" || !ValidRole(c.Role) {
+			return Principal{}, ErrUnauthenticated
+		}
+		return Principal{Name: c.Subject, Role: c.Role, Method: "jwt"}, nil
+	}
+	return Principal{}, ErrUnauthenticated
+}
+
+// Middleware authenticates every request and checks the matched mux route
+// against policy. It must be installed with router.Use so the route is known.
+func (a *Authenticator) Middleware(policy Policy) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			template := r.URL.Path
+			if route := mux.CurrentRoute(r); route != nil {
+				if t, err := route.GetPathTemplate(); err == nil {
+					template = t
+				}
+			}
+			if a.Disabled || policy.Allows(r.Method, template, Anonymous) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			p, err := a.Authenticate(r, r.Method+" "+template)
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="pdea", ApiKey realm="pdea"`)
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			if !policy.Allows(r.Method, template, p.Role) {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+			ctx := context.WithValue(r.Context(), principalKey{}, p)
+			ctx = audit.WithActor(ctx, p.String())
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAuthenticateQueryToken(t *testing.T) {
+	secret := []byte("test-secret")
+	a := &Authenticator{JWTSecret: secret, QueryToken: map[string]bool{"GET /api/events/stream": true}}
+	token, err := SignJWT(secret, Claims{Subject: "board", Role: RoleAnalyst, ExpiresAt: time.Now().Add(time.Hour).Unix()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		route  string
+		header bool
+		ok     bool
+	}{
+		{"stream", http.MethodGet, "/api/events/stream", "GET /api/events/stream", false, true},
+		{"other GET", http.MethodGet, "/api/history/events", "GET /api/history/events", false, false},
+		{"POST to the stream path", http.MethodPost, "/api/events/stream", "POST /api/events/stream", false, false},
+		{"header on other GET", http.MethodGet, "/api/history/events", "GET /api/history/events", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.header {
+				r.Header.Set("Authorization", "Bearer "+token)
+			} else {
+				r.URL.RawQuery = "access_token=" + token
+			}
+			p, err := a.Authenticate(r, tt.route)
+			if tt.ok && (err != nil || p.Name != "board") {
+				t.Errorf("Authenticate = %+v, %v, want board", p, err)
+			}
+			if !tt.ok && err != ErrUnauthenticated {
+				t.Errorf("Authenticate = %+v, %v, want ErrUnauthenticated", p, err)
+			}
+		})
+	}
+}
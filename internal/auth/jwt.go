@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+type Claims struct {
+	Subject   string `json:"sub"`
+	Role      string `json:"role"`
+	Issuer    string `json:"iss,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+	NotBefore int64  `json:"nbf,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+}
+
+var (
+	ErrTokenMalformed = errors.New("malformed token")
+	ErrTokenSignature = errors.New("invalid token signature")
+	ErrTokenExpired   = errors.New("token expired or not yet valid")
+	ErrTokenNoExpiry  = errors.New("token has no expiry")
+)
+
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+func SignJWT(secret []byte, c Claims) (string, error) {
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	signing := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signing))
+	return signing + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// ParseJWT verifies an HS256 token and its time claims. Other algorithms are
+// rejected outright, including "none", and so are tokens without an exp
+// claim, which would otherwise never expire.
+func ParseJWT(secret []byte, token string, now time.Time) (Claims, error) {
+	var c Claims
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return c, ErrTokenMalformed
+	}
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return c, ErrTokenMalformed
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := json.Unmarshal(headerJSON, &header); err != nil || header.Alg != "HS256" {
+		return c, ErrTokenMalformed
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return c, ErrTokenMalformed
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return c, ErrTokenSignature
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return c, ErrTokenMalformed
+	}
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return c, ErrTokenMalformed
+	}
+	if c.ExpiresAt == 0 {
+		return c, ErrTokenNoExpiry
+	}
+	if now.Unix() >= c.ExpiresAt {
+		return c, ErrTokenExpired
+	}
+	if c.NotBefore != 0 && now.Unix() < c.NotBefore {
+		return c, ErrTokenExpired
+	}
+	return c, nil
+}
@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+)
+
+// forge signs payload under an arbitrary header, for tokens SignJWT would
+// never produce.
+func forge(secret []byte, header, payload string) string {
+	enc := base64.RawURLEncoding
+	signing := enc.EncodeToString([]byte(header)) + "." + enc.EncodeToString([]byte(payload))
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signing))
+	return signing + "." + enc.EncodeToString(mac.Sum(nil))
+}
+
+func TestParseJWT(t *testing.T) {
+	secret := []byte("secret")
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	sign := func(c Claims) string {
+		token, err := SignJWT(secret, c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	valid := sign(Claims{Subject: "alice", Role: RoleAttendant, ExpiresAt: now.Add(time.Hour).Unix()})
+	parts := strings.Split(valid, ".")
+
+	tests := []struct {
+		name  string
+		token string
+		want  Claims
+		err   error
+	}{
+		{"valid", valid, Claims{Subject: "alice", Role: RoleAttendant, ExpiresAt: now.Add(time.Hour).Unix()}, nil},
+		{"no expiry", sign(Claims{Subject: "svc", Role: RoleGate}), Claims{}, ErrTokenNoExpiry},
+		{"expired", sign(Claims{Subject: "alice", ExpiresAt: now.Add(-time.Second).Unix()}), Claims{}, ErrTokenExpired},
+		{"expires now", sign(Claims{Subject: "alice", ExpiresAt: now.Unix()}), Claims{}, ErrTokenExpired},
+		{"not yet valid", sign(Claims{Subject: "alice", ExpiresAt: now.Add(time.Hour).Unix(), NotBefore: now.Add(time.Minute).Unix()}), Claims{}, ErrTokenExpired},
+		{"valid from now", sign(Claims{Subject: "alice", ExpiresAt: now.Add(time.Hour).Unix(), NotBefore: now.Unix()}), Claims{Subject: "alice", ExpiresAt: now.Add(time.Hour).Unix(), NotBefore: now.Unix()}, nil},
+		{"other secret", func() string { token, _ := SignJWT([]byte("other"), Claims{Subject: "alice"}); return token }(), Claims{}, ErrTokenSignature},
+		{"payload swapped", parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"alice","role":"admin"}`)) + "." + parts[2], Claims{}, ErrTokenSignature},
+		{"signature stripped", parts[0] + "." + parts[1] + ".", Claims{}, ErrTokenSignature},
+		{"alg none", forge(secret, `{"alg":"none","typ":"JWT"}`, `{"sub":"alice"}`), Claims{}, ErrTokenMalformed},
+		{"alg none unsigned", base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + "." + parts[1] + ".", Claims{}, ErrTokenMalformed},
+		{"alg HS512", forge(secret, `{"alg":"HS512","typ":"JWT"}`, `{"sub":"alice"}`), Claims{}, ErrTokenMalformed},
+		{"alg RS256", forge(secret, `{"alg":"RS256","typ":"JWT"}`, `{"sub":"alice"}`), Claims{}, ErrTokenMalformed},
+		{"alg lowercase", forge(secret, `{"alg":"hs256","typ":"JWT"}`, `{"sub":"alice"}`), Claims{}, ErrTokenMalformed},
+		{"header not json", forge(secret, `HS256`, `{"sub":"alice"}`), Claims{}, ErrTokenMalformed},
+		{"payload not json", forge(secret, `{"alg":"HS256"}`, `alice`), Claims{}, ErrTokenMalformed},
+		{"two parts", parts[0] + "." + parts[1], Claims{}, ErrTokenMalformed},
+		{"four parts", valid + ".x", Claims{}, ErrTokenMalformed},
+		{"bad base64", "!!." + parts[1] + "." + parts[2], Claims{}, ErrTokenMalformed},
+		{"empty", "", Claims{}, ErrTokenMalformed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseJWT(secret, tt.token, now)
+			if err != tt.err {
+				t.Fatalf("ParseJWT(%q) error = %v, want %v", tt.token, err, tt.err)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("ParseJWT(%q) = %+v, want %+v", tt.token, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"PDEA/internal/audit"
+	"PDEA/internal/logging"
+
+	"github.com/gorilla/mux"
+)
+
+// Keys are only ever stored as SHA-256 hashes; the plaintext is returned
+// once, when the key is created. The prefix is kept so operators can tell
+// keys apart in listings.
+type APIKey struct {
+	ID        int        `json:"id"`
+	Name      string     `json:"name"`
+	Role      string     `json:"role"`
+	Prefix    string     `json:"prefix"`
+	Key       string     `json:"key,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+type KeyStore struct {
+	DB    *sql.DB
+	Audit *audit.Logger
+}
+
+func NewKeyStore(db *sql.DB) *KeyStore {
+	return &KeyStore{DB: db}
+}
+
+func (k *KeyStore) Migrate() error {
+	schemaSQL := `
+CREATE TABLE IF NOT EXISTS api_keys (
+id SERIAL PRIMARY KEY,
+name TEXT NOT NULL,
+role TEXT NOT NULL,
+prefix TEXT NOT NULL,
+key_hash TEXT NOT NULL UNIQUE,
+created_at TIMESTAMPTZ NOT NULL,
+revoked_at TIMESTAMPTZ
+);
+`
+	_, err := k.DB.Exec(schemaSQL)
+	return err
+}
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func keyPrefix(key string) string {
+	if len(key) > 12 {
+		return key[:12]
+	}
+	return key
+}
+
+func (k *KeyStore) Create(name, role string) (APIKey, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return APIKey{}, err
+	}
+	key := APIKey{Name: name, Role: role, Key: "pdea_" + hex.EncodeToString(buf), CreatedAt: time.Now().UTC()}
+	key.Prefix = keyPrefix(key.Key)
+	qr := `INSERT INTO api_keys (name, role, prefix, key_hash, created_at) VALUES ($1, $2, $3, $4, $5) RETURNING id;`
+	err := k.DB.QueryRow(qr, key.Name, key.Role, key.Prefix, hashKey(key.Key), key.CreatedAt).Scan(&key.ID)
+	return key, err
+}
+
+// Ensure registers a caller-chosen key, used for the bootstrap admin key.
+func (k *KeyStore) Ensure(name, role, key string) error {
+	qr := `INSERT INTO api_keys (name, role, prefix, key_hash, created_at) VALUES ($1, $2, $3, $4, $5) ON CONFLICT (key_hash) DO NOTHING;`
+	_, err := k.DB.Exec(qr, name, role, keyPrefix(key), hashKey(key), time.Now().UTC())
+	return err
+}
+
+func (k *KeyStore) Lookup(key string) (Principal, error) {
+	var p Principal
+	qr := `select name, role from api_keys where key_hash = $1 and revoked_at is null;`
+	err := k.DB.QueryRow(qr, hashKey(key)).Scan(&p.Name, &p.Role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return p, ErrUnauthenticated
+	}
+	p.Method = "key"
+	return p, err
+}
+
+func (k *KeyStore) List() ([]APIKey, error) {
+	rows, err := k.DB.Query(`select id, name, role, prefix, created_at, revoked_at from api_keys order by id;`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []APIKey
+	for rows.Next() {
+		var key APIKey
+		var revoked sql.NullTime
+		if err := rows.Scan(&key.ID, &key.Name, &key.Role, &key.Prefix, &key.CreatedAt, &revoked); err != nil {
+			return nil, err
+		}
+		if revoked.Valid {
+			key.RevokedAt = &revoked.Time
+		}
+		res = append(res, key)
+	}
+	return res, rows.Err()
+}
+
+func (k *KeyStore) Revoke(id int) (bool, error) {
+	res, err := k.DB.Exec(`UPDATE api_keys SET revoked_at = $1 where id = $2 and revoked_at is null;`, time.Now().UTC(), id)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+func (k *KeyStore) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/admin/keys", k.CreateHandler).Methods("POST")
+	router.HandleFunc("/api/admin/keys", k.ListHandler).Methods("GET")
+	router.HandleFunc("/api/admin/keys/{id}", k.RevokeHandler).Methods("DELETE")
+}
+
+func (k *KeyStore) CreateHandler(w http.ResponseWriter, r *http.Request) {
+	var reqBody APIKey
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if reqBody.Name == "" || !ValidRole(reqBody.Role) {
+		http.Error(w, "Invalid key. name is required and role must be one of: gate, attendant, admin, analyst, merchant.", http.StatusBadRequest)
+		return
+	}
+	key, err := k.Create(reqBody.Name, reqBody.Role)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("create api key failed", "error", err)
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+	logged := key
+	logged.Key = ""
+	k.Audit.Record(r, "create", "api_key", strconv.Itoa(key.ID), nil, logged)
+	resJson, _ := json.Marshal(key)
+	w.WriteHeader(http.StatusCreated)
+	w.Write(resJson)
+}
+
+func (k *KeyStore) ListHandler(w http.ResponseWriter, r *http.Request) {
+	keys, err := k.List()
+	if err != nil {
+		logging.FromContext(r.Context()).Error("list api keys failed", "error", err)
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+	resJson, _ := json.Marshal(keys)
+	w.WriteHeader(http.StatusOK)
+	w.Write(resJson)
+}
+
+func (k *KeyStore) RevokeHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid id", http.StatusBadRequest)
+		return
+	}
+	found, err := k.Revoke(id)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("revoke api key failed", "error", err)
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		http.Error(w, "Key not found", http.StatusNotFound)
+		return
+	}
+	k.Audit.Record(r, "revoke", "api_key", strconv.Itoa(id), nil, nil)
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,203 @@
+// Package charging tracks EV charging sessions. A session belongs to a stay
+// at a spot with a charger; the charger reports the energy delivered so far
+// while it runs, and the session is priced at the per-kWh rate in force when
+// it started. Sessions still running when the stay exits are stopped then.
+package charging
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"PDEA/internal/audit"
+	"PDEA/internal/parking"
+	"PDEA/internal/pricing"
+)
+
+var (
+	ErrNotFound  = errors.New("charging session not found")
+	ErrNoCharger = errors.New("parking spot has no charger")
+	ErrActive    = errors.New("stay already has a charging session running")
+	ErrStopped   = errors.New("charging session already stopped")
+	// ErrMeter is returned for readings that go backwards or exceed what
+	// the charger could have delivered since the session started.
+	ErrMeter = errors.New("implausible meter reading")
+)
+
+// meterSlack allows for clock differences between the charger and us when
+// checking a reading against the charger's power.
+const meterSlack = time.Minute
+
+type Session struct {
+	ID          int        `json:"id"`
+	StayID      int        `json:"stay_id"`
+	SpotNumber  string     `json:"spot_number"`
+	Connector   string     `json:"connector"`
+	MaxKW       float64    `json:"max_kw"`
+	CentsPerKWh int        `json:"cents_per_kwh"`
+	StartedAt   time.Time  `json:"started_at"`
+	StoppedAt   *time.Time `json:"stopped_at,omitempty"`
+	EnergyWh    int        `json:"energy_wh"`
+	Cents       int        `json:"cents"`
+}
+
+type Service struct {
+	DB          *sql.DB
+	Audit       *audit.Logger
+	CentsPerKWh int
+}
+
+func New(db *sql.DB, centsPerKWh int) *Service {
+	return &Service{DB: db, CentsPerKWh: centsPerKWh}
+}
+
+// NewFromEnv prices energy at PDEA_ENERGY_CENTS_PER_KWH, free if unset.
+func NewFromEnv(db *sql.DB) (*Service, error) {
+	cents := 0
+	if v := os.Getenv("PDEA_ENERGY_CENTS_PER_KWH"); v != "" {
+		var err error
+		if cents, err = strconv.Atoi(v); err != nil || cents < 0 {
+			return nil, fmt.Errorf("invalid PDEA_ENERGY_CENTS_PER_KWH %q", v)
+		}
+	}
+	return New(db, cents), nil
+}
+
+func (s *Service) Migrate() error {
+	schemaSQL := `
+CREATE TABLE IF NOT EXISTS charging_sessions (
+id SERIAL PRIMARY KEY,
+stay_id INTEGER NOT NULL,
+spot_number TEXT NOT NULL,
+connector TEXT NOT NULL,
+max_kw DOUBLE PRECISION NOT NULL,
+cents_per_kwh INTEGER NOT NULL,
+started_at TIMESTAMPTZ NOT NULL,
+stopped_at TIMESTAMPTZ,
+energy_wh INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS charging_sessions_stay ON charging_sessions (stay_id);
+CREATE UNIQUE INDEX IF NOT EXISTS charging_sessions_active ON charging_sessions (stay_id) WHERE stopped_at IS NULL;
+`
+	_, err := s.DB.Exec(schemaSQL)
+	return err
+}
+
+// Cost prices energyWh at centsPerKWh, rounded half up to whole cents.
+func Cost(energyWh, centsPerKWh int) int {
+	return (energyWh*centsPerKWh + 500) / 1000
+}
+
+const sessionColumns = `id, stay_id, spot_number, connector, max_kw, cents_per_kwh, started_at, stopped_at, energy_wh`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSession(row scanner) (Session, error) {
+	var se Session
+	var stopped sql.NullTime
+	err := row.Scan(&se.ID, &se.StayID, &se.SpotNumber, &se.Connector, &se.MaxKW, &se.CentsPerKWh, &se.StartedAt, &stopped, &se.EnergyWh)
+	if err == sql.ErrNoRows {
+		return se, ErrNotFound
+	}
+	if stopped.Valid {
+		se.StoppedAt = &stopped.Time
+	}
+	se.Cents = Cost(se.EnergyWh, se.CentsPerKWh)
+	return se, err
+}
+
+// Start opens a session for a stay parked at spot.
+func (s *Service) Start(stayID int, spot parking.ParkingSpot) (Session, error) {
+	if !spot.HasCharger() {
+		return Session{}, ErrNoCharger
+	}
+	qr := `INSERT INTO charging_sessions(stay_id, spot_number, connector, max_kw, cents_per_kwh, started_at) VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (stay_id) WHERE stopped_at IS NULL DO NOTHING returning ` + sessionColumns
+	se, err := scanSession(s.DB.QueryRow(qr, stayID, spot.SpotNumber, spot.Connector, spot.MaxKW, s.CentsPerKWh, time.Now().UTC()))
+	if err == ErrNotFound {
+		return se, ErrActive
+	}
+	return se, err
+}
+
+func (s *Service) Get(id int) (Session, error) {
+	return scanSession(s.DB.QueryRow(`select `+sessionColumns+` from charging_sessions where id = $1`, id))
+}
+
+// ForStay lists a stay's sessions, oldest first.
+func (s *Service) ForStay(stayID int) ([]Session, error) {
+	rows, err := s.DB.Query(`select `+sessionColumns+` from charging_sessions where stay_id = $1 order by id`, stayID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := []Session{}
+	for rows.Next() {
+		se, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, se)
+	}
+	return res, rows.Err()
+}
+
+// Stop ends a running session and returns it before and after.
+func (s *Service) Stop(id int) (Session, Session, error) {
+	qr := `UPDATE charging_sessions SET stopped_at = $1 where id = $2 and stopped_at is null returning ` + sessionColumns
+	after, err := scanSession(s.DB.QueryRow(qr, time.Now().UTC(), id))
+	if err == ErrNotFound {
+		before, gerr := s.Get(id)
+		if gerr != nil {
+			return before, before, gerr
+		}
+		return before, before, ErrStopped
+	}
+	before := after
+	before.StoppedAt = nil
+	return before, after, err
+}
+
+// Report records the charger's meter reading, the energy delivered since
+// the session started. The row is locked while the reading is checked, so
+// concurrent reports cannot move the meter backwards.
+func (s *Service) Report(id, energyWh int) (Session, error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return Session{}, err
+	}
+	defer tx.Rollback()
+	se, err := scanSession(tx.QueryRow(`select `+sessionColumns+` from charging_sessions where id = $1 for update`, id))
+	if err != nil {
+		return se, err
+	}
+	if se.StoppedAt != nil {
+		return se, ErrStopped
+	}
+	elapsed := time.Since(se.StartedAt) + meterSlack
+	if energyWh < se.EnergyWh || float64(energyWh) > se.MaxKW*1000*elapsed.Hours() {
+		return se, ErrMeter
+	}
+	if _, err = tx.Exec(`UPDATE charging_sessions SET energy_wh = $1 where id = $2`, energyWh, id); err != nil {
+		return se, err
+	}
+	if err = tx.Commit(); err != nil {
+		return se, err
+	}
+	se.EnergyWh = energyWh
+	se.Cents = Cost(se.EnergyWh, se.CentsPerKWh)
+	return se, nil
+}
+
+// StopStay stops the stay's running sessions at exit, normally in the
+// transaction that closes it.
+func StopStay(q pricing.Execer, stayID int, at time.Time) error {
+	_, err := q.Exec(`UPDATE charging_sessions SET stopped_at = $1 where stay_id = $2 and stopped_at is null`, at, stayID)
+	return err
+}
@@ -0,0 +1,92 @@
+package charging
+
+import (
+	"testing"
+	"time"
+
+	"PDEA/internal/parking"
+	"PDEA/internal/testdb"
+)
+
+func TestCost(t *testing.T) {
+	tests := []struct {
+		energyWh, centsPerKWh, want int
+	}{
+		{0, 30, 0},
+		{1000, 30, 30},
+		{12345, 30, 370},
+		{16, 30, 0},  // 0.48 cents
+		{17, 30, 1},  // 0.51 cents
+		{50, 10, 1},  // exactly half a cent rounds up
+		{5000, 0, 0}, // free energy
+	}
+	for _, tt := range tests {
+		if got := Cost(tt.energyWh, tt.centsPerKWh); got != tt.want {
+			t.Errorf("Cost(%d, %d) = %d, want %d", tt.energyWh, tt.centsPerKWh, got, tt.want)
+		}
+	}
+}
+
+func TestSessionBilling(t *testing.T) {
+	db := testdb.Open(t, "charging_test")
+	if _, err := db.Exec(`DROP TABLE IF EXISTS charging_sessions;`); err != nil {
+		t.Fatal(err)
+	}
+	s := New(db, 30)
+	if err := s.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	const stay = 1
+	charger := parking.ParkingSpot{SpotNumber: "E1", Connector: "ccs", MaxKW: 50}
+	if _, err := s.Start(stay, parking.ParkingSpot{SpotNumber: "A1"}); err != ErrNoCharger {
+		t.Errorf("Start at a spot without a charger = %v, want ErrNoCharger", err)
+	}
+	se, err := s.Start(stay, charger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Start(stay, charger); err != ErrActive {
+		t.Errorf("second Start = %v, want ErrActive", err)
+	}
+
+	// 50 kW over the minute of slack allows up to 833 Wh right away
+	tests := []struct {
+		name     string
+		energyWh int
+		err      error
+		cents    int
+	}{
+		{"first reading", 400, nil, 12},
+		{"meter moves on", 600, nil, 18},
+		{"meter goes backwards", 500, ErrMeter, 18},
+		{"more than the charger can deliver", 5000, ErrMeter, 18},
+		{"same reading again", 600, nil, 18},
+	}
+	for _, tt := range tests {
+		got, err := s.Report(se.ID, tt.energyWh)
+		if err != tt.err {
+			t.Errorf("%s: Report(%d) = %v, want %v", tt.name, tt.energyWh, err, tt.err)
+			continue
+		}
+		if stored, err := s.Get(se.ID); err != nil || stored.Cents != tt.cents {
+			t.Errorf("%s: session costs %d cents (%v), want %d", tt.name, stored.Cents, err, tt.cents)
+		}
+		if err == nil && got.Cents != tt.cents {
+			t.Errorf("%s: Report returned %d cents, want %d", tt.name, got.Cents, tt.cents)
+		}
+	}
+
+	if err := StopStay(db, stay, time.Now().UTC()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Report(se.ID, 700); err != ErrStopped {
+		t.Errorf("Report after the exit = %v, want ErrStopped", err)
+	}
+	sessions, err := s.ForStay(stay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 || sessions[0].StoppedAt == nil || sessions[0].Cents != 18 {
+		t.Errorf("ForStay = %+v, want one stopped session costing 18 cents", sessions)
+	}
+}
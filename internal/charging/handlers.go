@@ -0,0 +1,114 @@
+package charging
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"PDEA/internal/logging"
+
+	"github.com/gorilla/mux"
+)
+
+// RegisterRoutes adds the session lookups, stop and the meter endpoint
+// chargers report to. Starting a session is left to the vehicle service,
+// which knows the stay and its spot.
+func (s *Service) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/charging-sessions", s.ListHandler).Methods("GET")
+	router.HandleFunc("/api/charging-sessions/{id}", s.GetHandler).Methods("GET")
+	router.HandleFunc("/api/charging-sessions/{id}/stop", s.StopHandler).Methods("POST")
+	router.HandleFunc("/api/charging-sessions/{id}/meter", s.MeterHandler).Methods("POST")
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	resJson, _ := json.Marshal(v)
+	w.WriteHeader(status)
+	w.Write(resJson)
+}
+
+// WriteError maps the package's errors to responses.
+func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	switch err {
+	case ErrNotFound:
+		http.Error(w, "Charging session not found", http.StatusNotFound)
+	case ErrNoCharger, ErrActive, ErrStopped:
+		http.Error(w, err.Error(), http.StatusConflict)
+	case ErrMeter:
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	default:
+		logging.FromContext(r.Context()).Error("charging session failed", "error", err)
+		http.Error(w, "Server error", http.StatusInternalServerError)
+	}
+}
+
+func sessionID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid id", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+func (s *Service) ListHandler(w http.ResponseWriter, r *http.Request) {
+	stayID, err := strconv.Atoi(r.URL.Query().Get("stay_id"))
+	if err != nil {
+		http.Error(w, "stay_id is required", http.StatusBadRequest)
+		return
+	}
+	res, err := s.ForStay(stayID)
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *Service) GetHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := sessionID(w, r)
+	if !ok {
+		return
+	}
+	se, err := s.Get(id)
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, se)
+}
+
+func (s *Service) StopHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := sessionID(w, r)
+	if !ok {
+		return
+	}
+	before, se, err := s.Stop(id)
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+	s.Audit.Record(r, "stop", "charging_session", strconv.Itoa(se.ID), before, se)
+	writeJSON(w, http.StatusOK, se)
+}
+
+// MeterHandler takes {"energy_wh": 1234}, the energy delivered since the
+// session started.
+func (s *Service) MeterHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := sessionID(w, r)
+	if !ok {
+		return
+	}
+	var reqBody struct {
+		EnergyWh *int `json:"energy_wh"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil || reqBody.EnergyWh == nil {
+		http.Error(w, "Invalid request body, energy_wh is required", http.StatusBadRequest)
+		return
+	}
+	se, err := s.Report(id, *reqBody.EnergyWh)
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, se)
+}
@@ -0,0 +1,332 @@
+// Package discount manages validation codes that merchants hand out and
+// their redemptions against stays. A code takes a percentage or a fixed
+// amount off a stay's fee, or makes its first minutes free. Codes are
+// redeemed while the stay is in progress; the cents each one took off are
+// filled in when the stay exits.
+package discount
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"PDEA/internal/audit"
+	"PDEA/internal/parking"
+	"PDEA/internal/pricing"
+
+	"github.com/lib/pq"
+)
+
+const (
+	KindPercent = "percent"
+	KindAmount  = "amount"
+	KindMinutes = "minutes"
+)
+
+var (
+	ErrInvalid    = errors.New("invalid discount code")
+	ErrNotFound   = errors.New("discount code not found")
+	ErrExpired    = errors.New("discount code has expired")
+	ErrIneligible = errors.New("discount code does not apply to this spot type")
+	ErrExhausted  = errors.New("discount code has no uses left")
+	ErrRedeemed   = errors.New("discount code already applied to this stay")
+)
+
+// Code is a discount code. Value is a percentage, cents or minutes by Kind.
+// MaxUses of 0 means unlimited, an unset ExpiresAt never expires and an
+// empty SpotTypes applies to every type.
+type Code struct {
+	Code      string     `json:"code"`
+	Kind      string     `json:"kind"`
+	Value     int        `json:"value"`
+	MaxUses   int        `json:"max_uses"`
+	Uses      int        `json:"uses"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	SpotTypes []string   `json:"spot_types"`
+	Merchant  string     `json:"merchant"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (c Code) validate() error {
+	if c.Code == "" || strings.ContainsAny(c.Code, " /") {
+		return fmt.Errorf("%w: code must be non-empty without spaces or slashes", ErrInvalid)
+	}
+	switch c.Kind {
+	case KindPercent:
+		if c.Value <= 0 || c.Value > 100 {
+			return fmt.Errorf("%w: a percent value must be 1-100", ErrInvalid)
+		}
+	case KindAmount, KindMinutes:
+		if c.Value <= 0 {
+			return fmt.Errorf("%w: value must be positive", ErrInvalid)
+		}
+	default:
+		return fmt.Errorf("%w: kind must be percent, amount or minutes", ErrInvalid)
+	}
+	if c.MaxUses < 0 {
+		return fmt.Errorf("%w: max_uses must not be negative", ErrInvalid)
+	}
+	return nil
+}
+
+func (c Code) eligible(spotType string) bool {
+	if len(c.SpotTypes) == 0 {
+		return true
+	}
+	for _, t := range c.SpotTypes {
+		if t == spotType {
+			return true
+		}
+	}
+	return false
+}
+
+// Redemption is one use of a code. Cents is unset until the stay exits.
+type Redemption struct {
+	Code       string    `json:"code"`
+	StayID     int       `json:"stay_id"`
+	RedeemedAt time.Time `json:"redeemed_at"`
+	Cents      *int      `json:"cents,omitempty"`
+}
+
+type Service struct {
+	DB    *sql.DB
+	Audit *audit.Logger
+}
+
+func New(db *sql.DB) *Service {
+	return &Service{DB: db}
+}
+
+func (s *Service) Migrate() error {
+	schemaSQL := `
+CREATE TABLE IF NOT EXISTS discount_codes (
+code TEXT PRIMARY KEY,
+kind TEXT NOT NULL,
+value INTEGER NOT NULL,
+max_uses INTEGER NOT NULL DEFAULT 0,
+expires_at TIMESTAMPTZ,
+spot_types TEXT[] NOT NULL DEFAULT '{}',
+merchant TEXT NOT NULL DEFAULT '',
+created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS discount_redemptions (
+id SERIAL PRIMARY KEY,
+code TEXT NOT NULL REFERENCES discount_codes (code) ON DELETE CASCADE,
+stay_id INTEGER NOT NULL,
+redeemed_at TIMESTAMPTZ NOT NULL,
+cents INTEGER,
+UNIQUE (stay_id, code)
+);
+`
+	_, err := s.DB.Exec(schemaSQL)
+	return err
+}
+
+const codeColumns = `c.code, c.kind, c.value, c.max_uses, (select count(*) from discount_redemptions r where r.code = c.code), c.expires_at, c.spot_types, c.merchant, c.created_at`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanCode(row scanner) (Code, error) {
+	var c Code
+	var expires sql.NullTime
+	err := row.Scan(&c.Code, &c.Kind, &c.Value, &c.MaxUses, &c.Uses, &expires, pq.Array(&c.SpotTypes), &c.Merchant, &c.CreatedAt)
+	if err == sql.ErrNoRows {
+		return c, ErrNotFound
+	}
+	if expires.Valid {
+		c.ExpiresAt = &expires.Time
+	}
+	if c.SpotTypes == nil {
+		c.SpotTypes = []string{}
+	}
+	return c, err
+}
+
+func (s *Service) Codes() ([]Code, error) {
+	rows, err := s.DB.Query(`select ` + codeColumns + ` from discount_codes c order by c.code`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := []Code{}
+	for rows.Next() {
+		c, err := scanCode(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, c)
+	}
+	return res, rows.Err()
+}
+
+func (s *Service) Get(code string) (Code, error) {
+	return scanCode(s.DB.QueryRow(`select `+codeColumns+` from discount_codes c where c.code = $1`, code))
+}
+
+// Put creates or replaces a code and returns the previous version, if any.
+// Redemptions made so far still count towards MaxUses.
+func (s *Service) Put(c Code) (*Code, error) {
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	old, err := s.Get(c.Code)
+	if err != nil && err != ErrNotFound {
+		return nil, err
+	}
+	if c.SpotTypes == nil {
+		c.SpotTypes = []string{}
+	}
+	qr := `INSERT INTO discount_codes(code, kind, value, max_uses, expires_at, spot_types, merchant, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (code) DO UPDATE SET kind = excluded.kind, value = excluded.value, max_uses = excluded.max_uses,
+		expires_at = excluded.expires_at, spot_types = excluded.spot_types, merchant = excluded.merchant;`
+	if _, err = s.DB.Exec(qr, c.Code, c.Kind, c.Value, c.MaxUses, c.ExpiresAt, pq.Array(c.SpotTypes), c.Merchant, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+	if old.Code == "" {
+		return nil, nil
+	}
+	return &old, nil
+}
+
+// Delete removes a code together with its redemptions.
+func (s *Service) Delete(code string) (Code, error) {
+	c, err := s.Get(code)
+	if err != nil {
+		return c, err
+	}
+	_, err = s.DB.Exec(`DELETE FROM discount_codes where code = $1`, code)
+	return c, err
+}
+
+// Redeem applies code to a stay of spotType. The code row is locked so
+// concurrent redemptions cannot go past MaxUses.
+func (s *Service) Redeem(code string, stayID int, spotType string) (Redemption, error) {
+	r := Redemption{Code: code, StayID: stayID, RedeemedAt: time.Now().UTC()}
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return r, err
+	}
+	defer tx.Rollback()
+	c, err := scanCode(tx.QueryRow(`select `+codeColumns+` from discount_codes c where c.code = $1 for update`, code))
+	if err != nil {
+		return r, err
+	}
+	switch {
+	case c.ExpiresAt != nil && !r.RedeemedAt.Before(*c.ExpiresAt):
+		return r, ErrExpired
+	case !c.eligible(spotType):
+		return r, ErrIneligible
+	case c.MaxUses > 0 && c.Uses >= c.MaxUses:
+		return r, ErrExhausted
+	}
+	qr := `INSERT INTO discount_redemptions(code, stay_id, redeemed_at) VALUES ($1, $2, $3) ON CONFLICT (stay_id, code) DO NOTHING`
+	res, err := tx.Exec(qr, code, stayID, r.RedeemedAt)
+	if err != nil {
+		return r, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return r, err
+	} else if n == 0 {
+		return r, ErrRedeemed
+	}
+	return r, tx.Commit()
+}
+
+// Redemptions lists a code's uses, newest first.
+func (s *Service) Redemptions(code string) ([]Redemption, error) {
+	if _, err := s.Get(code); err != nil {
+		return nil, err
+	}
+	rows, err := s.DB.Query(`select code, stay_id, redeemed_at, cents from discount_redemptions where code = $1 order by id desc`, code)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := []Redemption{}
+	for rows.Next() {
+		var r Redemption
+		var cents sql.NullInt64
+		if err := rows.Scan(&r.Code, &r.StayID, &r.RedeemedAt, &cents); err != nil {
+			return nil, err
+		}
+		if cents.Valid {
+			n := int(cents.Int64)
+			r.Cents = &n
+		}
+		res = append(res, r)
+	}
+	return res, rows.Err()
+}
+
+// ForStay returns the codes redeemed for a stay in the order they were
+// redeemed.
+func (s *Service) ForStay(stayID int) ([]Code, error) {
+	qr := `select ` + codeColumns + ` from discount_redemptions d join discount_codes c on c.code = d.code where d.stay_id = $1 order by d.id`
+	rows, err := s.DB.Query(qr, stayID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []Code
+	for rows.Next() {
+		c, err := scanCode(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, c)
+	}
+	return res, rows.Err()
+}
+
+// Apply works out the fee of a stay at hourlyCents after codes and what
+// each code took off. Free minutes go first, as they shorten the billed
+// time, then percentages, then fixed amounts; the fee never drops below
+// zero.
+func Apply(codes []Code, hourlyCents int, entry, exit time.Time) (int, []parking.AppliedDiscount) {
+	ordered := make([]Code, len(codes))
+	copy(ordered, codes)
+	rank := map[string]int{KindMinutes: 0, KindPercent: 1, KindAmount: 2}
+	sort.SliceStable(ordered, func(i, j int) bool { return rank[ordered[i].Kind] < rank[ordered[j].Kind] })
+
+	fee := pricing.Fee(hourlyCents, entry, exit)
+	billedExit := exit
+	var applied []parking.AppliedDiscount
+	for _, c := range ordered {
+		cut := 0
+		switch c.Kind {
+		case KindMinutes:
+			billedExit = billedExit.Add(-time.Duration(c.Value) * time.Minute)
+			next := 0
+			if billedExit.After(entry) {
+				next = pricing.Fee(hourlyCents, entry, billedExit)
+			}
+			cut = fee - next
+		case KindPercent:
+			// round half up to whole cents
+			cut = (fee*c.Value + 50) / 100
+		case KindAmount:
+			cut = min(c.Value, fee)
+		}
+		fee -= cut
+		applied = append(applied, parking.AppliedDiscount{Code: c.Code, Kind: c.Kind, Value: c.Value, Cents: cut})
+	}
+	return fee, applied
+}
+
+// Settle records what each code took off a stay at exit, normally in the
+// transaction that closes it.
+func Settle(q pricing.Execer, stayID int, applied []parking.AppliedDiscount) error {
+	for _, a := range applied {
+		if _, err := q.Exec(`UPDATE discount_redemptions SET cents = $1 where stay_id = $2 and code = $3`, a.Cents, stayID, a.Code); err != nil {
+			return err
+		}
+	}
+	return nil
+}
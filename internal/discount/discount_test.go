@@ -0,0 +1,89 @@
+package discount
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"PDEA/internal/parking"
+)
+
+func TestApply(t *testing.T) {
+	entry := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	percent := func(code string, v int) Code { return Code{Code: code, Kind: KindPercent, Value: v} }
+	amount := func(code string, v int) Code { return Code{Code: code, Kind: KindAmount, Value: v} }
+	minutes := func(code string, v int) Code { return Code{Code: code, Kind: KindMinutes, Value: v} }
+	cut := func(c Code, cents int) parking.AppliedDiscount {
+		return parking.AppliedDiscount{Code: c.Code, Kind: c.Kind, Value: c.Value, Cents: cents}
+	}
+
+	tests := []struct {
+		name    string
+		codes   []Code
+		hourly  int
+		stay    time.Duration
+		fee     int
+		applied []parking.AppliedDiscount
+	}{
+		{
+			name: "no codes", hourly: 200, stay: 150 * time.Minute, fee: 600,
+		},
+		{
+			name: "percent", codes: []Code{percent("TEN", 10)}, hourly: 200, stay: 150 * time.Minute,
+			fee: 540, applied: []parking.AppliedDiscount{cut(percent("TEN", 10), 60)},
+		},
+		{
+			name: "percent rounds half up", codes: []Code{percent("P15", 15)}, hourly: 250, stay: time.Hour,
+			fee: 212, applied: []parking.AppliedDiscount{cut(percent("P15", 15), 38)},
+		},
+		{
+			name: "percent before amount", codes: []Code{amount("A100", 100), percent("HALF", 50)}, hourly: 200, stay: 150 * time.Minute,
+			fee:     200,
+			applied: []parking.AppliedDiscount{cut(percent("HALF", 50), 300), cut(amount("A100", 100), 100)},
+		},
+		{
+			name: "minutes before percent", codes: []Code{percent("HALF", 50), minutes("M30", 30)}, hourly: 200, stay: 150 * time.Minute,
+			fee:     200,
+			applied: []parking.AppliedDiscount{cut(minutes("M30", 30), 200), cut(percent("HALF", 50), 200)},
+		},
+		{
+			name: "minutes within the first hour", codes: []Code{minutes("M10", 10)}, hourly: 200, stay: 50 * time.Minute,
+			fee: 200, applied: []parking.AppliedDiscount{cut(minutes("M10", 10), 0)},
+		},
+		{
+			name: "minutes cover the stay", codes: []Code{minutes("M60", 60)}, hourly: 200, stay: 45 * time.Minute,
+			fee: 0, applied: []parking.AppliedDiscount{cut(minutes("M60", 60), 200)},
+		},
+		{
+			name: "amount clamped to the fee", codes: []Code{amount("BIG", 1000)}, hourly: 200, stay: time.Hour,
+			fee: 0, applied: []parking.AppliedDiscount{cut(amount("BIG", 1000), 200)},
+		},
+		{
+			name: "second amount takes what is left", codes: []Code{amount("A150", 150), amount("B150", 150)}, hourly: 200, stay: time.Hour,
+			fee:     0,
+			applied: []parking.AppliedDiscount{cut(amount("A150", 150), 150), cut(amount("B150", 150), 50)},
+		},
+		{
+			name: "nothing left after full percent", codes: []Code{amount("A50", 50), percent("FREE", 100)}, hourly: 200, stay: 150 * time.Minute,
+			fee:     0,
+			applied: []parking.AppliedDiscount{cut(percent("FREE", 100), 600), cut(amount("A50", 50), 0)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fee, applied := Apply(tt.codes, tt.hourly, entry, entry.Add(tt.stay))
+			if fee != tt.fee || !reflect.DeepEqual(applied, tt.applied) {
+				t.Errorf("Apply = %d, %+v, want %d, %+v", fee, applied, tt.fee, tt.applied)
+			}
+		})
+	}
+}
+
+func TestApplyKeepsCodes(t *testing.T) {
+	codes := []Code{{Code: "A", Kind: KindAmount, Value: 10}, {Code: "M", Kind: KindMinutes, Value: 5}}
+	entry := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	Apply(codes, 100, entry, entry.Add(time.Hour))
+	if codes[0].Code != "A" || codes[1].Code != "M" {
+		t.Errorf("Apply reordered its argument: %+v", codes)
+	}
+}
@@ -0,0 +1,107 @@
+package discount
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"PDEA/internal/logging"
+
+	"github.com/gorilla/mux"
+)
+
+// RegisterRoutes adds code management and redemption history. Redeeming a
+// code is left to the vehicle service, which knows the stay and its spot.
+func (s *Service) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/discount-codes", s.ListHandler).Methods("GET")
+	router.HandleFunc("/api/discount-codes/{code}", s.GetHandler).Methods("GET")
+	router.HandleFunc("/api/discount-codes/{code}", s.PutHandler).Methods("PUT")
+	router.HandleFunc("/api/discount-codes/{code}", s.DeleteHandler).Methods("DELETE")
+	router.HandleFunc("/api/discount-codes/{code}/redemptions", s.RedemptionsHandler).Methods("GET")
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	resJson, _ := json.Marshal(v)
+	w.WriteHeader(status)
+	w.Write(resJson)
+}
+
+func serverError(w http.ResponseWriter, r *http.Request, msg string, err error) {
+	logging.FromContext(r.Context()).Error(msg, "error", err)
+	http.Error(w, "Server error", http.StatusInternalServerError)
+}
+
+func (s *Service) ListHandler(w http.ResponseWriter, r *http.Request) {
+	res, err := s.Codes()
+	if err != nil {
+		serverError(w, r, "list discount codes failed", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *Service) GetHandler(w http.ResponseWriter, r *http.Request) {
+	c, err := s.Get(mux.Vars(r)["code"])
+	if err == ErrNotFound {
+		http.Error(w, "Discount code not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		serverError(w, r, "get discount code failed", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, c)
+}
+
+// PutHandler creates or replaces the code named in the path.
+func (s *Service) PutHandler(w http.ResponseWriter, r *http.Request) {
+	var reqBody Code
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	reqBody.Code = mux.Vars(r)["code"]
+	before, err := s.Put(reqBody)
+	if errors.Is(err, ErrInvalid) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		serverError(w, r, "put discount code failed", err)
+		return
+	}
+	c, err := s.Get(reqBody.Code)
+	if err != nil {
+		serverError(w, r, "get discount code failed", err)
+		return
+	}
+	s.Audit.Record(r, "set", "discount_code", c.Code, before, c)
+	writeJSON(w, http.StatusOK, c)
+}
+
+func (s *Service) DeleteHandler(w http.ResponseWriter, r *http.Request) {
+	before, err := s.Delete(mux.Vars(r)["code"])
+	if err == ErrNotFound {
+		http.Error(w, "Discount code not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		serverError(w, r, "delete discount code failed", err)
+		return
+	}
+	s.Audit.Record(r, "delete", "discount_code", before.Code, before, nil)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Service) RedemptionsHandler(w http.ResponseWriter, r *http.Request) {
+	res, err := s.Redemptions(mux.Vars(r)["code"])
+	if err == ErrNotFound {
+		http.Error(w, "Discount code not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		serverError(w, r, "list discount redemptions failed", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
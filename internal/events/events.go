@@ -0,0 +1,240 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"PDEA/internal/logging"
+)
+
+const (
+	SpotCreated      = "spot.created"
+	SpotUpdated      = "spot.updated"
+	SpotDeleted      = "spot.deleted"
+	SpotAvailability = "spot.availability"
+	VehicleEntry     = "vehicle.entry"
+	VehicleExit      = "vehicle.exit"
+)
+
+// Event is a change reported to live subscribers and webhooks. ID is the
+// event's sequence number in the event store, which only grows, so clients
+// resume from the last ID they saw across restarts and replicas.
+type Event struct {
+	ID          uint64    `json:"id"`
+	Type        string    `json:"type"`
+	Time        time.Time `json:"time"`
+	SpotNumber  string    `json:"spot_number,omitempty"`
+	SpotType    string    `json:"spot_type,omitempty"`
+	Zone        string    `json:"zone,omitempty"`
+	IsAvailable *bool     `json:"is_available,omitempty"`
+	StayID      int       `json:"stay_id,omitempty"`
+	Plate       string    `json:"license_plate,omitempty"`
+	Data        any       `json:"data,omitempty"`
+}
+
+// Log is the durable record of events that reconnecting clients are
+// replayed from. Since returns up to limit events with IDs above afterID, in
+// ID order.
+type Log interface {
+	Since(afterID uint64, limit int) ([]Event, error)
+}
+
+// replayPage is how many events Subscribe reads from the log at a time.
+const replayPage = 500
+
+// Broker fans events out to live subscribers. A reconnecting client is
+// replayed what it missed from the Log, from its Last-Event-ID.
+type Broker struct {
+	mu     sync.Mutex
+	log    Log
+	subs   map[chan Event]struct{}
+	closed bool
+}
+
+func NewBroker(log Log) *Broker {
+	return &Broker{log: log, subs: make(map[chan Event]struct{})}
+}
+
+// Publish sends ev, already recorded in the log, to the live subscribers.
+func (b *Broker) Publish(ev Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subs {
+		select {
+		case ch <- ev:
+		default:
+			// slow consumer, drop it and let the client resume by event ID
+			delete(b.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// Subscribe returns the logged events after lastID, none if lastID is 0,
+// and a channel for new ones. The channel is opened before the log is read
+// so nothing falls in between; it may repeat the end of replay, so events
+// on it up to the last replayed ID should be skipped. cancel must be called
+// once the subscriber goes away.
+func (b *Broker) Subscribe(lastID uint64) (replay []Event, ch chan Event, cancel func(), err error) {
+	ch = make(chan Event, 64)
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		close(ch)
+		return nil, ch, func() {}, nil
+	}
+	b.subs[ch] = struct{}{}
+	b.mu.Unlock()
+	cancel = func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subs[ch]; ok {
+			delete(b.subs, ch)
+			close(ch)
+		}
+	}
+	for after := lastID; after > 0; {
+		page, err := b.log.Since(after, replayPage)
+		if err != nil {
+			cancel()
+			return nil, nil, nil, err
+		}
+		replay = append(replay, page...)
+		if len(page) < replayPage {
+			break
+		}
+		after = page[len(page)-1].ID
+	}
+	return replay, ch, cancel, nil
+}
+
+// Close ends every open stream. It is called when the server shuts down,
+// since streaming handlers would otherwise never finish draining.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for ch := range b.subs {
+		delete(b.subs, ch)
+		close(ch)
+	}
+}
+
+type Filter struct {
+	Events    map[string]bool
+	SpotTypes map[string]bool
+	Zones     map[string]bool
+}
+
+func FilterFromRequest(r *http.Request) Filter {
+	q := r.URL.Query()
+	return Filter{Events: set(q.Get("event")), SpotTypes: set(q.Get("type")), Zones: set(q.Get("zone"))}
+}
+
+func set(list string) map[string]bool {
+	if list == "" {
+		return nil
+	}
+	res := make(map[string]bool)
+	for _, v := range strings.Split(list, ",") {
+		res[strings.TrimSpace(v)] = true
+	}
+	return res
+}
+
+func (f Filter) Match(ev Event) bool {
+	if f.Events != nil && !f.Events[ev.Type] {
+		return false
+	}
+	if f.SpotTypes != nil && !f.SpotTypes[ev.SpotType] {
+		return false
+	}
+	if f.Zones != nil && !f.Zones[ev.Zone] {
+		return false
+	}
+	return true
+}
+
+// ServeSSE streams events as text/event-stream. Filters are passed as comma
+// separated query parameters: event, type (spot type) and zone.
+func (b *Broker) ServeSSE(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("last_event_id")
+	}
+	var from uint64
+	if lastID != "" {
+		var err error
+		from, err = strconv.ParseUint(lastID, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid Last-Event-ID", http.StatusBadRequest)
+			return
+		}
+	}
+	filter := FilterFromRequest(r)
+	replay, ch, cancel, err := b.Subscribe(from)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("event replay failed", "error", err)
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+	defer cancel()
+	// streams outlive the server's write timeout by design
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	// live events up to the last one replayed were already sent
+	replayed := from
+	for _, ev := range replay {
+		if filter.Match(ev) {
+			writeEvent(w, ev)
+		}
+		replayed = ev.ID
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(15 * time.Second)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": ping\n\n")
+			flusher.Flush()
+		case ev, ok := <-ch:
+			if !ok {
+				return
+			}
+			if ev.ID <= replayed {
+				continue
+			}
+			if filter.Match(ev) {
+				writeEvent(w, ev)
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, ev Event) {
+	data, _ := json.Marshal(ev)
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", ev.ID, ev.Type, data)
+}
+
+func Bool(v bool) *bool {
+	return &v
+}
@@ -0,0 +1,341 @@
+package eventstore
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"PDEA/internal/events"
+)
+
+// Store appends every entry, exit and spot change to parking_events, an
+// append-only table, and keeps the projections derived from it up to date.
+// The projections can always be thrown away and rebuilt with Rebuild.
+type Store struct {
+	DB *sql.DB
+}
+
+type Record struct {
+	Seq         int64           `json:"seq"`
+	Type        string          `json:"type"`
+	SpotNumber  string          `json:"spot_number,omitempty"`
+	SpotType    string          `json:"spot_type,omitempty"`
+	Zone        string          `json:"zone,omitempty"`
+	IsAvailable *bool           `json:"is_available,omitempty"`
+	StayID      int             `json:"stay_id,omitempty"`
+	Plate       string          `json:"license_plate,omitempty"`
+	Data        json.RawMessage `json:"data,omitempty"`
+	OccurredAt  time.Time       `json:"occurred_at"`
+
+	// fresh is set on events read back that were appended less than
+	// gapGrace ago.
+	fresh bool
+}
+
+type SpotState struct {
+	SpotNumber  string    `json:"spot_number"`
+	Type        string    `json:"type"`
+	Zone        string    `json:"zone"`
+	IsAvailable bool      `json:"is_available"`
+	LastSeq     int64     `json:"last_seq"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type OpenStay struct {
+	StayID     int       `json:"stay_id"`
+	SpotNumber string    `json:"spot_number"`
+	Plate      string    `json:"license_plate"`
+	EntryTime  time.Time `json:"entry_time"`
+	LastSeq    int64     `json:"last_seq"`
+}
+
+func New(db *sql.DB) *Store {
+	return &Store{DB: db}
+}
+
+func (s *Store) Migrate() error {
+	schemaSQL := `
+CREATE TABLE IF NOT EXISTS parking_events (
+seq BIGSERIAL PRIMARY KEY,
+type TEXT NOT NULL,
+spot_number TEXT NOT NULL DEFAULT '',
+spot_type TEXT NOT NULL DEFAULT '',
+zone TEXT NOT NULL DEFAULT '',
+is_available BOOLEAN,
+stay_id INT NOT NULL DEFAULT 0,
+license_plate TEXT NOT NULL DEFAULT '',
+data JSONB,
+occurred_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE OR REPLACE FUNCTION parking_events_immutable() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'parking_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DO $$
+BEGIN
+	IF NOT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'parking_events_no_change') THEN
+		CREATE TRIGGER parking_events_no_change BEFORE UPDATE OR DELETE ON parking_events
+		FOR EACH ROW EXECUTE PROCEDURE parking_events_immutable();
+	END IF;
+END $$;
+
+CREATE TABLE IF NOT EXISTS projection_spot_availability (
+spot_number TEXT PRIMARY KEY,
+type TEXT NOT NULL,
+zone TEXT NOT NULL,
+is_available BOOLEAN NOT NULL,
+last_seq BIGINT NOT NULL,
+updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS projection_open_stays (
+stay_id INT PRIMARY KEY,
+spot_number TEXT NOT NULL,
+license_plate TEXT NOT NULL,
+entry_time TIMESTAMPTZ NOT NULL,
+last_seq BIGINT NOT NULL
+);
+
+ALTER TABLE parking_events ADD COLUMN IF NOT EXISTS recorded_at TIMESTAMPTZ NOT NULL DEFAULT clock_timestamp();
+`
+	_, err := s.DB.Exec(schemaSQL)
+	return err
+}
+
+func fromEvent(ev events.Event) Record {
+	rec := Record{Type: ev.Type, SpotNumber: ev.SpotNumber, SpotType: ev.SpotType, Zone: ev.Zone, IsAvailable: ev.IsAvailable,
+		StayID: ev.StayID, Plate: ev.Plate, OccurredAt: ev.Time}
+	if ev.Data != nil {
+		rec.Data, _ = json.Marshal(ev.Data)
+	}
+	if rec.OccurredAt.IsZero() {
+		rec.OccurredAt = time.Now().UTC()
+	}
+	return rec
+}
+
+// Append records ev and applies it to the projections in one transaction.
+func (s *Store) Append(ev events.Event) (Record, error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return Record{}, err
+	}
+	defer tx.Rollback()
+	rec, err := AppendTx(tx, ev)
+	if err != nil {
+		return rec, err
+	}
+	return rec, tx.Commit()
+}
+
+// gapGrace is how long a sequence number missing from the log is waited
+// for. Appends run concurrently, so a number can be taken by a transaction
+// that commits after a later one, or by one that rolls back and leaves a
+// gap for good. A number still missing once the events after it are older
+// than gapGrace is taken to be rolled back.
+const gapGrace = 5 * time.Second
+
+// gapPoll is how often a reader stopped at a gap looks again.
+const gapPoll = 50 * time.Millisecond
+
+// AppendTx records ev and applies it to the projections in tx, normally the
+// transaction making the change ev reports, so that neither is committed
+// without the other. Appends do not wait for each other, so events may
+// commit out of sequence order for up to gapGrace; readers resuming after a
+// sequence number use settled, which waits for the gaps to close. It should
+// be the last statement before the commit. Events for the same spot or stay
+// follow the row locks their changes take and so commit in order.
+func AppendTx(tx *sql.Tx, ev events.Event) (Record, error) {
+	rec := fromEvent(ev)
+	qr := `INSERT INTO parking_events (type, spot_number, spot_type, zone, is_available, stay_id, license_plate, data, occurred_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING seq;`
+	var data any
+	if rec.Data != nil {
+		data = string(rec.Data)
+	}
+	err := tx.QueryRow(qr, rec.Type, rec.SpotNumber, rec.SpotType, rec.Zone, rec.IsAvailable, rec.StayID, rec.Plate, data, rec.OccurredAt).Scan(&rec.Seq)
+	if err != nil {
+		return rec, err
+	}
+	return rec, apply(tx, rec)
+}
+
+func apply(tx *sql.Tx, rec Record) error {
+	var err error
+	switch rec.Type {
+	case events.SpotCreated, events.SpotUpdated:
+		avail := rec.IsAvailable != nil && *rec.IsAvailable
+		qr := `INSERT INTO projection_spot_availability (spot_number, type, zone, is_available, last_seq, updated_at) VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (spot_number) DO UPDATE SET type = $2, zone = $3, is_available = $4, last_seq = $5, updated_at = $6;`
+		_, err = tx.Exec(qr, rec.SpotNumber, rec.SpotType, rec.Zone, avail, rec.Seq, rec.OccurredAt)
+	case events.SpotDeleted:
+		_, err = tx.Exec(`DELETE FROM projection_spot_availability where spot_number = $1;`, rec.SpotNumber)
+	case events.SpotAvailability:
+		avail := rec.IsAvailable != nil && *rec.IsAvailable
+		qr := `INSERT INTO projection_spot_availability (spot_number, type, zone, is_available, last_seq, updated_at) VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (spot_number) DO UPDATE SET is_available = $4, last_seq = $5, updated_at = $6;`
+		_, err = tx.Exec(qr, rec.SpotNumber, rec.SpotType, rec.Zone, avail, rec.Seq, rec.OccurredAt)
+	case events.VehicleEntry:
+		qr := `INSERT INTO projection_open_stays (stay_id, spot_number, license_plate, entry_time, last_seq) VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (stay_id) DO UPDATE SET spot_number = $2, license_plate = $3, entry_time = $4, last_seq = $5;`
+		_, err = tx.Exec(qr, rec.StayID, rec.SpotNumber, rec.Plate, rec.OccurredAt, rec.Seq)
+	case events.VehicleExit:
+		_, err = tx.Exec(`DELETE FROM projection_open_stays where stay_id = $1;`, rec.StayID)
+	}
+	return err
+}
+
+// Rebuild empties the projections and replays the whole event log. Appends
+// are blocked for the duration so the result is a consistent snapshot.
+func (s *Store) Rebuild() (int, error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	if _, err = tx.Exec(`LOCK TABLE parking_events IN SHARE MODE;`); err != nil {
+		return 0, err
+	}
+	if _, err = tx.Exec(`TRUNCATE projection_spot_availability, projection_open_stays;`); err != nil {
+		return 0, err
+	}
+	recs, err := readEvents(tx, "", 0, nil)
+	if err != nil {
+		return 0, err
+	}
+	for _, rec := range recs {
+		if err = apply(tx, rec); err != nil {
+			return 0, err
+		}
+	}
+	return len(recs), tx.Commit()
+}
+
+type querier interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+// readEvents returns the events after seq after, optionally for one spot
+// and at most limit of them when limit is set.
+func readEvents(q querier, spotNumber string, after int64, limit *int) ([]Record, error) {
+	qr := `select seq, type, spot_number, spot_type, zone, is_available, stay_id, license_plate, data, occurred_at,
+clock_timestamp() - recorded_at < make_interval(secs => $4) from parking_events
+where ($1 = '' or spot_number = $1) and seq > $2 order by seq limit $3;`
+	rows, err := q.Query(qr, spotNumber, after, limit, gapGrace.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []Record
+	for rows.Next() {
+		var rec Record
+		var avail sql.NullBool
+		var data []byte
+		err := rows.Scan(&rec.Seq, &rec.Type, &rec.SpotNumber, &rec.SpotType, &rec.Zone, &avail, &rec.StayID, &rec.Plate, &data, &rec.OccurredAt, &rec.fresh)
+		if err != nil {
+			return nil, err
+		}
+		if avail.Valid {
+			rec.IsAvailable = &avail.Bool
+		}
+		if data != nil {
+			rec.Data = data
+		}
+		res = append(res, rec)
+	}
+	return res, rows.Err()
+}
+
+// settled is readEvents for a reader resuming after seq after: it waits
+// until no event read follows a gap that may still be filled, so that an
+// event committing late with a lower sequence number is not skipped.
+func settled(q querier, spotNumber string, after int64, limit *int) ([]Record, error) {
+	for {
+		recs, err := readEvents(q, spotNumber, after, limit)
+		if err != nil || spotNumber != "" || !openGap(after, recs) {
+			return recs, err
+		}
+		time.Sleep(gapPoll)
+	}
+}
+
+// openGap reports whether a recently appended event in recs, read in order
+// after seq after, follows a missing sequence number. Events of one spot
+// are not consecutive, so only whole-log reads can be checked.
+func openGap(after int64, recs []Record) bool {
+	prev := after
+	for _, rec := range recs {
+		if rec.Seq != prev+1 && rec.fresh {
+			return true
+		}
+		prev = rec.Seq
+	}
+	return false
+}
+
+// Events returns up to limit events after seq after, optionally for one
+// spot.
+func (s *Store) Events(spotNumber string, after int64, limit int) ([]Record, error) {
+	return settled(s.DB, spotNumber, after, &limit)
+}
+
+// Event returns rec as it was published.
+func (rec Record) Event() events.Event {
+	ev := events.Event{ID: uint64(rec.Seq), Type: rec.Type, Time: rec.OccurredAt, SpotNumber: rec.SpotNumber, SpotType: rec.SpotType,
+		Zone: rec.Zone, IsAvailable: rec.IsAvailable, StayID: rec.StayID, Plate: rec.Plate}
+	if rec.Data != nil {
+		ev.Data = rec.Data
+	}
+	return ev
+}
+
+// Since returns up to limit events after the event with ID afterID, for
+// replaying to reconnecting clients.
+func (s *Store) Since(afterID uint64, limit int) ([]events.Event, error) {
+	recs, err := settled(s.DB, "", int64(afterID), &limit)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]events.Event, len(recs))
+	for i, rec := range recs {
+		res[i] = rec.Event()
+	}
+	return res, nil
+}
+
+func (s *Store) Spots() ([]SpotState, error) {
+	rows, err := s.DB.Query(`select spot_number, type, zone, is_available, last_seq, updated_at from projection_spot_availability order by spot_number;`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []SpotState
+	for rows.Next() {
+		var st SpotState
+		if err := rows.Scan(&st.SpotNumber, &st.Type, &st.Zone, &st.IsAvailable, &st.LastSeq, &st.UpdatedAt); err != nil {
+			return nil, err
+		}
+		res = append(res, st)
+	}
+	return res, rows.Err()
+}
+
+func (s *Store) OpenStays() ([]OpenStay, error) {
+	rows, err := s.DB.Query(`select stay_id, spot_number, license_plate, entry_time, last_seq from projection_open_stays order by stay_id;`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []OpenStay
+	for rows.Next() {
+		var st OpenStay
+		if err := rows.Scan(&st.StayID, &st.SpotNumber, &st.Plate, &st.EntryTime, &st.LastSeq); err != nil {
+			return nil, err
+		}
+		res = append(res, st)
+	}
+	return res, rows.Err()
+}
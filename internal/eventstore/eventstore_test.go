@@ -0,0 +1,41 @@
+package eventstore
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOpenGap(t *testing.T) {
+	rec := func(seq int64, fresh bool) Record { return Record{Seq: seq, fresh: fresh} }
+	tests := []struct {
+		name  string
+		after int64
+		recs  []Record
+		want  bool
+	}{
+		{"none read", 4, nil, false},
+		{"consecutive", 4, []Record{rec(5, true), rec(6, true)}, false},
+		{"recent event after a gap", 4, []Record{rec(5, true), rec(7, true)}, true},
+		{"gap right after the resume point", 4, []Record{rec(6, true)}, true},
+		{"old gap, taken as rolled back", 4, []Record{rec(5, false), rec(7, false), rec(8, true)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := openGap(tt.after, tt.recs); got != tt.want {
+				t.Errorf("openGap(%d, %v) = %v, want %v", tt.after, tt.recs, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventsHandlerRejectsBadPaging(t *testing.T) {
+	s := New(nil)
+	for _, query := range []string{"after=x", "after=-1", "limit=0", "limit=-5", "limit=many"} {
+		w := httptest.NewRecorder()
+		s.EventsHandler(w, httptest.NewRequest(http.MethodGet, "/api/history/events?"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("GET ?%s = %d, want 400", query, w.Code)
+		}
+	}
+}
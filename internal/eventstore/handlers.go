@@ -0,0 +1,69 @@
+package eventstore
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"PDEA/internal/logging"
+
+	"github.com/gorilla/mux"
+)
+
+func (s *Store) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/history/events", s.EventsHandler).Methods("GET")
+	router.HandleFunc("/api/history/spots", s.SpotsHandler).Methods("GET")
+	router.HandleFunc("/api/history/open-stays", s.OpenStaysHandler).Methods("GET")
+}
+
+func writeJSON(w http.ResponseWriter, r *http.Request, v any, err error) {
+	if err != nil {
+		logging.FromContext(r.Context()).Error("history failed", "error", err)
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+	resJson, _ := json.Marshal(v)
+	w.WriteHeader(http.StatusOK)
+	w.Write(resJson)
+}
+
+// Event history is read a page at a time: limit events, defaultPage unless
+// given and never more than maxPage, after the seq in after. The next page
+// starts after the last seq returned.
+const (
+	defaultPage = 100
+	maxPage     = 1000
+)
+
+// EventsHandler supports the after, limit and spot_number query parameters.
+func (s *Store) EventsHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var after int64
+	if v := q.Get("after"); v != "" {
+		var err error
+		if after, err = strconv.ParseInt(v, 10, 64); err != nil || after < 0 {
+			http.Error(w, "Invalid after", http.StatusBadRequest)
+			return
+		}
+	}
+	limit := defaultPage
+	if v := q.Get("limit"); v != "" {
+		var err error
+		if limit, err = strconv.Atoi(v); err != nil || limit <= 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+	res, err := s.Events(q.Get("spot_number"), after, min(limit, maxPage))
+	writeJSON(w, r, res, err)
+}
+
+func (s *Store) SpotsHandler(w http.ResponseWriter, r *http.Request) {
+	res, err := s.Spots()
+	writeJSON(w, r, res, err)
+}
+
+func (s *Store) OpenStaysHandler(w http.ResponseWriter, r *http.Request) {
+	res, err := s.OpenStays()
+	writeJSON(w, r, res, err)
+}
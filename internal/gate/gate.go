@@ -0,0 +1,320 @@
+// Package gate drives the barriers at the lot's entry and exit lanes. Each
+// gate is a device reached through an Adapter; a successful entry or exit
+// sends its gate an open command and waits for the acknowledgement. Every
+// command is logged with its outcome, and a gate that times out or reports
+// a fault is marked faulted until it next opens.
+package gate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"PDEA/internal/audit"
+	"PDEA/internal/metrics"
+	"PDEA/internal/parking"
+)
+
+const (
+	Entry = "entry"
+	Exit  = "exit"
+)
+
+// Gate health, from the outcome of the last command.
+const (
+	StatusOK    = "ok"
+	StatusFault = "fault"
+)
+
+// Command outcomes; they are also the values of parking.GateResult.Status.
+const (
+	ResultOpened  = "opened"
+	ResultTimeout = "timeout"
+	ResultFault   = "fault"
+)
+
+var (
+	ErrInvalid  = errors.New("invalid gate")
+	ErrNotFound = errors.New("gate not found")
+	// ErrTimeout and ErrFault are returned by adapters when the device did
+	// not acknowledge in time or reported it could not open.
+	ErrTimeout = errors.New("gate did not acknowledge in time")
+	ErrFault   = errors.New("gate fault")
+)
+
+var (
+	opens       = metrics.NewCounterVec("pdea_gate_commands_total", "Gate open commands by direction and result (opened, timeout or fault).", "direction", "result")
+	openSeconds = metrics.NewHistogramVec("pdea_gate_ack_seconds", "Time from sending an open command to its acknowledgement.", metrics.DefBuckets, "direction")
+)
+
+type Gate struct {
+	ID        string `json:"id"`
+	Direction string `json:"direction"`
+	Lane      string `json:"lane"`
+	// Address is where the adapter reaches the device, host:port for TCP.
+	Address      string     `json:"address"`
+	Status       string     `json:"status"`
+	LastError    string     `json:"last_error,omitempty"`
+	LastOpenedAt *time.Time `json:"last_opened_at,omitempty"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// Command is one open command sent to a gate. StayID is unset for manual
+// opens.
+type Command struct {
+	ID        int       `json:"id"`
+	GateID    string    `json:"gate_id"`
+	StayID    int       `json:"stay_id,omitempty"`
+	Result    string    `json:"result"`
+	Error     string    `json:"error,omitempty"`
+	LatencyMS int       `json:"latency_ms"`
+	At        time.Time `json:"at"`
+}
+
+// Adapter speaks a gate device's protocol. Open returns once the device
+// acknowledges the command, or with ErrTimeout or ErrFault.
+type Adapter interface {
+	Open(ctx context.Context, g Gate) error
+}
+
+type Controller struct {
+	DB      *sql.DB
+	Adapter Adapter
+	// Timeout bounds how long an open command waits for its
+	// acknowledgement.
+	Timeout time.Duration
+	Audit   *audit.Logger
+	Log     *slog.Logger
+}
+
+func New(db *sql.DB, adapter Adapter) *Controller {
+	return &Controller{DB: db, Adapter: adapter, Timeout: 3 * time.Second, Log: slog.Default()}
+}
+
+// NewFromEnv picks the adapter named by PDEA_GATE_ADAPTER, "tcp" (the
+// default) or "sim" for the in-process simulator, and the acknowledgement
+// timeout from PDEA_GATE_TIMEOUT.
+func NewFromEnv(db *sql.DB, logger *slog.Logger) (*Controller, error) {
+	var adapter Adapter
+	switch name := os.Getenv("PDEA_GATE_ADAPTER"); name {
+	case "", "tcp":
+		adapter = &TCPAdapter{}
+	case "sim":
+		adapter = &Simulator{}
+	default:
+		return nil, fmt.Errorf("invalid PDEA_GATE_ADAPTER %q, want tcp or sim", name)
+	}
+	c := New(db, adapter)
+	c.Log = logger
+	if v := os.Getenv("PDEA_GATE_TIMEOUT"); v != "" {
+		var err error
+		if c.Timeout, err = time.ParseDuration(v); err != nil || c.Timeout <= 0 {
+			return nil, fmt.Errorf("invalid PDEA_GATE_TIMEOUT %q", v)
+		}
+	}
+	return c, nil
+}
+
+func (c *Controller) Migrate() error {
+	schemaSQL := `
+CREATE TABLE IF NOT EXISTS gates (
+id TEXT PRIMARY KEY,
+direction TEXT NOT NULL,
+lane TEXT NOT NULL DEFAULT '',
+address TEXT NOT NULL DEFAULT '',
+status TEXT NOT NULL,
+last_error TEXT NOT NULL DEFAULT '',
+last_opened_at TIMESTAMPTZ,
+updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS gate_commands (
+id SERIAL PRIMARY KEY,
+gate_id TEXT NOT NULL,
+stay_id INTEGER,
+result TEXT NOT NULL,
+error TEXT NOT NULL DEFAULT '',
+latency_ms INTEGER NOT NULL,
+at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS gate_commands_gate ON gate_commands (gate_id, id);
+`
+	_, err := c.DB.Exec(schemaSQL)
+	return err
+}
+
+const gateColumns = `id, direction, lane, address, status, last_error, last_opened_at, updated_at`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanGate(row scanner) (Gate, error) {
+	var g Gate
+	var opened sql.NullTime
+	err := row.Scan(&g.ID, &g.Direction, &g.Lane, &g.Address, &g.Status, &g.LastError, &opened, &g.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return g, ErrNotFound
+	}
+	if opened.Valid {
+		g.LastOpenedAt = &opened.Time
+	}
+	return g, err
+}
+
+func (c *Controller) List() ([]Gate, error) {
+	rows, err := c.DB.Query(`select ` + gateColumns + ` from gates order by id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := []Gate{}
+	for rows.Next() {
+		g, err := scanGate(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, g)
+	}
+	return res, rows.Err()
+}
+
+func (c *Controller) Get(id string) (Gate, error) {
+	return scanGate(c.DB.QueryRow(`select `+gateColumns+` from gates where id = $1`, id))
+}
+
+// ForLane returns the gate at lane.
+func (c *Controller) ForLane(lane string) (Gate, error) {
+	return scanGate(c.DB.QueryRow(`select `+gateColumns+` from gates where lane = $1 order by id limit 1`, lane))
+}
+
+// Put registers or replaces a gate and returns the previous version, if
+// any. Replacing a gate keeps its health.
+func (c *Controller) Put(g Gate) (*Gate, error) {
+	if g.ID == "" || strings.ContainsAny(g.ID, " /") {
+		return nil, fmt.Errorf("%w: id must be non-empty without spaces or slashes", ErrInvalid)
+	}
+	if g.Direction != Entry && g.Direction != Exit {
+		return nil, fmt.Errorf("%w: direction must be entry or exit", ErrInvalid)
+	}
+	old, err := c.Get(g.ID)
+	if err != nil && err != ErrNotFound {
+		return nil, err
+	}
+	qr := `INSERT INTO gates(id, direction, lane, address, status, updated_at) VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (id) DO UPDATE SET direction = excluded.direction, lane = excluded.lane, address = excluded.address, updated_at = excluded.updated_at;`
+	if _, err = c.DB.Exec(qr, g.ID, g.Direction, g.Lane, g.Address, StatusOK, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+	if old.ID == "" {
+		return nil, nil
+	}
+	return &old, nil
+}
+
+func (c *Controller) Delete(id string) (Gate, error) {
+	g, err := c.Get(id)
+	if err != nil {
+		return g, err
+	}
+	_, err = c.DB.Exec(`DELETE FROM gates where id = $1`, id)
+	return g, err
+}
+
+// Commands lists the latest commands sent to a gate, newest first.
+func (c *Controller) Commands(gateID string, limit int) ([]Command, error) {
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	qr := `select id, gate_id, coalesce(stay_id, 0), result, error, latency_ms, at from gate_commands where gate_id = $1 order by id desc limit $2`
+	rows, err := c.DB.Query(qr, gateID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := []Command{}
+	for rows.Next() {
+		var cmd Command
+		if err := rows.Scan(&cmd.ID, &cmd.GateID, &cmd.StayID, &cmd.Result, &cmd.Error, &cmd.LatencyMS, &cmd.At); err != nil {
+			return nil, err
+		}
+		res = append(res, cmd)
+	}
+	return res, rows.Err()
+}
+
+// Open sends g an open command for stayID and waits up to Timeout for the
+// acknowledgement. The outcome is logged and returned rather than failed:
+// the stay it was sent for has already been recorded. An error is only
+// returned if the outcome could not be stored.
+func (c *Controller) Open(ctx context.Context, g Gate, stayID int) (parking.GateResult, error) {
+	octx, cancel := context.WithTimeout(ctx, c.Timeout)
+	start := time.Now()
+	err := c.Adapter.Open(octx, g)
+	cancel()
+	latency := time.Since(start)
+	res := parking.GateResult{GateID: g.ID, Status: ResultOpened}
+	switch {
+	case err == nil:
+		openSeconds.Observe(latency.Seconds(), g.Direction)
+	case errors.Is(err, ErrTimeout), errors.Is(err, context.DeadlineExceeded):
+		res.Status, res.Error = ResultTimeout, ErrTimeout.Error()
+	default:
+		res.Status, res.Error = ResultFault, err.Error()
+	}
+	opens.Inc(g.Direction, res.Status)
+
+	now := time.Now().UTC()
+	var stay any
+	if stayID != 0 {
+		stay = stayID
+	}
+	qr := `INSERT INTO gate_commands(gate_id, stay_id, result, error, latency_ms, at) VALUES ($1, $2, $3, $4, $5, $6)`
+	if _, err := c.DB.Exec(qr, g.ID, stay, res.Status, res.Error, latency.Milliseconds(), now); err != nil {
+		return res, err
+	}
+	if res.Status == ResultOpened {
+		_, err = c.DB.Exec(`UPDATE gates SET status = $1, last_error = '', last_opened_at = $2 where id = $3`, StatusOK, now, g.ID)
+		return res, err
+	}
+	c.Log.Error("gate did not open", "gate_id", g.ID, "stay_id", stayID, "result", res.Status, "error", res.Error)
+	after := g
+	after.Status, after.LastError = StatusFault, res.Error
+	if _, err = c.DB.Exec(`UPDATE gates SET status = $1, last_error = $2 where id = $3`, StatusFault, res.Error, g.ID); err != nil {
+		return res, err
+	}
+	if g.Status != StatusFault {
+		c.Audit.RecordContext(ctx, "fault", "gate", g.ID, g, after)
+	}
+	return res, nil
+}
+
+// Check reports the gates whose last command failed.
+func (c *Controller) Check(ctx context.Context) error {
+	rows, err := c.DB.QueryContext(ctx, `select id from gates where status = $1 order by id`, StatusFault)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	var faulted []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return err
+		}
+		faulted = append(faulted, id)
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	if len(faulted) > 0 {
+		return fmt.Errorf("gates in fault: %s", strings.Join(faulted, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package gate
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"PDEA/internal/audit"
+	"PDEA/internal/logging"
+
+	"github.com/gorilla/mux"
+)
+
+func (c *Controller) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/gates", c.ListHandler).Methods("GET")
+	router.HandleFunc("/api/gates/{id}", c.GetHandler).Methods("GET")
+	router.HandleFunc("/api/gates/{id}", c.PutHandler).Methods("PUT")
+	router.HandleFunc("/api/gates/{id}", c.DeleteHandler).Methods("DELETE")
+	router.HandleFunc("/api/gates/{id}/commands", c.CommandsHandler).Methods("GET")
+	router.HandleFunc("/api/gates/{id}/open", c.OpenHandler).Methods("POST")
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	resJson, _ := json.Marshal(v)
+	w.WriteHeader(status)
+	w.Write(resJson)
+}
+
+func serverError(w http.ResponseWriter, r *http.Request, msg string, err error) {
+	logging.FromContext(r.Context()).Error(msg, "error", err)
+	http.Error(w, "Server error", http.StatusInternalServerError)
+}
+
+func (c *Controller) ListHandler(w http.ResponseWriter, r *http.Request) {
+	res, err := c.List()
+	if err != nil {
+		serverError(w, r, "list gates failed", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (c *Controller) GetHandler(w http.ResponseWriter, r *http.Request) {
+	g, err := c.Get(mux.Vars(r)["id"])
+	if err == ErrNotFound {
+		http.Error(w, "Gate not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		serverError(w, r, "get gate failed", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, g)
+}
+
+// PutHandler registers or replaces the gate named in the path.
+func (c *Controller) PutHandler(w http.ResponseWriter, r *http.Request) {
+	var reqBody Gate
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	reqBody.ID = mux.Vars(r)["id"]
+	before, err := c.Put(reqBody)
+	if errors.Is(err, ErrInvalid) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		serverError(w, r, "put gate failed", err)
+		return
+	}
+	g, err := c.Get(reqBody.ID)
+	if err != nil {
+		serverError(w, r, "get gate failed", err)
+		return
+	}
+	c.Audit.Record(r, "set", "gate", g.ID, before, g)
+	writeJSON(w, http.StatusOK, g)
+}
+
+func (c *Controller) DeleteHandler(w http.ResponseWriter, r *http.Request) {
+	before, err := c.Delete(mux.Vars(r)["id"])
+	if err == ErrNotFound {
+		http.Error(w, "Gate not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		serverError(w, r, "delete gate failed", err)
+		return
+	}
+	c.Audit.Record(r, "delete", "gate", before.ID, before, nil)
+	w.WriteHeader(http.StatusOK)
+}
+
+// CommandsHandler lists the gate's latest commands, at most ?limit=.
+func (c *Controller) CommandsHandler(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	res, err := c.Commands(mux.Vars(r)["id"], limit)
+	if err != nil {
+		serverError(w, r, "list gate commands failed", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// OpenHandler opens a gate by hand, for instance to let out a driver the
+// attendant has dealt with. It answers 200 with the outcome whether or not
+// the gate acknowledged.
+func (c *Controller) OpenHandler(w http.ResponseWriter, r *http.Request) {
+	g, err := c.Get(mux.Vars(r)["id"])
+	if err == ErrNotFound {
+		http.Error(w, "Gate not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		serverError(w, r, "get gate failed", err)
+		return
+	}
+	ctx := audit.ContextFromRequest(r)
+	res, err := c.Open(ctx, g, 0)
+	if err != nil {
+		serverError(w, r, "open gate failed", err)
+		return
+	}
+	c.Audit.RecordContext(ctx, "open", "gate", g.ID, nil, res)
+	writeJSON(w, http.StatusOK, res)
+}
@@ -0,0 +1,118 @@
+package gate
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// idleTimeout closes simulator connections that send nothing for this long.
+const idleTimeout = time.Minute
+
+// Simulator stands in for gate devices. Used as an Adapter it answers
+// in-process; Serve answers the TCP line protocol, so the TCP adapter can be
+// pointed at it. Every gate acknowledges after Delay unless SetFault has
+// given it a fault.
+type Simulator struct {
+	Delay time.Duration
+	Log   *slog.Logger
+
+	mu     sync.Mutex
+	faults map[string]string
+}
+
+// SetFault makes gateID answer with a fault until it is cleared with an
+// empty reason.
+func (s *Simulator) SetFault(gateID, reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.faults == nil {
+		s.faults = map[string]string{}
+	}
+	if reason == "" {
+		delete(s.faults, gateID)
+	} else {
+		s.faults[gateID] = reason
+	}
+}
+
+// answer waits Delay and returns the gate's fault, or "" to acknowledge.
+func (s *Simulator) answer(ctx context.Context, gateID string) (string, error) {
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case <-time.After(s.Delay):
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.faults[gateID], nil
+}
+
+func (s *Simulator) Open(ctx context.Context, g Gate) error {
+	fault, err := s.answer(ctx, g.ID)
+	if err != nil {
+		return ErrTimeout
+	}
+	if fault != "" {
+		return fmt.Errorf("%w: %s", ErrFault, fault)
+	}
+	return nil
+}
+
+// Serve answers open commands on ln until ctx is cancelled.
+func (s *Simulator) Serve(ctx context.Context, ln net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		go s.serveConn(ctx, conn)
+	}
+}
+
+func (s *Simulator) serveConn(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+	sc := bufio.NewScanner(conn)
+	for {
+		conn.SetReadDeadline(time.Now().Add(idleTimeout))
+		if !sc.Scan() {
+			return
+		}
+		fields := strings.Fields(sc.Text())
+		if len(fields) != 3 || fields[0] != "OPEN" {
+			fmt.Fprintf(conn, "FAULT 0 bad command\n")
+			continue
+		}
+		gateID, seq := fields[1], fields[2]
+		fault, err := s.answer(ctx, gateID)
+		if err != nil {
+			return
+		}
+		if fault != "" {
+			s.log().Info("gate fault", "gate_id", gateID, "reason", fault)
+			fmt.Fprintf(conn, "FAULT %s %s\n", seq, fault)
+			continue
+		}
+		s.log().Info("gate opened", "gate_id", gateID)
+		fmt.Fprintf(conn, "ACK %s\n", seq)
+	}
+}
+
+func (s *Simulator) log() *slog.Logger {
+	if s.Log == nil {
+		return slog.Default()
+	}
+	return s.Log
+}
@@ -0,0 +1,81 @@
+package gate
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// The TCP line protocol: the controller sends
+//
+//	OPEN <gate id> <seq>
+//
+// and the device answers with one of
+//
+//	ACK <seq>
+//	FAULT <seq> <reason>
+//
+// Lines end in "\n". seq lets the controller match answers to commands on
+// devices that keep the connection open.
+
+// TCPAdapter dials the gate's Address for each command.
+type TCPAdapter struct {
+	seq atomic.Uint64
+}
+
+func (a *TCPAdapter) Open(ctx context.Context, g Gate) error {
+	if g.Address == "" {
+		return fmt.Errorf("%w: gate has no address", ErrFault)
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", g.Address)
+	if err != nil {
+		return tcpError(ctx, err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	seq := strconv.FormatUint(a.seq.Add(1), 10)
+	if _, err = fmt.Fprintf(conn, "OPEN %s %s\n", g.ID, seq); err != nil {
+		return tcpError(ctx, err)
+	}
+	sc := bufio.NewScanner(conn)
+	for sc.Scan() {
+		fields := strings.SplitN(strings.TrimSpace(sc.Text()), " ", 3)
+		if len(fields) < 2 || fields[1] != seq {
+			// an answer to an earlier command
+			continue
+		}
+		switch fields[0] {
+		case "ACK":
+			return nil
+		case "FAULT":
+			reason := "no reason given"
+			if len(fields) == 3 {
+				reason = fields[2]
+			}
+			return fmt.Errorf("%w: %s", ErrFault, reason)
+		}
+		return fmt.Errorf("%w: unexpected reply %q", ErrFault, sc.Text())
+	}
+	if err = sc.Err(); err != nil {
+		return tcpError(ctx, err)
+	}
+	return fmt.Errorf("%w: connection closed without a reply", ErrFault)
+}
+
+// tcpError reports deadlines as ErrTimeout and anything else, such as a
+// refused connection, as a fault.
+func tcpError(ctx context.Context, err error) error {
+	var ne net.Error
+	if ctx.Err() != nil || (errors.As(err, &ne) && ne.Timeout()) {
+		return ErrTimeout
+	}
+	return fmt.Errorf("%w: %v", ErrFault, err)
+}
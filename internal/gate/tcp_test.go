@@ -0,0 +1,85 @@
+package gate
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestTCPAdapterOpen(t *testing.T) {
+	sim := &Simulator{Delay: 20 * time.Millisecond, Log: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	sim.SetFault("G-FAULT", "barrier jammed")
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go sim.Serve(ctx, ln)
+
+	// a port nothing listens on
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	refused := closed.Addr().String()
+	closed.Close()
+
+	tests := []struct {
+		name    string
+		gate    Gate
+		timeout time.Duration
+		err     error
+		msg     string
+	}{
+		{"ack", Gate{ID: "G1", Address: ln.Addr().String()}, time.Second, nil, ""},
+		{"fault", Gate{ID: "G-FAULT", Address: ln.Addr().String()}, time.Second, ErrFault, "gate fault: barrier jammed"},
+		{"timeout", Gate{ID: "G1", Address: ln.Addr().String()}, 5 * time.Millisecond, ErrTimeout, ""},
+		{"no address", Gate{ID: "G1"}, time.Second, ErrFault, ""},
+		{"refused", Gate{ID: "G1", Address: refused}, time.Second, ErrFault, ""},
+	}
+	a := &TCPAdapter{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+			err := a.Open(ctx, tt.gate)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Open = %v, want %v", err, tt.err)
+			}
+			if tt.msg != "" && err.Error() != tt.msg {
+				t.Errorf("Open = %q, want %q", err, tt.msg)
+			}
+		})
+	}
+}
+
+// TestTCPAdapterSkipsStaleReplies checks that answers to earlier commands on
+// the connection are passed over.
+func TestTCPAdapterSkipsStaleReplies(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		buf := make([]byte, 64)
+		conn.Read(buf)
+		// seq 1 is the adapter's first command
+		io.WriteString(conn, "FAULT 0 stale\nACK 1\n")
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := (&TCPAdapter{}).Open(ctx, Gate{ID: "G1", Address: ln.Addr().String()}); err != nil {
+		t.Errorf("Open = %v, want the ACK for its own command", err)
+	}
+}
@@ -0,0 +1,91 @@
+// Package grpcapi holds the gRPC pieces shared by the spot and vehicle
+// services: the availability stream and conversions to protobuf types.
+package grpcapi
+
+import (
+	"time"
+
+	"PDEA/internal/events"
+	"PDEA/internal/grpcapi/pdeapb"
+	"PDEA/internal/logging"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Timestamp converts t, leaving an unset time unset.
+func Timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+// AvailabilityServer streams availability changes from a service's broker.
+type AvailabilityServer struct {
+	pdeapb.UnimplementedAvailabilityServer
+	Broker *events.Broker
+}
+
+func (s *AvailabilityServer) Watch(req *pdeapb.WatchRequest, stream pdeapb.Availability_WatchServer) error {
+	filter := events.Filter{Zones: set(req.Zones), SpotTypes: set(req.SpotTypes)}
+	replay, ch, cancel, err := s.Broker.Subscribe(req.LastEventId)
+	if err != nil {
+		logging.FromContext(stream.Context()).Error("event replay failed", "error", err)
+		return status.Error(codes.Unavailable, "event replay failed")
+	}
+	defer cancel()
+	// live events up to the last one replayed were already sent
+	replayed := req.LastEventId
+	for _, ev := range replay {
+		if err := send(stream, filter, ev); err != nil {
+			return err
+		}
+		replayed = ev.ID
+	}
+	for {
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case ev, ok := <-ch:
+			if !ok {
+				// dropped as a slow consumer or shutting down; the client
+				// resumes with its last event ID
+				return status.Error(codes.Unavailable, "stream closed, resume from last event id")
+			}
+			if ev.ID <= replayed {
+				continue
+			}
+			if err := send(stream, filter, ev); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func send(stream pdeapb.Availability_WatchServer, filter events.Filter, ev events.Event) error {
+	if ev.IsAvailable == nil || !filter.Match(ev) {
+		return nil
+	}
+	return stream.Send(&pdeapb.AvailabilityChange{
+		EventId:     ev.ID,
+		EventType:   ev.Type,
+		SpotNumber:  ev.SpotNumber,
+		SpotType:    ev.SpotType,
+		Zone:        ev.Zone,
+		IsAvailable: *ev.IsAvailable,
+		Time:        Timestamp(ev.Time),
+	})
+}
+
+func set(list []string) map[string]bool {
+	if len(list) == 0 {
+		return nil
+	}
+	res := make(map[string]bool)
+	for _, v := range list {
+		res[v] = true
+	}
+	return res
+}
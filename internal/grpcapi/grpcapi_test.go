@@ -0,0 +1,192 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"PDEA/internal/events"
+	"PDEA/internal/grpcapi/pdeapb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// memLog is an events.Log over a slice in ID order.
+type memLog struct {
+	evs []events.Event
+	err error
+}
+
+func (l *memLog) Since(afterID uint64, limit int) ([]events.Event, error) {
+	if l.err != nil {
+		return nil, l.err
+	}
+	var res []events.Event
+	for _, ev := range l.evs {
+		if ev.ID > afterID && len(res) < limit {
+			res = append(res, ev)
+		}
+	}
+	return res, nil
+}
+
+// watchClient serves an AvailabilityServer on broker over an in-memory
+// connection.
+func watchClient(t *testing.T, broker *events.Broker) pdeapb.AvailabilityClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	pdeapb.RegisterAvailabilityServer(srv, &AvailabilityServer{Broker: broker})
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pdeapb.NewAvailabilityClient(conn)
+}
+
+func availability(id uint64, spot, zone string, free bool) events.Event {
+	return events.Event{ID: id, Type: events.SpotAvailability, Time: time.Date(2024, 3, 1, 9, 0, int(id), 0, time.UTC), SpotNumber: spot, SpotType: "compact", Zone: zone, IsAvailable: &free}
+}
+
+func recvIDs(t *testing.T, stream pdeapb.Availability_WatchClient, n int) []uint64 {
+	t.Helper()
+	var ids []uint64
+	for len(ids) < n {
+		change, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv after %v: %v", ids, err)
+		}
+		ids = append(ids, change.GetEventId())
+	}
+	return ids
+}
+
+func TestWatchResumesFromLastEventID(t *testing.T) {
+	log := &memLog{evs: []events.Event{
+		availability(1, "A1", "north", false),
+		{ID: 2, Type: events.VehicleEntry, SpotNumber: "A1", Zone: "north", Plate: "ABC123"},
+		availability(3, "B1", "south", false),
+		availability(4, "A2", "north", false),
+		availability(5, "A1", "north", true),
+	}}
+	broker := events.NewBroker(log)
+	client := watchClient(t, broker)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.Watch(ctx, &pdeapb.WatchRequest{LastEventId: 1, Zones: []string{"north"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 1 was seen already, 2 is not an availability change and 3 is in
+	// another zone.
+	first, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.GetEventId() != 4 || first.GetSpotNumber() != "A2" || first.GetIsAvailable() || first.GetZone() != "north" {
+		t.Errorf("first change = %+v, want event 4 taking A2 in north", first)
+	}
+	if !first.GetTime().AsTime().Equal(log.evs[3].Time) {
+		t.Errorf("first change time = %v, want %v", first.GetTime().AsTime(), log.evs[3].Time)
+	}
+	if ids := recvIDs(t, stream, 1); ids[0] != 5 {
+		t.Fatalf("replayed %v after 4, want 5", ids)
+	}
+
+	// The subscription was registered before the replay, so live events
+	// the replay already covered arrive again and must be skipped.
+	broker.Publish(log.evs[3])
+	broker.Publish(log.evs[4])
+	broker.Publish(availability(6, "B2", "south", true))
+	broker.Publish(availability(7, "A2", "north", true))
+	if ids := recvIDs(t, stream, 1); ids[0] != 7 {
+		t.Errorf("live events %v, want 7", ids)
+	}
+}
+
+func TestWatchPagesThroughReplay(t *testing.T) {
+	log := &memLog{}
+	for id := uint64(1); id <= 1200; id++ {
+		log.evs = append(log.evs, availability(id, "A1", "north", id%2 == 0))
+	}
+	client := watchClient(t, events.NewBroker(log))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.Watch(ctx, &pdeapb.WatchRequest{LastEventId: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := recvIDs(t, stream, 1100)
+	for i, id := range ids {
+		if id != uint64(101+i) {
+			t.Fatalf("replayed event %d at position %d, want %d", id, i, 101+i)
+		}
+	}
+}
+
+func TestWatchErrors(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	t.Run("replay fails", func(t *testing.T) {
+		client := watchClient(t, events.NewBroker(&memLog{err: errors.New("connection refused")}))
+		stream, err := client.Watch(ctx, &pdeapb.WatchRequest{LastEventId: 3})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := stream.Recv(); status.Code(err) != codes.Unavailable {
+			t.Errorf("Recv = %v, want Unavailable", err)
+		}
+	})
+
+	t.Run("broker closed", func(t *testing.T) {
+		broker := events.NewBroker(&memLog{evs: []events.Event{availability(1, "A1", "north", false)}})
+		client := watchClient(t, broker)
+		stream, err := client.Watch(ctx, &pdeapb.WatchRequest{LastEventId: 0})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// without a replay there is nothing to wait on, so keep publishing
+		// until the stream is known to be subscribed
+		got := make(chan error, 1)
+		go func() {
+			_, err := stream.Recv()
+			got <- err
+		}()
+		for subscribed := false; !subscribed; {
+			broker.Publish(availability(2, "A1", "north", true))
+			select {
+			case err := <-got:
+				if err != nil {
+					t.Fatal(err)
+				}
+				subscribed = true
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+		broker.Close()
+		for {
+			_, err := stream.Recv()
+			if err == nil {
+				continue
+			}
+			if status.Code(err) != codes.Unavailable {
+				t.Errorf("Recv after close = %v, want Unavailable", err)
+			}
+			break
+		}
+	})
+}
@@ -0,0 +1,56 @@
+// Package grpctest starts services for the gRPC tests of the spot and
+// vehicle packages.
+package grpctest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"os"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// Env points a service the test starts at PDEA_TEST_DATABASE_URL, with
+// auth off and rate limits the test will not hit. It skips the test if
+// PDEA_TEST_DATABASE_URL is not set.
+func Env(t *testing.T) {
+	t.Helper()
+	dsn := os.Getenv("PDEA_TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("PDEA_TEST_DATABASE_URL not set")
+	}
+	t.Setenv("PDEA_DATABASE_URL", dsn)
+	t.Setenv("PDEA_AUTH", "off")
+	t.Setenv("PDEA_RATE_LIMITS", "default=1000:1000")
+}
+
+// Dial serves srv, interceptors included, over an in-memory connection and
+// connects to it. Both are stopped when the test ends.
+func Dial(t *testing.T, srv *grpc.Server) *grpc.ClientConn {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// Unique returns an upper-case name no earlier run left in the test
+// database.
+func Unique(prefix string) string {
+	buf := make([]byte, 4)
+	rand.Read(buf)
+	return strings.ToUpper(prefix + hex.EncodeToString(buf))
+}
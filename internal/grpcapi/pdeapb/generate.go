@@ -0,0 +1,5 @@
+// Package pdeapb holds the protobuf messages and gRPC stubs generated from
+// pdea.proto.
+package pdeapb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative pdea.proto
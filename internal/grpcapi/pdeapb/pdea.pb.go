@@ -0,0 +1,1752 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.31.0
+// 	protoc        (unknown)
+// source: pdea.proto
+
+package pdeapb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ParkingSpot struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id          int32  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	SpotNumber  string `protobuf:"bytes,2,opt,name=spot_number,json=spotNumber,proto3" json:"spot_number,omitempty"`
+	Type        string `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`
+	IsAvailable bool   `protobuf:"varint,4,opt,name=is_available,json=isAvailable,proto3" json:"is_available,omitempty"`
+	Zone        string `protobuf:"bytes,5,opt,name=zone,proto3" json:"zone,omitempty"`
+	// charger plug and power; unset for spots without a charger
+	Connector string  `protobuf:"bytes,6,opt,name=connector,proto3" json:"connector,omitempty"`
+	MaxKw     float64 `protobuf:"fixed64,7,opt,name=max_kw,json=maxKw,proto3" json:"max_kw,omitempty"`
+	// only electric vehicles may park here
+	EvOnly bool `protobuf:"varint,8,opt,name=ev_only,json=evOnly,proto3" json:"ev_only,omitempty"`
+	// kept for vehicles with an accessibility permit
+	Accessible bool `protobuf:"varint,9,opt,name=accessible,proto3" json:"accessible,omitempty"`
+}
+
+func (x *ParkingSpot) Reset() {
+	*x = ParkingSpot{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pdea_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ParkingSpot) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ParkingSpot) ProtoMessage() {}
+
+func (x *ParkingSpot) ProtoReflect() protoreflect.Message {
+	mi := &file_pdea_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ParkingSpot.ProtoReflect.Descriptor instead.
+func (*ParkingSpot) Descriptor() ([]byte, []int) {
+	return file_pdea_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ParkingSpot) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *ParkingSpot) GetSpotNumber() string {
+	if x != nil {
+		return x.SpotNumber
+	}
+	return ""
+}
+
+func (x *ParkingSpot) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *ParkingSpot) GetIsAvailable() bool {
+	if x != nil {
+		return x.IsAvailable
+	}
+	return false
+}
+
+func (x *ParkingSpot) GetZone() string {
+	if x != nil {
+		return x.Zone
+	}
+	return ""
+}
+
+func (x *ParkingSpot) GetConnector() string {
+	if x != nil {
+		return x.Connector
+	}
+	return ""
+}
+
+func (x *ParkingSpot) GetMaxKw() float64 {
+	if x != nil {
+		return x.MaxKw
+	}
+	return 0
+}
+
+func (x *ParkingSpot) GetEvOnly() bool {
+	if x != nil {
+		return x.EvOnly
+	}
+	return false
+}
+
+func (x *ParkingSpot) GetAccessible() bool {
+	if x != nil {
+		return x.Accessible
+	}
+	return false
+}
+
+type ListSpotsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ListSpotsRequest) Reset() {
+	*x = ListSpotsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pdea_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListSpotsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSpotsRequest) ProtoMessage() {}
+
+func (x *ListSpotsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pdea_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSpotsRequest.ProtoReflect.Descriptor instead.
+func (*ListSpotsRequest) Descriptor() ([]byte, []int) {
+	return file_pdea_proto_rawDescGZIP(), []int{1}
+}
+
+type ListSpotsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Spots []*ParkingSpot `protobuf:"bytes,1,rep,name=spots,proto3" json:"spots,omitempty"`
+}
+
+func (x *ListSpotsResponse) Reset() {
+	*x = ListSpotsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pdea_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListSpotsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSpotsResponse) ProtoMessage() {}
+
+func (x *ListSpotsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pdea_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSpotsResponse.ProtoReflect.Descriptor instead.
+func (*ListSpotsResponse) Descriptor() ([]byte, []int) {
+	return file_pdea_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListSpotsResponse) GetSpots() []*ParkingSpot {
+	if x != nil {
+		return x.Spots
+	}
+	return nil
+}
+
+type GetSpotRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id int32 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (x *GetSpotRequest) Reset() {
+	*x = GetSpotRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pdea_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetSpotRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSpotRequest) ProtoMessage() {}
+
+func (x *GetSpotRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pdea_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSpotRequest.ProtoReflect.Descriptor instead.
+func (*GetSpotRequest) Descriptor() ([]byte, []int) {
+	return file_pdea_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetSpotRequest) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type CreateSpotRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Spot *ParkingSpot `protobuf:"bytes,1,opt,name=spot,proto3" json:"spot,omitempty"`
+}
+
+func (x *CreateSpotRequest) Reset() {
+	*x = CreateSpotRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pdea_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CreateSpotRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateSpotRequest) ProtoMessage() {}
+
+func (x *CreateSpotRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pdea_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateSpotRequest.ProtoReflect.Descriptor instead.
+func (*CreateSpotRequest) Descriptor() ([]byte, []int) {
+	return file_pdea_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CreateSpotRequest) GetSpot() *ParkingSpot {
+	if x != nil {
+		return x.Spot
+	}
+	return nil
+}
+
+type UpdateSpotRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id   int32        `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Spot *ParkingSpot `protobuf:"bytes,2,opt,name=spot,proto3" json:"spot,omitempty"`
+}
+
+func (x *UpdateSpotRequest) Reset() {
+	*x = UpdateSpotRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pdea_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *UpdateSpotRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateSpotRequest) ProtoMessage() {}
+
+func (x *UpdateSpotRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pdea_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateSpotRequest.ProtoReflect.Descriptor instead.
+func (*UpdateSpotRequest) Descriptor() ([]byte, []int) {
+	return file_pdea_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *UpdateSpotRequest) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *UpdateSpotRequest) GetSpot() *ParkingSpot {
+	if x != nil {
+		return x.Spot
+	}
+	return nil
+}
+
+type DeleteSpotRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id int32 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (x *DeleteSpotRequest) Reset() {
+	*x = DeleteSpotRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pdea_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DeleteSpotRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteSpotRequest) ProtoMessage() {}
+
+func (x *DeleteSpotRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pdea_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteSpotRequest.ProtoReflect.Descriptor instead.
+func (*DeleteSpotRequest) Descriptor() ([]byte, []int) {
+	return file_pdea_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *DeleteSpotRequest) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type VehicleRecord struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id           int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	SpotNumber   string                 `protobuf:"bytes,2,opt,name=spot_number,json=spotNumber,proto3" json:"spot_number,omitempty"`
+	LicensePlate string                 `protobuf:"bytes,3,opt,name=license_plate,json=licensePlate,proto3" json:"license_plate,omitempty"`
+	EntryTime    *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=entry_time,json=entryTime,proto3" json:"entry_time,omitempty"`
+	// unset while the vehicle is still parked
+	ExitTime *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=exit_time,json=exitTime,proto3" json:"exit_time,omitempty"`
+	// rate locked in at entry and fee charged at exit; unset for unpriced stays
+	HourlyCents *int64 `protobuf:"varint,6,opt,name=hourly_cents,json=hourlyCents,proto3,oneof" json:"hourly_cents,omitempty"`
+	FeeCents    *int64 `protobuf:"varint,7,opt,name=fee_cents,json=feeCents,proto3,oneof" json:"fee_cents,omitempty"`
+	// part of fee_cents charged because the ticket was lost
+	LostTicketCents *int64 `protobuf:"varint,8,opt,name=lost_ticket_cents,json=lostTicketCents,proto3,oneof" json:"lost_ticket_cents,omitempty"`
+	TicketId        string `protobuf:"bytes,9,opt,name=ticket_id,json=ticketId,proto3" json:"ticket_id,omitempty"`
+	// signed ticket for the QR code; only returned by RegisterEntry
+	TicketToken string `protobuf:"bytes,10,opt,name=ticket_token,json=ticketToken,proto3" json:"ticket_token,omitempty"`
+	// why an attendant let the stay out unpaid
+	PaymentOverride string `protobuf:"bytes,11,opt,name=payment_override,json=paymentOverride,proto3" json:"payment_override,omitempty"`
+	// part of the hourly fee taken off by discount codes, set at exit
+	DiscountCents *int64 `protobuf:"varint,12,opt,name=discount_cents,json=discountCents,proto3,oneof" json:"discount_cents,omitempty"`
+	// the same by code; only returned by the exit calls
+	Discounts []*AppliedDiscount `protobuf:"bytes,13,rep,name=discounts,proto3" json:"discounts,omitempty"`
+	Ev        bool               `protobuf:"varint,14,opt,name=ev,proto3" json:"ev,omitempty"`
+	// part of fee_cents charged for charging sessions
+	EnergyCents  *int64 `protobuf:"varint,15,opt,name=energy_cents,json=energyCents,proto3,oneof" json:"energy_cents,omitempty"`
+	PermitNumber string `protobuf:"bytes,16,opt,name=permit_number,json=permitNumber,proto3" json:"permit_number,omitempty"`
+	// why the permit did not cover an accessible spot the vehicle was let
+	// into anyway
+	PermitWarning string `protobuf:"bytes,17,opt,name=permit_warning,json=permitWarning,proto3" json:"permit_warning,omitempty"`
+	// how the gate named in the request answered the open command; only
+	// returned by the entry and exit calls
+	Gate *GateResult `protobuf:"bytes,18,opt,name=gate,proto3" json:"gate,omitempty"`
+}
+
+func (x *VehicleRecord) Reset() {
+	*x = VehicleRecord{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pdea_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *VehicleRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VehicleRecord) ProtoMessage() {}
+
+func (x *VehicleRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_pdea_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VehicleRecord.ProtoReflect.Descriptor instead.
+func (*VehicleRecord) Descriptor() ([]byte, []int) {
+	return file_pdea_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *VehicleRecord) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *VehicleRecord) GetSpotNumber() string {
+	if x != nil {
+		return x.SpotNumber
+	}
+	return ""
+}
+
+func (x *VehicleRecord) GetLicensePlate() string {
+	if x != nil {
+		return x.LicensePlate
+	}
+	return ""
+}
+
+func (x *VehicleRecord) GetEntryTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.EntryTime
+	}
+	return nil
+}
+
+func (x *VehicleRecord) GetExitTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExitTime
+	}
+	return nil
+}
+
+func (x *VehicleRecord) GetHourlyCents() int64 {
+	if x != nil && x.HourlyCents != nil {
+		return *x.HourlyCents
+	}
+	return 0
+}
+
+func (x *VehicleRecord) GetFeeCents() int64 {
+	if x != nil && x.FeeCents != nil {
+		return *x.FeeCents
+	}
+	return 0
+}
+
+func (x *VehicleRecord) GetLostTicketCents() int64 {
+	if x != nil && x.LostTicketCents != nil {
+		return *x.LostTicketCents
+	}
+	return 0
+}
+
+func (x *VehicleRecord) GetTicketId() string {
+	if x != nil {
+		return x.TicketId
+	}
+	return ""
+}
+
+func (x *VehicleRecord) GetTicketToken() string {
+	if x != nil {
+		return x.TicketToken
+	}
+	return ""
+}
+
+func (x *VehicleRecord) GetPaymentOverride() string {
+	if x != nil {
+		return x.PaymentOverride
+	}
+	return ""
+}
+
+func (x *VehicleRecord) GetDiscountCents() int64 {
+	if x != nil && x.DiscountCents != nil {
+		return *x.DiscountCents
+	}
+	return 0
+}
+
+func (x *VehicleRecord) GetDiscounts() []*AppliedDiscount {
+	if x != nil {
+		return x.Discounts
+	}
+	return nil
+}
+
+func (x *VehicleRecord) GetEv() bool {
+	if x != nil {
+		return x.Ev
+	}
+	return false
+}
+
+func (x *VehicleRecord) GetEnergyCents() int64 {
+	if x != nil && x.EnergyCents != nil {
+		return *x.EnergyCents
+	}
+	return 0
+}
+
+func (x *VehicleRecord) GetPermitNumber() string {
+	if x != nil {
+		return x.PermitNumber
+	}
+	return ""
+}
+
+func (x *VehicleRecord) GetPermitWarning() string {
+	if x != nil {
+		return x.PermitWarning
+	}
+	return ""
+}
+
+func (x *VehicleRecord) GetGate() *GateResult {
+	if x != nil {
+		return x.Gate
+	}
+	return nil
+}
+
+// GateResult is the outcome of opening a gate: status is opened, timeout
+// or fault, with error saying what went wrong.
+type GateResult struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	GateId string `protobuf:"bytes,1,opt,name=gate_id,json=gateId,proto3" json:"gate_id,omitempty"`
+	Status string `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	Error  string `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+func (x *GateResult) Reset() {
+	*x = GateResult{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pdea_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GateResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GateResult) ProtoMessage() {}
+
+func (x *GateResult) ProtoReflect() protoreflect.Message {
+	mi := &file_pdea_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GateResult.ProtoReflect.Descriptor instead.
+func (*GateResult) Descriptor() ([]byte, []int) {
+	return file_pdea_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GateResult) GetGateId() string {
+	if x != nil {
+		return x.GateId
+	}
+	return ""
+}
+
+func (x *GateResult) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *GateResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// AppliedDiscount is what one discount code took off a stay. value is a
+// percentage, cents or minutes by kind.
+type AppliedDiscount struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Code  string `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	Kind  string `protobuf:"bytes,2,opt,name=kind,proto3" json:"kind,omitempty"`
+	Value int64  `protobuf:"varint,3,opt,name=value,proto3" json:"value,omitempty"`
+	Cents int64  `protobuf:"varint,4,opt,name=cents,proto3" json:"cents,omitempty"`
+}
+
+func (x *AppliedDiscount) Reset() {
+	*x = AppliedDiscount{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pdea_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AppliedDiscount) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AppliedDiscount) ProtoMessage() {}
+
+func (x *AppliedDiscount) ProtoReflect() protoreflect.Message {
+	mi := &file_pdea_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AppliedDiscount.ProtoReflect.Descriptor instead.
+func (*AppliedDiscount) Descriptor() ([]byte, []int) {
+	return file_pdea_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *AppliedDiscount) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *AppliedDiscount) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *AppliedDiscount) GetValue() int64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *AppliedDiscount) GetCents() int64 {
+	if x != nil {
+		return x.Cents
+	}
+	return 0
+}
+
+// payment_override is only read on exits. It lets an attendant release a
+// stay whose fee is not paid; the reason is kept with the record.
+type StayRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SpotNumber      string `protobuf:"bytes,1,opt,name=spot_number,json=spotNumber,proto3" json:"spot_number,omitempty"`
+	LicensePlate    string `protobuf:"bytes,2,opt,name=license_plate,json=licensePlate,proto3" json:"license_plate,omitempty"`
+	PaymentOverride string `protobuf:"bytes,3,opt,name=payment_override,json=paymentOverride,proto3" json:"payment_override,omitempty"`
+	// ev and permit_number are only read on entries; ev is required for
+	// ev_only spots and a valid permit for accessible ones
+	Ev           bool   `protobuf:"varint,4,opt,name=ev,proto3" json:"ev,omitempty"`
+	PermitNumber string `protobuf:"bytes,5,opt,name=permit_number,json=permitNumber,proto3" json:"permit_number,omitempty"`
+	// the gate the vehicle is at, opened once the call succeeds
+	GateId string `protobuf:"bytes,6,opt,name=gate_id,json=gateId,proto3" json:"gate_id,omitempty"`
+}
+
+func (x *StayRequest) Reset() {
+	*x = StayRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pdea_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StayRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StayRequest) ProtoMessage() {}
+
+func (x *StayRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pdea_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StayRequest.ProtoReflect.Descriptor instead.
+func (*StayRequest) Descriptor() ([]byte, []int) {
+	return file_pdea_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *StayRequest) GetSpotNumber() string {
+	if x != nil {
+		return x.SpotNumber
+	}
+	return ""
+}
+
+func (x *StayRequest) GetLicensePlate() string {
+	if x != nil {
+		return x.LicensePlate
+	}
+	return ""
+}
+
+func (x *StayRequest) GetPaymentOverride() string {
+	if x != nil {
+		return x.PaymentOverride
+	}
+	return ""
+}
+
+func (x *StayRequest) GetEv() bool {
+	if x != nil {
+		return x.Ev
+	}
+	return false
+}
+
+func (x *StayRequest) GetPermitNumber() string {
+	if x != nil {
+		return x.PermitNumber
+	}
+	return ""
+}
+
+func (x *StayRequest) GetGateId() string {
+	if x != nil {
+		return x.GateId
+	}
+	return ""
+}
+
+type TicketExitRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	TicketToken     string `protobuf:"bytes,1,opt,name=ticket_token,json=ticketToken,proto3" json:"ticket_token,omitempty"`
+	PaymentOverride string `protobuf:"bytes,2,opt,name=payment_override,json=paymentOverride,proto3" json:"payment_override,omitempty"`
+	GateId          string `protobuf:"bytes,3,opt,name=gate_id,json=gateId,proto3" json:"gate_id,omitempty"`
+}
+
+func (x *TicketExitRequest) Reset() {
+	*x = TicketExitRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pdea_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TicketExitRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TicketExitRequest) ProtoMessage() {}
+
+func (x *TicketExitRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pdea_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TicketExitRequest.ProtoReflect.Descriptor instead.
+func (*TicketExitRequest) Descriptor() ([]byte, []int) {
+	return file_pdea_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *TicketExitRequest) GetTicketToken() string {
+	if x != nil {
+		return x.TicketToken
+	}
+	return ""
+}
+
+func (x *TicketExitRequest) GetPaymentOverride() string {
+	if x != nil {
+		return x.PaymentOverride
+	}
+	return ""
+}
+
+func (x *TicketExitRequest) GetGateId() string {
+	if x != nil {
+		return x.GateId
+	}
+	return ""
+}
+
+type LostTicketExitRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	LicensePlate    string `protobuf:"bytes,1,opt,name=license_plate,json=licensePlate,proto3" json:"license_plate,omitempty"`
+	PaymentOverride string `protobuf:"bytes,2,opt,name=payment_override,json=paymentOverride,proto3" json:"payment_override,omitempty"`
+	GateId          string `protobuf:"bytes,3,opt,name=gate_id,json=gateId,proto3" json:"gate_id,omitempty"`
+}
+
+func (x *LostTicketExitRequest) Reset() {
+	*x = LostTicketExitRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pdea_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LostTicketExitRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LostTicketExitRequest) ProtoMessage() {}
+
+func (x *LostTicketExitRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pdea_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LostTicketExitRequest.ProtoReflect.Descriptor instead.
+func (*LostTicketExitRequest) Descriptor() ([]byte, []int) {
+	return file_pdea_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *LostTicketExitRequest) GetLicensePlate() string {
+	if x != nil {
+		return x.LicensePlate
+	}
+	return ""
+}
+
+func (x *LostTicketExitRequest) GetPaymentOverride() string {
+	if x != nil {
+		return x.PaymentOverride
+	}
+	return ""
+}
+
+func (x *LostTicketExitRequest) GetGateId() string {
+	if x != nil {
+		return x.GateId
+	}
+	return ""
+}
+
+// Exactly one of spot_number and license_plate must be set.
+type ListRecordsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SpotNumber   string `protobuf:"bytes,1,opt,name=spot_number,json=spotNumber,proto3" json:"spot_number,omitempty"`
+	LicensePlate string `protobuf:"bytes,2,opt,name=license_plate,json=licensePlate,proto3" json:"license_plate,omitempty"`
+}
+
+func (x *ListRecordsRequest) Reset() {
+	*x = ListRecordsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pdea_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListRecordsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRecordsRequest) ProtoMessage() {}
+
+func (x *ListRecordsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pdea_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRecordsRequest.ProtoReflect.Descriptor instead.
+func (*ListRecordsRequest) Descriptor() ([]byte, []int) {
+	return file_pdea_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ListRecordsRequest) GetSpotNumber() string {
+	if x != nil {
+		return x.SpotNumber
+	}
+	return ""
+}
+
+func (x *ListRecordsRequest) GetLicensePlate() string {
+	if x != nil {
+		return x.LicensePlate
+	}
+	return ""
+}
+
+type ListRecordsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Records []*VehicleRecord `protobuf:"bytes,1,rep,name=records,proto3" json:"records,omitempty"`
+}
+
+func (x *ListRecordsResponse) Reset() {
+	*x = ListRecordsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pdea_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListRecordsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRecordsResponse) ProtoMessage() {}
+
+func (x *ListRecordsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pdea_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRecordsResponse.ProtoReflect.Descriptor instead.
+func (*ListRecordsResponse) Descriptor() ([]byte, []int) {
+	return file_pdea_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ListRecordsResponse) GetRecords() []*VehicleRecord {
+	if x != nil {
+		return x.Records
+	}
+	return nil
+}
+
+type WatchRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// resume after this event ID, as with Last-Event-ID on the SSE stream
+	LastEventId uint64   `protobuf:"varint,1,opt,name=last_event_id,json=lastEventId,proto3" json:"last_event_id,omitempty"`
+	Zones       []string `protobuf:"bytes,2,rep,name=zones,proto3" json:"zones,omitempty"`
+	SpotTypes   []string `protobuf:"bytes,3,rep,name=spot_types,json=spotTypes,proto3" json:"spot_types,omitempty"`
+}
+
+func (x *WatchRequest) Reset() {
+	*x = WatchRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pdea_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRequest) ProtoMessage() {}
+
+func (x *WatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pdea_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRequest.ProtoReflect.Descriptor instead.
+func (*WatchRequest) Descriptor() ([]byte, []int) {
+	return file_pdea_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *WatchRequest) GetLastEventId() uint64 {
+	if x != nil {
+		return x.LastEventId
+	}
+	return 0
+}
+
+func (x *WatchRequest) GetZones() []string {
+	if x != nil {
+		return x.Zones
+	}
+	return nil
+}
+
+func (x *WatchRequest) GetSpotTypes() []string {
+	if x != nil {
+		return x.SpotTypes
+	}
+	return nil
+}
+
+type AvailabilityChange struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	EventId     uint64                 `protobuf:"varint,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	EventType   string                 `protobuf:"bytes,2,opt,name=event_type,json=eventType,proto3" json:"event_type,omitempty"`
+	SpotNumber  string                 `protobuf:"bytes,3,opt,name=spot_number,json=spotNumber,proto3" json:"spot_number,omitempty"`
+	SpotType    string                 `protobuf:"bytes,4,opt,name=spot_type,json=spotType,proto3" json:"spot_type,omitempty"`
+	Zone        string                 `protobuf:"bytes,5,opt,name=zone,proto3" json:"zone,omitempty"`
+	IsAvailable bool                   `protobuf:"varint,6,opt,name=is_available,json=isAvailable,proto3" json:"is_available,omitempty"`
+	Time        *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=time,proto3" json:"time,omitempty"`
+}
+
+func (x *AvailabilityChange) Reset() {
+	*x = AvailabilityChange{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pdea_proto_msgTypes[16]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AvailabilityChange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AvailabilityChange) ProtoMessage() {}
+
+func (x *AvailabilityChange) ProtoReflect() protoreflect.Message {
+	mi := &file_pdea_proto_msgTypes[16]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AvailabilityChange.ProtoReflect.Descriptor instead.
+func (*AvailabilityChange) Descriptor() ([]byte, []int) {
+	return file_pdea_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *AvailabilityChange) GetEventId() uint64 {
+	if x != nil {
+		return x.EventId
+	}
+	return 0
+}
+
+func (x *AvailabilityChange) GetEventType() string {
+	if x != nil {
+		return x.EventType
+	}
+	return ""
+}
+
+func (x *AvailabilityChange) GetSpotNumber() string {
+	if x != nil {
+		return x.SpotNumber
+	}
+	return ""
+}
+
+func (x *AvailabilityChange) GetSpotType() string {
+	if x != nil {
+		return x.SpotType
+	}
+	return ""
+}
+
+func (x *AvailabilityChange) GetZone() string {
+	if x != nil {
+		return x.Zone
+	}
+	return ""
+}
+
+func (x *AvailabilityChange) GetIsAvailable() bool {
+	if x != nil {
+		return x.IsAvailable
+	}
+	return false
+}
+
+func (x *AvailabilityChange) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+var File_pdea_proto protoreflect.FileDescriptor
+
+var file_pdea_proto_rawDesc = []byte{
+	0x0a, 0x0a, 0x70, 0x64, 0x65, 0x61, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x07, 0x70, 0x64,
+	0x65, 0x61, 0x2e, 0x76, 0x31, 0x1a, 0x1f, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xf7, 0x01, 0x0a, 0x0b, 0x50, 0x61, 0x72, 0x6b, 0x69,
+	0x6e, 0x67, 0x53, 0x70, 0x6f, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x02, 0x69, 0x64, 0x12, 0x1f, 0x0a, 0x0b, 0x73, 0x70, 0x6f, 0x74, 0x5f, 0x6e,
+	0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x73, 0x70, 0x6f,
+	0x74, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x21, 0x0a, 0x0c, 0x69,
+	0x73, 0x5f, 0x61, 0x76, 0x61, 0x69, 0x6c, 0x61, 0x62, 0x6c, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x0b, 0x69, 0x73, 0x41, 0x76, 0x61, 0x69, 0x6c, 0x61, 0x62, 0x6c, 0x65, 0x12, 0x12,
+	0x0a, 0x04, 0x7a, 0x6f, 0x6e, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x7a, 0x6f,
+	0x6e, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x18,
+	0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x6f, 0x72,
+	0x12, 0x15, 0x0a, 0x06, 0x6d, 0x61, 0x78, 0x5f, 0x6b, 0x77, 0x18, 0x07, 0x20, 0x01, 0x28, 0x01,
+	0x52, 0x05, 0x6d, 0x61, 0x78, 0x4b, 0x77, 0x12, 0x17, 0x0a, 0x07, 0x65, 0x76, 0x5f, 0x6f, 0x6e,
+	0x6c, 0x79, 0x18, 0x08, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x65, 0x76, 0x4f, 0x6e, 0x6c, 0x79,
+	0x12, 0x1e, 0x0a, 0x0a, 0x61, 0x63, 0x63, 0x65, 0x73, 0x73, 0x69, 0x62, 0x6c, 0x65, 0x18, 0x09,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x61, 0x63, 0x63, 0x65, 0x73, 0x73, 0x69, 0x62, 0x6c, 0x65,
+	0x22, 0x12, 0x0a, 0x10, 0x4c, 0x69, 0x73, 0x74, 0x53, 0x70, 0x6f, 0x74, 0x73, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x22, 0x3f, 0x0a, 0x11, 0x4c, 0x69, 0x73, 0x74, 0x53, 0x70, 0x6f, 0x74,
+	0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2a, 0x0a, 0x05, 0x73, 0x70, 0x6f,
+	0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x70, 0x64, 0x65, 0x61, 0x2e,
+	0x76, 0x31, 0x2e, 0x50, 0x61, 0x72, 0x6b, 0x69, 0x6e, 0x67, 0x53, 0x70, 0x6f, 0x74, 0x52, 0x05,
+	0x73, 0x70, 0x6f, 0x74, 0x73, 0x22, 0x20, 0x0a, 0x0e, 0x47, 0x65, 0x74, 0x53, 0x70, 0x6f, 0x74,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x02, 0x69, 0x64, 0x22, 0x3d, 0x0a, 0x11, 0x43, 0x72, 0x65, 0x61, 0x74,
+	0x65, 0x53, 0x70, 0x6f, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x28, 0x0a, 0x04,
+	0x73, 0x70, 0x6f, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x70, 0x64, 0x65,
+	0x61, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x61, 0x72, 0x6b, 0x69, 0x6e, 0x67, 0x53, 0x70, 0x6f, 0x74,
+	0x52, 0x04, 0x73, 0x70, 0x6f, 0x74, 0x22, 0x4d, 0x0a, 0x11, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65,
+	0x53, 0x70, 0x6f, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x02, 0x69, 0x64, 0x12, 0x28, 0x0a, 0x04, 0x73,
+	0x70, 0x6f, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x70, 0x64, 0x65, 0x61,
+	0x2e, 0x76, 0x31, 0x2e, 0x50, 0x61, 0x72, 0x6b, 0x69, 0x6e, 0x67, 0x53, 0x70, 0x6f, 0x74, 0x52,
+	0x04, 0x73, 0x70, 0x6f, 0x74, 0x22, 0x23, 0x0a, 0x11, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x53,
+	0x70, 0x6f, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x02, 0x69, 0x64, 0x22, 0xa9, 0x06, 0x0a, 0x0d, 0x56,
+	0x65, 0x68, 0x69, 0x63, 0x6c, 0x65, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x12, 0x0e, 0x0a, 0x02,
+	0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x02, 0x69, 0x64, 0x12, 0x1f, 0x0a, 0x0b,
+	0x73, 0x70, 0x6f, 0x74, 0x5f, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0a, 0x73, 0x70, 0x6f, 0x74, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x12, 0x23, 0x0a,
+	0x0d, 0x6c, 0x69, 0x63, 0x65, 0x6e, 0x73, 0x65, 0x5f, 0x70, 0x6c, 0x61, 0x74, 0x65, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x6c, 0x69, 0x63, 0x65, 0x6e, 0x73, 0x65, 0x50, 0x6c, 0x61,
+	0x74, 0x65, 0x12, 0x39, 0x0a, 0x0a, 0x65, 0x6e, 0x74, 0x72, 0x79, 0x5f, 0x74, 0x69, 0x6d, 0x65,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61,
+	0x6d, 0x70, 0x52, 0x09, 0x65, 0x6e, 0x74, 0x72, 0x79, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x37, 0x0a,
+	0x09, 0x65, 0x78, 0x69, 0x74, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
+	0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x08, 0x65, 0x78,
+	0x69, 0x74, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x26, 0x0a, 0x0c, 0x68, 0x6f, 0x75, 0x72, 0x6c, 0x79,
+	0x5f, 0x63, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x48, 0x00, 0x52, 0x0b,
+	0x68, 0x6f, 0x75, 0x72, 0x6c, 0x79, 0x43, 0x65, 0x6e, 0x74, 0x73, 0x88, 0x01, 0x01, 0x12, 0x20,
+	0x0a, 0x09, 0x66, 0x65, 0x65, 0x5f, 0x63, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x07, 0x20, 0x01, 0x28,
+	0x03, 0x48, 0x01, 0x52, 0x08, 0x66, 0x65, 0x65, 0x43, 0x65, 0x6e, 0x74, 0x73, 0x88, 0x01, 0x01,
+	0x12, 0x2f, 0x0a, 0x11, 0x6c, 0x6f, 0x73, 0x74, 0x5f, 0x74, 0x69, 0x63, 0x6b, 0x65, 0x74, 0x5f,
+	0x63, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x08, 0x20, 0x01, 0x28, 0x03, 0x48, 0x02, 0x52, 0x0f, 0x6c,
+	0x6f, 0x73, 0x74, 0x54, 0x69, 0x63, 0x6b, 0x65, 0x74, 0x43, 0x65, 0x6e, 0x74, 0x73, 0x88, 0x01,
+	0x01, 0x12, 0x1b, 0x0a, 0x09, 0x74, 0x69, 0x63, 0x6b, 0x65, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x09,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x74, 0x69, 0x63, 0x6b, 0x65, 0x74, 0x49, 0x64, 0x12, 0x21,
+	0x0a, 0x0c, 0x74, 0x69, 0x63, 0x6b, 0x65, 0x74, 0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x0a,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x74, 0x69, 0x63, 0x6b, 0x65, 0x74, 0x54, 0x6f, 0x6b, 0x65,
+	0x6e, 0x12, 0x29, 0x0a, 0x10, 0x70, 0x61, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x5f, 0x6f, 0x76, 0x65,
+	0x72, 0x72, 0x69, 0x64, 0x65, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x70, 0x61, 0x79,
+	0x6d, 0x65, 0x6e, 0x74, 0x4f, 0x76, 0x65, 0x72, 0x72, 0x69, 0x64, 0x65, 0x12, 0x2a, 0x0a, 0x0e,
+	0x64, 0x69, 0x73, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x5f, 0x63, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x0c,
+	0x20, 0x01, 0x28, 0x03, 0x48, 0x03, 0x52, 0x0d, 0x64, 0x69, 0x73, 0x63, 0x6f, 0x75, 0x6e, 0x74,
+	0x43, 0x65, 0x6e, 0x74, 0x73, 0x88, 0x01, 0x01, 0x12, 0x36, 0x0a, 0x09, 0x64, 0x69, 0x73, 0x63,
+	0x6f, 0x75, 0x6e, 0x74, 0x73, 0x18, 0x0d, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x70, 0x64,
+	0x65, 0x61, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x70, 0x70, 0x6c, 0x69, 0x65, 0x64, 0x44, 0x69, 0x73,
+	0x63, 0x6f, 0x75, 0x6e, 0x74, 0x52, 0x09, 0x64, 0x69, 0x73, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x73,
+	0x12, 0x0e, 0x0a, 0x02, 0x65, 0x76, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x08, 0x52, 0x02, 0x65, 0x76,
+	0x12, 0x26, 0x0a, 0x0c, 0x65, 0x6e, 0x65, 0x72, 0x67, 0x79, 0x5f, 0x63, 0x65, 0x6e, 0x74, 0x73,
+	0x18, 0x0f, 0x20, 0x01, 0x28, 0x03, 0x48, 0x04, 0x52, 0x0b, 0x65, 0x6e, 0x65, 0x72, 0x67, 0x79,
+	0x43, 0x65, 0x6e, 0x74, 0x73, 0x88, 0x01, 0x01, 0x12, 0x23, 0x0a, 0x0d, 0x70, 0x65, 0x72, 0x6d,
+	0x69, 0x74, 0x5f, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x10, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0c, 0x70, 0x65, 0x72, 0x6d, 0x69, 0x74, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x12, 0x25, 0x0a,
+	0x0e, 0x70, 0x65, 0x72, 0x6d, 0x69, 0x74, 0x5f, 0x77, 0x61, 0x72, 0x6e, 0x69, 0x6e, 0x67, 0x18,
+	0x11, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x70, 0x65, 0x72, 0x6d, 0x69, 0x74, 0x57, 0x61, 0x72,
+	0x6e, 0x69, 0x6e, 0x67, 0x12, 0x27, 0x0a, 0x04, 0x67, 0x61, 0x74, 0x65, 0x18, 0x12, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x13, 0x2e, 0x70, 0x64, 0x65, 0x61, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x61, 0x74,
+	0x65, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x52, 0x04, 0x67, 0x61, 0x74, 0x65, 0x42, 0x0f, 0x0a,
+	0x0d, 0x5f, 0x68, 0x6f, 0x75, 0x72, 0x6c, 0x79, 0x5f, 0x63, 0x65, 0x6e, 0x74, 0x73, 0x42, 0x0c,
+	0x0a, 0x0a, 0x5f, 0x66, 0x65, 0x65, 0x5f, 0x63, 0x65, 0x6e, 0x74, 0x73, 0x42, 0x14, 0x0a, 0x12,
+	0x5f, 0x6c, 0x6f, 0x73, 0x74, 0x5f, 0x74, 0x69, 0x63, 0x6b, 0x65, 0x74, 0x5f, 0x63, 0x65, 0x6e,
+	0x74, 0x73, 0x42, 0x11, 0x0a, 0x0f, 0x5f, 0x64, 0x69, 0x73, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x5f,
+	0x63, 0x65, 0x6e, 0x74, 0x73, 0x42, 0x0f, 0x0a, 0x0d, 0x5f, 0x65, 0x6e, 0x65, 0x72, 0x67, 0x79,
+	0x5f, 0x63, 0x65, 0x6e, 0x74, 0x73, 0x22, 0x53, 0x0a, 0x0a, 0x47, 0x61, 0x74, 0x65, 0x52, 0x65,
+	0x73, 0x75, 0x6c, 0x74, 0x12, 0x17, 0x0a, 0x07, 0x67, 0x61, 0x74, 0x65, 0x5f, 0x69, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x67, 0x61, 0x74, 0x65, 0x49, 0x64, 0x12, 0x16, 0x0a,
+	0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73,
+	0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x65, 0x0a, 0x0f, 0x41,
+	0x70, 0x70, 0x6c, 0x69, 0x65, 0x64, 0x44, 0x69, 0x73, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x12,
+	0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x63, 0x6f,
+	0x64, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x14, 0x0a, 0x05,
+	0x63, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x63, 0x65, 0x6e,
+	0x74, 0x73, 0x22, 0xcc, 0x01, 0x0a, 0x0b, 0x53, 0x74, 0x61, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x1f, 0x0a, 0x0b, 0x73, 0x70, 0x6f, 0x74, 0x5f, 0x6e, 0x75, 0x6d, 0x62, 0x65,
+	0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x73, 0x70, 0x6f, 0x74, 0x4e, 0x75, 0x6d,
+	0x62, 0x65, 0x72, 0x12, 0x23, 0x0a, 0x0d, 0x6c, 0x69, 0x63, 0x65, 0x6e, 0x73, 0x65, 0x5f, 0x70,
+	0x6c, 0x61, 0x74, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x6c, 0x69, 0x63, 0x65,
+	0x6e, 0x73, 0x65, 0x50, 0x6c, 0x61, 0x74, 0x65, 0x12, 0x29, 0x0a, 0x10, 0x70, 0x61, 0x79, 0x6d,
+	0x65, 0x6e, 0x74, 0x5f, 0x6f, 0x76, 0x65, 0x72, 0x72, 0x69, 0x64, 0x65, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0f, 0x70, 0x61, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x4f, 0x76, 0x65, 0x72, 0x72,
+	0x69, 0x64, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x65, 0x76, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x02, 0x65, 0x76, 0x12, 0x23, 0x0a, 0x0d, 0x70, 0x65, 0x72, 0x6d, 0x69, 0x74, 0x5f, 0x6e, 0x75,
+	0x6d, 0x62, 0x65, 0x72, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x70, 0x65, 0x72, 0x6d,
+	0x69, 0x74, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x12, 0x17, 0x0a, 0x07, 0x67, 0x61, 0x74, 0x65,
+	0x5f, 0x69, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x67, 0x61, 0x74, 0x65, 0x49,
+	0x64, 0x22, 0x7a, 0x0a, 0x11, 0x54, 0x69, 0x63, 0x6b, 0x65, 0x74, 0x45, 0x78, 0x69, 0x74, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x21, 0x0a, 0x0c, 0x74, 0x69, 0x63, 0x6b, 0x65, 0x74,
+	0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x74, 0x69,
+	0x63, 0x6b, 0x65, 0x74, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x29, 0x0a, 0x10, 0x70, 0x61, 0x79,
+	0x6d, 0x65, 0x6e, 0x74, 0x5f, 0x6f, 0x76, 0x65, 0x72, 0x72, 0x69, 0x64, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0f, 0x70, 0x61, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x4f, 0x76, 0x65, 0x72,
+	0x72, 0x69, 0x64, 0x65, 0x12, 0x17, 0x0a, 0x07, 0x67, 0x61, 0x74, 0x65, 0x5f, 0x69, 0x64, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x67, 0x61, 0x74, 0x65, 0x49, 0x64, 0x22, 0x80, 0x01,
+	0x0a, 0x15, 0x4c, 0x6f, 0x73, 0x74, 0x54, 0x69, 0x63, 0x6b, 0x65, 0x74, 0x45, 0x78, 0x69, 0x74,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x23, 0x0a, 0x0d, 0x6c, 0x69, 0x63, 0x65, 0x6e,
+	0x73, 0x65, 0x5f, 0x70, 0x6c, 0x61, 0x74, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c,
+	0x6c, 0x69, 0x63, 0x65, 0x6e, 0x73, 0x65, 0x50, 0x6c, 0x61, 0x74, 0x65, 0x12, 0x29, 0x0a, 0x10,
+	0x70, 0x61, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x5f, 0x6f, 0x76, 0x65, 0x72, 0x72, 0x69, 0x64, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x70, 0x61, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x4f,
+	0x76, 0x65, 0x72, 0x72, 0x69, 0x64, 0x65, 0x12, 0x17, 0x0a, 0x07, 0x67, 0x61, 0x74, 0x65, 0x5f,
+	0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x67, 0x61, 0x74, 0x65, 0x49, 0x64,
+	0x22, 0x5a, 0x0a, 0x12, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x73, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1f, 0x0a, 0x0b, 0x73, 0x70, 0x6f, 0x74, 0x5f, 0x6e,
+	0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x73, 0x70, 0x6f,
+	0x74, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x12, 0x23, 0x0a, 0x0d, 0x6c, 0x69, 0x63, 0x65, 0x6e,
+	0x73, 0x65, 0x5f, 0x70, 0x6c, 0x61, 0x74, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c,
+	0x6c, 0x69, 0x63, 0x65, 0x6e, 0x73, 0x65, 0x50, 0x6c, 0x61, 0x74, 0x65, 0x22, 0x47, 0x0a, 0x13,
+	0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x12, 0x30, 0x0a, 0x07, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x73, 0x18, 0x01,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x70, 0x64, 0x65, 0x61, 0x2e, 0x76, 0x31, 0x2e, 0x56,
+	0x65, 0x68, 0x69, 0x63, 0x6c, 0x65, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x52, 0x07, 0x72, 0x65,
+	0x63, 0x6f, 0x72, 0x64, 0x73, 0x22, 0x67, 0x0a, 0x0c, 0x57, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x22, 0x0a, 0x0d, 0x6c, 0x61, 0x73, 0x74, 0x5f, 0x65, 0x76,
+	0x65, 0x6e, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0b, 0x6c, 0x61,
+	0x73, 0x74, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x49, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x7a, 0x6f, 0x6e,
+	0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x7a, 0x6f, 0x6e, 0x65, 0x73, 0x12,
+	0x1d, 0x0a, 0x0a, 0x73, 0x70, 0x6f, 0x74, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x73, 0x18, 0x03, 0x20,
+	0x03, 0x28, 0x09, 0x52, 0x09, 0x73, 0x70, 0x6f, 0x74, 0x54, 0x79, 0x70, 0x65, 0x73, 0x22, 0xf3,
+	0x01, 0x0a, 0x12, 0x41, 0x76, 0x61, 0x69, 0x6c, 0x61, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x79, 0x43,
+	0x68, 0x61, 0x6e, 0x67, 0x65, 0x12, 0x19, 0x0a, 0x08, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x5f, 0x69,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x49, 0x64,
+	0x12, 0x1d, 0x0a, 0x0a, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x54, 0x79, 0x70, 0x65, 0x12,
+	0x1f, 0x0a, 0x0b, 0x73, 0x70, 0x6f, 0x74, 0x5f, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x73, 0x70, 0x6f, 0x74, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72,
+	0x12, 0x1b, 0x0a, 0x09, 0x73, 0x70, 0x6f, 0x74, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x08, 0x73, 0x70, 0x6f, 0x74, 0x54, 0x79, 0x70, 0x65, 0x12, 0x12, 0x0a,
+	0x04, 0x7a, 0x6f, 0x6e, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x7a, 0x6f, 0x6e,
+	0x65, 0x12, 0x21, 0x0a, 0x0c, 0x69, 0x73, 0x5f, 0x61, 0x76, 0x61, 0x69, 0x6c, 0x61, 0x62, 0x6c,
+	0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0b, 0x69, 0x73, 0x41, 0x76, 0x61, 0x69, 0x6c,
+	0x61, 0x62, 0x6c, 0x65, 0x12, 0x2e, 0x0a, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x07, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x04,
+	0x74, 0x69, 0x6d, 0x65, 0x32, 0xcc, 0x02, 0x0a, 0x0c, 0x50, 0x61, 0x72, 0x6b, 0x69, 0x6e, 0x67,
+	0x53, 0x70, 0x6f, 0x74, 0x73, 0x12, 0x42, 0x0a, 0x09, 0x4c, 0x69, 0x73, 0x74, 0x53, 0x70, 0x6f,
+	0x74, 0x73, 0x12, 0x19, 0x2e, 0x70, 0x64, 0x65, 0x61, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73,
+	0x74, 0x53, 0x70, 0x6f, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1a, 0x2e,
+	0x70, 0x64, 0x65, 0x61, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x53, 0x70, 0x6f, 0x74,
+	0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x38, 0x0a, 0x07, 0x47, 0x65, 0x74,
+	0x53, 0x70, 0x6f, 0x74, 0x12, 0x17, 0x2e, 0x70, 0x64, 0x65, 0x61, 0x2e, 0x76, 0x31, 0x2e, 0x47,
+	0x65, 0x74, 0x53, 0x70, 0x6f, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e,
+	0x70, 0x64, 0x65, 0x61, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x61, 0x72, 0x6b, 0x69, 0x6e, 0x67, 0x53,
+	0x70, 0x6f, 0x74, 0x12, 0x3e, 0x0a, 0x0a, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x53, 0x70, 0x6f,
+	0x74, 0x12, 0x1a, 0x2e, 0x70, 0x64, 0x65, 0x61, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x72, 0x65, 0x61,
+	0x74, 0x65, 0x53, 0x70, 0x6f, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e,
+	0x70, 0x64, 0x65, 0x61, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x61, 0x72, 0x6b, 0x69, 0x6e, 0x67, 0x53,
+	0x70, 0x6f, 0x74, 0x12, 0x3e, 0x0a, 0x0a, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x53, 0x70, 0x6f,
+	0x74, 0x12, 0x1a, 0x2e, 0x70, 0x64, 0x65, 0x61, 0x2e, 0x76, 0x31, 0x2e, 0x55, 0x70, 0x64, 0x61,
+	0x74, 0x65, 0x53, 0x70, 0x6f, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e,
+	0x70, 0x64, 0x65, 0x61, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x61, 0x72, 0x6b, 0x69, 0x6e, 0x67, 0x53,
+	0x70, 0x6f, 0x74, 0x12, 0x3e, 0x0a, 0x0a, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x53, 0x70, 0x6f,
+	0x74, 0x12, 0x1a, 0x2e, 0x70, 0x64, 0x65, 0x61, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x65, 0x6c, 0x65,
+	0x74, 0x65, 0x53, 0x70, 0x6f, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e,
+	0x70, 0x64, 0x65, 0x61, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x61, 0x72, 0x6b, 0x69, 0x6e, 0x67, 0x53,
+	0x70, 0x6f, 0x74, 0x32, 0xed, 0x02, 0x0a, 0x08, 0x56, 0x65, 0x68, 0x69, 0x63, 0x6c, 0x65, 0x73,
+	0x12, 0x3d, 0x0a, 0x0d, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x45, 0x6e, 0x74, 0x72,
+	0x79, 0x12, 0x14, 0x2e, 0x70, 0x64, 0x65, 0x61, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x61, 0x79,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x70, 0x64, 0x65, 0x61, 0x2e, 0x76,
+	0x31, 0x2e, 0x56, 0x65, 0x68, 0x69, 0x63, 0x6c, 0x65, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x12,
+	0x3c, 0x0a, 0x0c, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x45, 0x78, 0x69, 0x74, 0x12,
+	0x14, 0x2e, 0x70, 0x64, 0x65, 0x61, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x61, 0x79, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x70, 0x64, 0x65, 0x61, 0x2e, 0x76, 0x31, 0x2e,
+	0x56, 0x65, 0x68, 0x69, 0x63, 0x6c, 0x65, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x12, 0x48, 0x0a,
+	0x12, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x54, 0x69, 0x63, 0x6b, 0x65, 0x74, 0x45,
+	0x78, 0x69, 0x74, 0x12, 0x1a, 0x2e, 0x70, 0x64, 0x65, 0x61, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x69,
+	0x63, 0x6b, 0x65, 0x74, 0x45, 0x78, 0x69, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x16, 0x2e, 0x70, 0x64, 0x65, 0x61, 0x2e, 0x76, 0x31, 0x2e, 0x56, 0x65, 0x68, 0x69, 0x63, 0x6c,
+	0x65, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x12, 0x50, 0x0a, 0x16, 0x52, 0x65, 0x67, 0x69, 0x73,
+	0x74, 0x65, 0x72, 0x4c, 0x6f, 0x73, 0x74, 0x54, 0x69, 0x63, 0x6b, 0x65, 0x74, 0x45, 0x78, 0x69,
+	0x74, 0x12, 0x1e, 0x2e, 0x70, 0x64, 0x65, 0x61, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x6f, 0x73, 0x74,
+	0x54, 0x69, 0x63, 0x6b, 0x65, 0x74, 0x45, 0x78, 0x69, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x16, 0x2e, 0x70, 0x64, 0x65, 0x61, 0x2e, 0x76, 0x31, 0x2e, 0x56, 0x65, 0x68, 0x69,
+	0x63, 0x6c, 0x65, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x12, 0x48, 0x0a, 0x0b, 0x4c, 0x69, 0x73,
+	0x74, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x73, 0x12, 0x1b, 0x2e, 0x70, 0x64, 0x65, 0x61, 0x2e,
+	0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x73, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x70, 0x64, 0x65, 0x61, 0x2e, 0x76, 0x31, 0x2e,
+	0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x32, 0x4d, 0x0a, 0x0c, 0x41, 0x76, 0x61, 0x69, 0x6c, 0x61, 0x62, 0x69, 0x6c,
+	0x69, 0x74, 0x79, 0x12, 0x3d, 0x0a, 0x05, 0x57, 0x61, 0x74, 0x63, 0x68, 0x12, 0x15, 0x2e, 0x70,
+	0x64, 0x65, 0x61, 0x2e, 0x76, 0x31, 0x2e, 0x57, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x1b, 0x2e, 0x70, 0x64, 0x65, 0x61, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x76,
+	0x61, 0x69, 0x6c, 0x61, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x79, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65,
+	0x30, 0x01, 0x42, 0x1e, 0x5a, 0x1c, 0x50, 0x44, 0x45, 0x41, 0x2f, 0x69, 0x6e, 0x74, 0x65, 0x72,
+	0x6e, 0x61, 0x6c, 0x2f, 0x67, 0x72, 0x70, 0x63, 0x61, 0x70, 0x69, 0x2f, 0x70, 0x64, 0x65, 0x61,
+	0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_pdea_proto_rawDescOnce sync.Once
+	file_pdea_proto_rawDescData = file_pdea_proto_rawDesc
+)
+
+func file_pdea_proto_rawDescGZIP() []byte {
+	file_pdea_proto_rawDescOnce.Do(func() {
+		file_pdea_proto_rawDescData = protoimpl.X.CompressGZIP(file_pdea_proto_rawDescData)
+	})
+	return file_pdea_proto_rawDescData
+}
+
+var file_pdea_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
+var file_pdea_proto_goTypes = []interface{}{
+	(*ParkingSpot)(nil),           // 0: pdea.v1.ParkingSpot
+	(*ListSpotsRequest)(nil),      // 1: pdea.v1.ListSpotsRequest
+	(*ListSpotsResponse)(nil),     // 2: pdea.v1.ListSpotsResponse
+	(*GetSpotRequest)(nil),        // 3: pdea.v1.GetSpotRequest
+	(*CreateSpotRequest)(nil),     // 4: pdea.v1.CreateSpotRequest
+	(*UpdateSpotRequest)(nil),     // 5: pdea.v1.UpdateSpotRequest
+	(*DeleteSpotRequest)(nil),     // 6: pdea.v1.DeleteSpotRequest
+	(*VehicleRecord)(nil),         // 7: pdea.v1.VehicleRecord
+	(*GateResult)(nil),            // 8: pdea.v1.GateResult
+	(*AppliedDiscount)(nil),       // 9: pdea.v1.AppliedDiscount
+	(*StayRequest)(nil),           // 10: pdea.v1.StayRequest
+	(*TicketExitRequest)(nil),     // 11: pdea.v1.TicketExitRequest
+	(*LostTicketExitRequest)(nil), // 12: pdea.v1.LostTicketExitRequest
+	(*ListRecordsRequest)(nil),    // 13: pdea.v1.ListRecordsRequest
+	(*ListRecordsResponse)(nil),   // 14: pdea.v1.ListRecordsResponse
+	(*WatchRequest)(nil),          // 15: pdea.v1.WatchRequest
+	(*AvailabilityChange)(nil),    // 16: pdea.v1.AvailabilityChange
+	(*timestamppb.Timestamp)(nil), // 17: google.protobuf.Timestamp
+}
+var file_pdea_proto_depIdxs = []int32{
+	0,  // 0: pdea.v1.ListSpotsResponse.spots:type_name -> pdea.v1.ParkingSpot
+	0,  // 1: pdea.v1.CreateSpotRequest.spot:type_name -> pdea.v1.ParkingSpot
+	0,  // 2: pdea.v1.UpdateSpotRequest.spot:type_name -> pdea.v1.ParkingSpot
+	17, // 3: pdea.v1.VehicleRecord.entry_time:type_name -> google.protobuf.Timestamp
+	17, // 4: pdea.v1.VehicleRecord.exit_time:type_name -> google.protobuf.Timestamp
+	9,  // 5: pdea.v1.VehicleRecord.discounts:type_name -> pdea.v1.AppliedDiscount
+	8,  // 6: pdea.v1.VehicleRecord.gate:type_name -> pdea.v1.GateResult
+	7,  // 7: pdea.v1.ListRecordsResponse.records:type_name -> pdea.v1.VehicleRecord
+	17, // 8: pdea.v1.AvailabilityChange.time:type_name -> google.protobuf.Timestamp
+	1,  // 9: pdea.v1.ParkingSpots.ListSpots:input_type -> pdea.v1.ListSpotsRequest
+	3,  // 10: pdea.v1.ParkingSpots.GetSpot:input_type -> pdea.v1.GetSpotRequest
+	4,  // 11: pdea.v1.ParkingSpots.CreateSpot:input_type -> pdea.v1.CreateSpotRequest
+	5,  // 12: pdea.v1.ParkingSpots.UpdateSpot:input_type -> pdea.v1.UpdateSpotRequest
+	6,  // 13: pdea.v1.ParkingSpots.DeleteSpot:input_type -> pdea.v1.DeleteSpotRequest
+	10, // 14: pdea.v1.Vehicles.RegisterEntry:input_type -> pdea.v1.StayRequest
+	10, // 15: pdea.v1.Vehicles.RegisterExit:input_type -> pdea.v1.StayRequest
+	11, // 16: pdea.v1.Vehicles.RegisterTicketExit:input_type -> pdea.v1.TicketExitRequest
+	12, // 17: pdea.v1.Vehicles.RegisterLostTicketExit:input_type -> pdea.v1.LostTicketExitRequest
+	13, // 18: pdea.v1.Vehicles.ListRecords:input_type -> pdea.v1.ListRecordsRequest
+	15, // 19: pdea.v1.Availability.Watch:input_type -> pdea.v1.WatchRequest
+	2,  // 20: pdea.v1.ParkingSpots.ListSpots:output_type -> pdea.v1.ListSpotsResponse
+	0,  // 21: pdea.v1.ParkingSpots.GetSpot:output_type -> pdea.v1.ParkingSpot
+	0,  // 22: pdea.v1.ParkingSpots.CreateSpot:output_type -> pdea.v1.ParkingSpot
+	0,  // 23: pdea.v1.ParkingSpots.UpdateSpot:output_type -> pdea.v1.ParkingSpot
+	0,  // 24: pdea.v1.ParkingSpots.DeleteSpot:output_type -> pdea.v1.ParkingSpot
+	7,  // 25: pdea.v1.Vehicles.RegisterEntry:output_type -> pdea.v1.VehicleRecord
+	7,  // 26: pdea.v1.Vehicles.RegisterExit:output_type -> pdea.v1.VehicleRecord
+	7,  // 27: pdea.v1.Vehicles.RegisterTicketExit:output_type -> pdea.v1.VehicleRecord
+	7,  // 28: pdea.v1.Vehicles.RegisterLostTicketExit:output_type -> pdea.v1.VehicleRecord
+	14, // 29: pdea.v1.Vehicles.ListRecords:output_type -> pdea.v1.ListRecordsResponse
+	16, // 30: pdea.v1.Availability.Watch:output_type -> pdea.v1.AvailabilityChange
+	20, // [20:31] is the sub-list for method output_type
+	9,  // [9:20] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
+}
+
+func init() { file_pdea_proto_init() }
+func file_pdea_proto_init() {
+	if File_pdea_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_pdea_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ParkingSpot); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pdea_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListSpotsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pdea_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListSpotsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pdea_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetSpotRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pdea_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CreateSpotRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pdea_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*UpdateSpotRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pdea_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DeleteSpotRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pdea_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*VehicleRecord); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pdea_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GateResult); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pdea_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AppliedDiscount); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pdea_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StayRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pdea_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TicketExitRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pdea_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LostTicketExitRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pdea_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListRecordsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pdea_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListRecordsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pdea_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*WatchRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pdea_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AvailabilityChange); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_pdea_proto_msgTypes[7].OneofWrappers = []interface{}{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_pdea_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   17,
+			NumExtensions: 0,
+			NumServices:   3,
+		},
+		GoTypes:           file_pdea_proto_goTypes,
+		DependencyIndexes: file_pdea_proto_depIdxs,
+		MessageInfos:      file_pdea_proto_msgTypes,
+	}.Build()
+	File_pdea_proto = out.File
+	file_pdea_proto_rawDesc = nil
+	file_pdea_proto_goTypes = nil
+	file_pdea_proto_depIdxs = nil
+}
@@ -0,0 +1,612 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: pdea.proto
+
+package pdeapb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	ParkingSpots_ListSpots_FullMethodName  = "/pdea.v1.ParkingSpots/ListSpots"
+	ParkingSpots_GetSpot_FullMethodName    = "/pdea.v1.ParkingSpots/GetSpot"
+	ParkingSpots_CreateSpot_FullMethodName = "/pdea.v1.ParkingSpots/CreateSpot"
+	ParkingSpots_UpdateSpot_FullMethodName = "/pdea.v1.ParkingSpots/UpdateSpot"
+	ParkingSpots_DeleteSpot_FullMethodName = "/pdea.v1.ParkingSpots/DeleteSpot"
+)
+
+// ParkingSpotsClient is the client API for ParkingSpots service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ParkingSpotsClient interface {
+	ListSpots(ctx context.Context, in *ListSpotsRequest, opts ...grpc.CallOption) (*ListSpotsResponse, error)
+	GetSpot(ctx context.Context, in *GetSpotRequest, opts ...grpc.CallOption) (*ParkingSpot, error)
+	CreateSpot(ctx context.Context, in *CreateSpotRequest, opts ...grpc.CallOption) (*ParkingSpot, error)
+	UpdateSpot(ctx context.Context, in *UpdateSpotRequest, opts ...grpc.CallOption) (*ParkingSpot, error)
+	DeleteSpot(ctx context.Context, in *DeleteSpotRequest, opts ...grpc.CallOption) (*ParkingSpot, error)
+}
+
+type parkingSpotsClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewParkingSpotsClient(cc grpc.ClientConnInterface) ParkingSpotsClient {
+	return &parkingSpotsClient{cc}
+}
+
+func (c *parkingSpotsClient) ListSpots(ctx context.Context, in *ListSpotsRequest, opts ...grpc.CallOption) (*ListSpotsResponse, error) {
+	out := new(ListSpotsResponse)
+	err := c.cc.Invoke(ctx, ParkingSpots_ListSpots_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *parkingSpotsClient) GetSpot(ctx context.Context, in *GetSpotRequest, opts ...grpc.CallOption) (*ParkingSpot, error) {
+	out := new(ParkingSpot)
+	err := c.cc.Invoke(ctx, ParkingSpots_GetSpot_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *parkingSpotsClient) CreateSpot(ctx context.Context, in *CreateSpotRequest, opts ...grpc.CallOption) (*ParkingSpot, error) {
+	out := new(ParkingSpot)
+	err := c.cc.Invoke(ctx, ParkingSpots_CreateSpot_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *parkingSpotsClient) UpdateSpot(ctx context.Context, in *UpdateSpotRequest, opts ...grpc.CallOption) (*ParkingSpot, error) {
+	out := new(ParkingSpot)
+	err := c.cc.Invoke(ctx, ParkingSpots_UpdateSpot_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *parkingSpotsClient) DeleteSpot(ctx context.Context, in *DeleteSpotRequest, opts ...grpc.CallOption) (*ParkingSpot, error) {
+	out := new(ParkingSpot)
+	err := c.cc.Invoke(ctx, ParkingSpots_DeleteSpot_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ParkingSpotsServer is the server API for ParkingSpots service.
+// All implementations must embed UnimplementedParkingSpotsServer
+// for forward compatibility
+type ParkingSpotsServer interface {
+	ListSpots(context.Context, *ListSpotsRequest) (*ListSpotsResponse, error)
+	GetSpot(context.Context, *GetSpotRequest) (*ParkingSpot, error)
+	CreateSpot(context.Context, *CreateSpotRequest) (*ParkingSpot, error)
+	UpdateSpot(context.Context, *UpdateSpotRequest) (*ParkingSpot, error)
+	DeleteSpot(context.Context, *DeleteSpotRequest) (*ParkingSpot, error)
+	mustEmbedUnimplementedParkingSpotsServer()
+}
+
+// UnimplementedParkingSpotsServer must be embedded to have forward compatible implementations.
+type UnimplementedParkingSpotsServer struct {
+}
+
+func (UnimplementedParkingSpotsServer) ListSpots(context.Context, *ListSpotsRequest) (*ListSpotsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSpots not implemented")
+}
+func (UnimplementedParkingSpotsServer) GetSpot(context.Context, *GetSpotRequest) (*ParkingSpot, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSpot not implemented")
+}
+func (UnimplementedParkingSpotsServer) CreateSpot(context.Context, *CreateSpotRequest) (*ParkingSpot, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateSpot not implemented")
+}
+func (UnimplementedParkingSpotsServer) UpdateSpot(context.Context, *UpdateSpotRequest) (*ParkingSpot, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateSpot not implemented")
+}
+func (UnimplementedParkingSpotsServer) DeleteSpot(context.Context, *DeleteSpotRequest) (*ParkingSpot, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteSpot not implemented")
+}
+func (UnimplementedParkingSpotsServer) mustEmbedUnimplementedParkingSpotsServer() {}
+
+// UnsafeParkingSpotsServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ParkingSpotsServer will
+// result in compilation errors.
+type UnsafeParkingSpotsServer interface {
+	mustEmbedUnimplementedParkingSpotsServer()
+}
+
+func RegisterParkingSpotsServer(s grpc.ServiceRegistrar, srv ParkingSpotsServer) {
+	s.RegisterService(&ParkingSpots_ServiceDesc, srv)
+}
+
+func _ParkingSpots_ListSpots_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSpotsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ParkingSpotsServer).ListSpots(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ParkingSpots_ListSpots_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ParkingSpotsServer).ListSpots(ctx, req.(*ListSpotsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ParkingSpots_GetSpot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSpotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ParkingSpotsServer).GetSpot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ParkingSpots_GetSpot_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ParkingSpotsServer).GetSpot(ctx, req.(*GetSpotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ParkingSpots_CreateSpot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateSpotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ParkingSpotsServer).CreateSpot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ParkingSpots_CreateSpot_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ParkingSpotsServer).CreateSpot(ctx, req.(*CreateSpotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ParkingSpots_UpdateSpot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateSpotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ParkingSpotsServer).UpdateSpot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ParkingSpots_UpdateSpot_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ParkingSpotsServer).UpdateSpot(ctx, req.(*UpdateSpotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ParkingSpots_DeleteSpot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteSpotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ParkingSpotsServer).DeleteSpot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ParkingSpots_DeleteSpot_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ParkingSpotsServer).DeleteSpot(ctx, req.(*DeleteSpotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ParkingSpots_ServiceDesc is the grpc.ServiceDesc for ParkingSpots service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ParkingSpots_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "pdea.v1.ParkingSpots",
+	HandlerType: (*ParkingSpotsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListSpots",
+			Handler:    _ParkingSpots_ListSpots_Handler,
+		},
+		{
+			MethodName: "GetSpot",
+			Handler:    _ParkingSpots_GetSpot_Handler,
+		},
+		{
+			MethodName: "CreateSpot",
+			Handler:    _ParkingSpots_CreateSpot_Handler,
+		},
+		{
+			MethodName: "UpdateSpot",
+			Handler:    _ParkingSpots_UpdateSpot_Handler,
+		},
+		{
+			MethodName: "DeleteSpot",
+			Handler:    _ParkingSpots_DeleteSpot_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "pdea.proto",
+}
+
+const (
+	Vehicles_RegisterEntry_FullMethodName          = "/pdea.v1.Vehicles/RegisterEntry"
+	Vehicles_RegisterExit_FullMethodName           = "/pdea.v1.Vehicles/RegisterExit"
+	Vehicles_RegisterTicketExit_FullMethodName     = "/pdea.v1.Vehicles/RegisterTicketExit"
+	Vehicles_RegisterLostTicketExit_FullMethodName = "/pdea.v1.Vehicles/RegisterLostTicketExit"
+	Vehicles_ListRecords_FullMethodName            = "/pdea.v1.Vehicles/ListRecords"
+)
+
+// VehiclesClient is the client API for Vehicles service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type VehiclesClient interface {
+	RegisterEntry(ctx context.Context, in *StayRequest, opts ...grpc.CallOption) (*VehicleRecord, error)
+	RegisterExit(ctx context.Context, in *StayRequest, opts ...grpc.CallOption) (*VehicleRecord, error)
+	RegisterTicketExit(ctx context.Context, in *TicketExitRequest, opts ...grpc.CallOption) (*VehicleRecord, error)
+	RegisterLostTicketExit(ctx context.Context, in *LostTicketExitRequest, opts ...grpc.CallOption) (*VehicleRecord, error)
+	ListRecords(ctx context.Context, in *ListRecordsRequest, opts ...grpc.CallOption) (*ListRecordsResponse, error)
+}
+
+type vehiclesClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewVehiclesClient(cc grpc.ClientConnInterface) VehiclesClient {
+	return &vehiclesClient{cc}
+}
+
+func (c *vehiclesClient) RegisterEntry(ctx context.Context, in *StayRequest, opts ...grpc.CallOption) (*VehicleRecord, error) {
+	out := new(VehicleRecord)
+	err := c.cc.Invoke(ctx, Vehicles_RegisterEntry_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *vehiclesClient) RegisterExit(ctx context.Context, in *StayRequest, opts ...grpc.CallOption) (*VehicleRecord, error) {
+	out := new(VehicleRecord)
+	err := c.cc.Invoke(ctx, Vehicles_RegisterExit_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *vehiclesClient) RegisterTicketExit(ctx context.Context, in *TicketExitRequest, opts ...grpc.CallOption) (*VehicleRecord, error) {
+	out := new(VehicleRecord)
+	err := c.cc.Invoke(ctx, Vehicles_RegisterTicketExit_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *vehiclesClient) RegisterLostTicketExit(ctx context.Context, in *LostTicketExitRequest, opts ...grpc.CallOption) (*VehicleRecord, error) {
+	out := new(VehicleRecord)
+	err := c.cc.Invoke(ctx, Vehicles_RegisterLostTicketExit_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *vehiclesClient) ListRecords(ctx context.Context, in *ListRecordsRequest, opts ...grpc.CallOption) (*ListRecordsResponse, error) {
+	out := new(ListRecordsResponse)
+	err := c.cc.Invoke(ctx, Vehicles_ListRecords_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// VehiclesServer is the server API for Vehicles service.
+// All implementations must embed UnimplementedVehiclesServer
+// for forward compatibility
+type VehiclesServer interface {
+	RegisterEntry(context.Context, *StayRequest) (*VehicleRecord, error)
+	RegisterExit(context.Context, *StayRequest) (*VehicleRecord, error)
+	RegisterTicketExit(context.Context, *TicketExitRequest) (*VehicleRecord, error)
+	RegisterLostTicketExit(context.Context, *LostTicketExitRequest) (*VehicleRecord, error)
+	ListRecords(context.Context, *ListRecordsRequest) (*ListRecordsResponse, error)
+	mustEmbedUnimplementedVehiclesServer()
+}
+
+// UnimplementedVehiclesServer must be embedded to have forward compatible implementations.
+type UnimplementedVehiclesServer struct {
+}
+
+func (UnimplementedVehiclesServer) RegisterEntry(context.Context, *StayRequest) (*VehicleRecord, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RegisterEntry not implemented")
+}
+func (UnimplementedVehiclesServer) RegisterExit(context.Context, *StayRequest) (*VehicleRecord, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RegisterExit not implemented")
+}
+func (UnimplementedVehiclesServer) RegisterTicketExit(context.Context, *TicketExitRequest) (*VehicleRecord, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RegisterTicketExit not implemented")
+}
+func (UnimplementedVehiclesServer) RegisterLostTicketExit(context.Context, *LostTicketExitRequest) (*VehicleRecord, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RegisterLostTicketExit not implemented")
+}
+func (UnimplementedVehiclesServer) ListRecords(context.Context, *ListRecordsRequest) (*ListRecordsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListRecords not implemented")
+}
+func (UnimplementedVehiclesServer) mustEmbedUnimplementedVehiclesServer() {}
+
+// UnsafeVehiclesServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to VehiclesServer will
+// result in compilation errors.
+type UnsafeVehiclesServer interface {
+	mustEmbedUnimplementedVehiclesServer()
+}
+
+func RegisterVehiclesServer(s grpc.ServiceRegistrar, srv VehiclesServer) {
+	s.RegisterService(&Vehicles_ServiceDesc, srv)
+}
+
+func _Vehicles_RegisterEntry_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StayRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VehiclesServer).RegisterEntry(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Vehicles_RegisterEntry_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VehiclesServer).RegisterEntry(ctx, req.(*StayRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Vehicles_RegisterExit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StayRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VehiclesServer).RegisterExit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Vehicles_RegisterExit_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VehiclesServer).RegisterExit(ctx, req.(*StayRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Vehicles_RegisterTicketExit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TicketExitRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VehiclesServer).RegisterTicketExit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Vehicles_RegisterTicketExit_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VehiclesServer).RegisterTicketExit(ctx, req.(*TicketExitRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Vehicles_RegisterLostTicketExit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LostTicketExitRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VehiclesServer).RegisterLostTicketExit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Vehicles_RegisterLostTicketExit_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VehiclesServer).RegisterLostTicketExit(ctx, req.(*LostTicketExitRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Vehicles_ListRecords_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRecordsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VehiclesServer).ListRecords(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Vehicles_ListRecords_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VehiclesServer).ListRecords(ctx, req.(*ListRecordsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Vehicles_ServiceDesc is the grpc.ServiceDesc for Vehicles service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Vehicles_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "pdea.v1.Vehicles",
+	HandlerType: (*VehiclesServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "RegisterEntry",
+			Handler:    _Vehicles_RegisterEntry_Handler,
+		},
+		{
+			MethodName: "RegisterExit",
+			Handler:    _Vehicles_RegisterExit_Handler,
+		},
+		{
+			MethodName: "RegisterTicketExit",
+			Handler:    _Vehicles_RegisterTicketExit_Handler,
+		},
+		{
+			MethodName: "RegisterLostTicketExit",
+			Handler:    _Vehicles_RegisterLostTicketExit_Handler,
+		},
+		{
+			MethodName: "ListRecords",
+			Handler:    _Vehicles_ListRecords_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "pdea.proto",
+}
+
+const (
+	Availability_Watch_FullMethodName = "/pdea.v1.Availability/Watch"
+)
+
+// AvailabilityClient is the client API for Availability service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AvailabilityClient interface {
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (Availability_WatchClient, error)
+}
+
+type availabilityClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAvailabilityClient(cc grpc.ClientConnInterface) AvailabilityClient {
+	return &availabilityClient{cc}
+}
+
+func (c *availabilityClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (Availability_WatchClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Availability_ServiceDesc.Streams[0], Availability_Watch_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &availabilityWatchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Availability_WatchClient interface {
+	Recv() (*AvailabilityChange, error)
+	grpc.ClientStream
+}
+
+type availabilityWatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *availabilityWatchClient) Recv() (*AvailabilityChange, error) {
+	m := new(AvailabilityChange)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// AvailabilityServer is the server API for Availability service.
+// All implementations must embed UnimplementedAvailabilityServer
+// for forward compatibility
+type AvailabilityServer interface {
+	Watch(*WatchRequest, Availability_WatchServer) error
+	mustEmbedUnimplementedAvailabilityServer()
+}
+
+// UnimplementedAvailabilityServer must be embedded to have forward compatible implementations.
+type UnimplementedAvailabilityServer struct {
+}
+
+func (UnimplementedAvailabilityServer) Watch(*WatchRequest, Availability_WatchServer) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedAvailabilityServer) mustEmbedUnimplementedAvailabilityServer() {}
+
+// UnsafeAvailabilityServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AvailabilityServer will
+// result in compilation errors.
+type UnsafeAvailabilityServer interface {
+	mustEmbedUnimplementedAvailabilityServer()
+}
+
+func RegisterAvailabilityServer(s grpc.ServiceRegistrar, srv AvailabilityServer) {
+	s.RegisterService(&Availability_ServiceDesc, srv)
+}
+
+func _Availability_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AvailabilityServer).Watch(m, &availabilityWatchServer{stream})
+}
+
+type Availability_WatchServer interface {
+	Send(*AvailabilityChange) error
+	grpc.ServerStream
+}
+
+type availabilityWatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *availabilityWatchServer) Send(m *AvailabilityChange) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Availability_ServiceDesc is the grpc.ServiceDesc for Availability service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Availability_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "pdea.v1.Availability",
+	HandlerType: (*AvailabilityServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       _Availability_Watch_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "pdea.proto",
+}
@@ -0,0 +1,177 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	StatusOK          = "ok"
+	StatusDegraded    = "degraded"
+	StatusUnavailable = "unavailable"
+)
+
+// A critical check failing makes the service not ready; a non-critical one
+// only reports it as degraded.
+type Check struct {
+	Name     string
+	Critical bool
+	Fn       func(ctx context.Context) error
+}
+
+type Checker struct {
+	Timeout time.Duration
+	mu      sync.Mutex
+	checks  []Check
+}
+
+func NewChecker() *Checker {
+	return &Checker{Timeout: 2 * time.Second}
+}
+
+func (c *Checker) Add(name string, critical bool, fn func(ctx context.Context) error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, Check{Name: name, Critical: critical, Fn: fn})
+}
+
+type Result struct {
+	Name     string `json:"name"`
+	Critical bool   `json:"critical"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status string   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+func (c *Checker) Run(ctx context.Context) Report {
+	c.mu.Lock()
+	checks := append([]Check(nil), c.checks...)
+	c.mu.Unlock()
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, ch := range checks {
+		wg.Add(1)
+		go func(i int, ch Check) {
+			defer wg.Done()
+			res := Result{Name: ch.Name, Critical: ch.Critical, Status: StatusOK}
+			if err := ch.Fn(ctx); err != nil {
+				res.Status = StatusUnavailable
+				res.Error = err.Error()
+			}
+			results[i] = res
+		}(i, ch)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: results}
+	for _, res := range results {
+		if res.Status == StatusOK {
+			continue
+		}
+		if res.Critical {
+			report.Status = StatusUnavailable
+			break
+		}
+		report.Status = StatusDegraded
+	}
+	return report
+}
+
+// Liveness only says the process is serving HTTP; it never touches
+// dependencies so a database outage does not get the process restarted.
+func (c *Checker) Liveness(w http.ResponseWriter, r *http.Request) {
+	resJson, _ := json.Marshal(Report{Status: StatusOK})
+	w.WriteHeader(http.StatusOK)
+	w.Write(resJson)
+}
+
+func (c *Checker) Readiness(w http.ResponseWriter, r *http.Request) {
+	report := c.Run(r.Context())
+	code := http.StatusOK
+	if report.Status == StatusUnavailable {
+		code = http.StatusServiceUnavailable
+	}
+	resJson, _ := json.Marshal(report)
+	w.WriteHeader(code)
+	w.Write(resJson)
+}
+
+// WaitForDB pings the database with exponential backoff until it answers or
+// timeout elapses, so a service started alongside postgres does not come
+// up without it.
+func WaitForDB(db *sql.DB, timeout time.Duration, logger *slog.Logger) error {
+	deadline := time.Now().Add(timeout)
+	backoff := 500 * time.Millisecond
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		err := db.PingContext(ctx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		if time.Now().Add(backoff).After(deadline) {
+			return fmt.Errorf("database not reachable after %d attempts: %w", attempt, err)
+		}
+		logger.Warn("database not reachable, retrying", "attempt", attempt, "retry_in", backoff.String(), "error", err)
+		time.Sleep(backoff)
+		backoff *= 2
+		if backoff > 10*time.Second {
+			backoff = 10 * time.Second
+		}
+	}
+}
+
+func Ping(db *sql.DB) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		return db.PingContext(ctx)
+	}
+}
+
+func migrateVersions(db *sql.DB) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+service TEXT PRIMARY KEY,
+version INT NOT NULL,
+applied_at TIMESTAMPTZ NOT NULL
+);`)
+	return err
+}
+
+// RecordSchemaVersion marks the service's schema as migrated to version.
+func RecordSchemaVersion(db *sql.DB, service string, version int) error {
+	if err := migrateVersions(db); err != nil {
+		return err
+	}
+	qr := `INSERT INTO schema_migrations (service, version, applied_at) VALUES ($1, $2, $3)
+ON CONFLICT (service) DO UPDATE SET version = $2, applied_at = $3;`
+	_, err := db.Exec(qr, service, version, time.Now().UTC())
+	return err
+}
+
+// SchemaVersion fails when the recorded schema is older than this binary
+// expects, e.g. because another replica rolled the schema back.
+func SchemaVersion(db *sql.DB, service string, want int) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		var got int
+		err := db.QueryRowContext(ctx, `select version from schema_migrations where service = $1;`, service).Scan(&got)
+		if err != nil {
+			return err
+		}
+		if got < want {
+			return fmt.Errorf("schema version %d, want %d", got, want)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,81 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// requestIDMetadata is RequestIDHeader as gRPC metadata keys are lower case.
+const requestIDMetadata = "x-request-id"
+
+// withRequest is Middleware's setup for a gRPC call: it accepts the caller's
+// x-request-id (or generates one), echoes it in the response header and
+// attaches a logger carrying it to ctx.
+func withRequest(ctx context.Context, logger *slog.Logger) (context.Context, *slog.Logger) {
+	var id string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(requestIDMetadata); len(v) > 0 {
+			id = v[0]
+		}
+	}
+	if id == "" || len(id) > 128 {
+		id = newRequestID()
+	}
+	grpc.SetHeader(ctx, metadata.Pairs(requestIDMetadata, id))
+	reqLogger := logger.With("request_id", id)
+	ctx = context.WithValue(ctx, requestIDKey{}, id)
+	return context.WithValue(ctx, loggerKey{}, reqLogger), reqLogger
+}
+
+func logCall(ctx context.Context, logger *slog.Logger, method string, start time.Time, err error) {
+	remote := ""
+	if p, ok := peer.FromContext(ctx); ok {
+		remote = p.Addr.String()
+	}
+	logger.Info("access",
+		"method", "GRPC",
+		"route", method,
+		"code", status.Code(err).String(),
+		"latency_ms", float64(time.Since(start).Microseconds())/1000,
+		"remote", remote,
+	)
+}
+
+// UnaryInterceptor is Middleware for unary gRPC calls, logging the full
+// method name as the route and the status code.
+func UnaryInterceptor(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		ctx, reqLogger := withRequest(ctx, logger)
+		resp, err := handler(ctx, req)
+		logCall(ctx, reqLogger, info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+// StreamInterceptor is UnaryInterceptor for streams, logged once the
+// stream ends.
+func StreamInterceptor(logger *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		ctx, reqLogger := withRequest(ss.Context(), logger)
+		err := handler(srv, &loggedStream{ServerStream: ss, ctx: ctx})
+		logCall(ctx, reqLogger, info.FullMethod, start, err)
+		return err
+	}
+}
+
+type loggedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *loggedStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,146 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// New builds a logger writing to w. level is debug, info, warn or error and
+// format is json or text.
+func New(w io.Writer, level, format string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q", level)
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+	switch strings.ToLower(format) {
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	case "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	}
+	return nil, fmt.Errorf("invalid log format %q", format)
+}
+
+// NewFromEnv reads PDEA_LOG_LEVEL (default info) and PDEA_LOG_FORMAT
+// (default text) and logs to stderr with the service name attached.
+func NewFromEnv(service string) (*slog.Logger, error) {
+	level := os.Getenv("PDEA_LOG_LEVEL")
+	if level == "" {
+		level = "info"
+	}
+	format := os.Getenv("PDEA_LOG_FORMAT")
+	if format == "" {
+		format = "text"
+	}
+	logger, err := New(os.Stderr, level, format)
+	if err != nil {
+		return nil, err
+	}
+	return logger.With("service", service), nil
+}
+
+type loggerKey struct{}
+type requestIDKey struct{}
+
+// FromContext returns the request scoped logger, or the default logger
+// outside of a request.
+func FromContext(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
+
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+func newRequestID() string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// Middleware accepts the caller's X-Request-ID (or generates one), echoes it
+// on the response, attaches a logger carrying it to the request context and
+// writes an access log line once the handler returns.
+func Middleware(logger *slog.Logger) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			id := r.Header.Get(RequestIDHeader)
+			if id == "" || len(id) > 128 {
+				id = newRequestID()
+			}
+			w.Header().Set(RequestIDHeader, id)
+			reqLogger := logger.With("request_id", id)
+			ctx := context.WithValue(r.Context(), requestIDKey{}, id)
+			ctx = context.WithValue(ctx, loggerKey{}, reqLogger)
+
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r.WithContext(ctx))
+
+			route := r.URL.Path
+			if cur := mux.CurrentRoute(r); cur != nil {
+				if t, err := cur.GetPathTemplate(); err == nil {
+					route = t
+				}
+			}
+			reqLogger.Info("access",
+				"method", r.Method,
+				"route", route,
+				"path", r.URL.Path,
+				"status", rec.status,
+				"bytes", rec.bytes,
+				"latency_ms", float64(time.Since(start).Microseconds())/1000,
+				"remote", r.RemoteAddr,
+			)
+		})
+	}
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	if !s.wroteHeader {
+		s.status = code
+		s.wroteHeader = true
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	s.wroteHeader = true
+	n, err := s.ResponseWriter.Write(b)
+	s.bytes += n
+	return n, err
+}
+
+func (s *statusRecorder) Flush() {
+	if f, ok := s.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
@@ -0,0 +1,114 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var dbDuration = NewHistogramVec("pdea_db_query_duration_seconds", "Database round trip time by operation.", DefBuckets, "service", "op")
+
+// OpenDB opens a postgres pool whose connections time every Query and Exec.
+func OpenDB(service, dsn string) (*sql.DB, error) {
+	c, err := pq.NewConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+	db := sql.OpenDB(&connector{Connector: c, service: service})
+	registerDBStats(service, db)
+	return db, nil
+}
+
+func registerDBStats(service string, db *sql.DB) {
+	stat := func(name, help string, value func(sql.DBStats) float64) {
+		NewGaugeFunc(name, help, []string{"service"}, func() []Sample {
+			return []Sample{{Values: []string{service}, Value: value(db.Stats())}}
+		})
+	}
+	stat("pdea_db_max_open_connections", "Maximum number of open connections to the database.", func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) })
+	stat("pdea_db_open_connections", "Established connections, in use and idle.", func(s sql.DBStats) float64 { return float64(s.OpenConnections) })
+	stat("pdea_db_in_use_connections", "Connections currently in use.", func(s sql.DBStats) float64 { return float64(s.InUse) })
+	stat("pdea_db_idle_connections", "Idle connections.", func(s sql.DBStats) float64 { return float64(s.Idle) })
+	stat("pdea_db_wait_count", "Total number of connections waited for.", func(s sql.DBStats) float64 { return float64(s.WaitCount) })
+	stat("pdea_db_wait_duration_seconds", "Total time blocked waiting for a new connection.", func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() })
+}
+
+type connector struct {
+	driver.Connector
+	service string
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	cn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{Conn: cn, service: c.service}, nil
+}
+
+// conn forwards the optional driver interfaces pq implements so database/sql
+// keeps using the context aware fast paths and connection validation.
+type conn struct {
+	driver.Conn
+	service string
+}
+
+func (c *conn) observe(op string, start time.Time) {
+	dbDuration.Observe(time.Since(start).Seconds(), c.service, op)
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	defer c.observe("query", time.Now())
+	return q.QueryContext(ctx, query, args)
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	defer c.observe("exec", time.Now())
+	return e.ExecContext(ctx, query, args)
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return p.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+func (c *conn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *conn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *conn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
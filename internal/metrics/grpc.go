@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	grpcRequests = NewCounterVec("pdea_grpc_requests_total", "gRPC calls by full method name and status code.", "service", "method", "code")
+	grpcDuration = NewHistogramVec("pdea_grpc_request_duration_seconds", "gRPC call latency by full method name.", DefBuckets, "service", "method")
+)
+
+func observeGRPC(service, method string, start time.Time, err error) {
+	grpcRequests.Inc(service, method, status.Code(err).String())
+	grpcDuration.Observe(time.Since(start).Seconds(), service, method)
+}
+
+// UnaryInterceptor records call counts and latency labelled with the full
+// method name, the gRPC counterpart of Middleware.
+func UnaryInterceptor(service string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		observeGRPC(service, info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+// StreamInterceptor records streams like UnaryInterceptor; the latency is
+// how long the stream stayed open.
+func StreamInterceptor(service string) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		observeGRPC(service, info.FullMethod, start, err)
+		return err
+	}
+}
@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+var (
+	httpRequests = NewCounterVec("pdea_http_requests_total", "HTTP requests by mux route, method and status code.", "service", "route", "method", "code")
+	httpDuration = NewHistogramVec("pdea_http_request_duration_seconds", "HTTP request latency by mux route and method.", DefBuckets, "service", "route", "method")
+)
+
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusWriter) WriteHeader(code int) {
+	if s.status == 0 {
+		s.status = code
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusWriter) Write(b []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	return s.ResponseWriter.Write(b)
+}
+
+func (s *statusWriter) Flush() {
+	if f, ok := s.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (s *statusWriter) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+// Middleware records request counts and latency labelled with the route
+// template, so /api/parking-spots/1 and /api/parking-spots/2 share a series.
+func Middleware(service string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			sw := &statusWriter{ResponseWriter: w}
+			next.ServeHTTP(sw, r)
+			route := "unmatched"
+			if cur := mux.CurrentRoute(r); cur != nil {
+				if t, err := cur.GetPathTemplate(); err == nil {
+					route = t
+				}
+			}
+			if sw.status == 0 {
+				sw.status = http.StatusOK
+			}
+			httpRequests.Inc(service, route, r.Method, strconv.Itoa(sw.status))
+			httpDuration.Observe(time.Since(start).Seconds(), service, route, r.Method)
+		})
+	}
+}
@@ -0,0 +1,278 @@
+// Package metrics is a small Prometheus text-format registry. It covers the
+// counters, histograms and scrape-time gauges the services need without
+// pulling in the client library.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type family interface {
+	write(w io.Writer)
+}
+
+type Registry struct {
+	mu       sync.Mutex
+	names    []string
+	families map[string]family
+}
+
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]family)}
+}
+
+var Default = NewRegistry()
+
+// register returns the family already registered under name, if any, so
+// two services running in one process share their metrics. Registering a
+// name again as a different kind of metric is a programming error and
+// panics.
+func (r *Registry) register(name string, f family) family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing, ok := r.families[name]; ok {
+		if kind(existing) != kind(f) {
+			panic(fmt.Sprintf("metrics: %s registered as %s, cannot register it again as %s", name, kind(existing), kind(f)))
+		}
+		return existing
+	}
+	r.families[name] = f
+	r.names = append(r.names, name)
+	sort.Strings(r.names)
+	return f
+}
+
+func kind(f family) string {
+	switch f.(type) {
+	case *CounterVec:
+		return "a counter"
+	case *HistogramVec:
+		return "a histogram"
+	case *gaugeFamily:
+		return "a gauge"
+	}
+	return fmt.Sprintf("%T", f)
+}
+
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	fams := make([]family, 0, len(r.names))
+	for _, n := range r.names {
+		fams = append(fams, r.families[n])
+	}
+	r.mu.Unlock()
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	for _, f := range fams {
+		f.write(w)
+	}
+}
+
+func Handler() http.Handler {
+	return Default
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatLabels(names, values []string, extra ...string) string {
+	if len(names) == 0 && len(extra) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(names)+1)
+	for i, n := range names {
+		parts = append(parts, fmt.Sprintf(`%s="%s"`, n, labelEscaper.Replace(values[i])))
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		parts = append(parts, fmt.Sprintf(`%s="%s"`, extra[i], extra[i+1]))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func key(values []string) string {
+	return strings.Join(values, "\xff")
+}
+
+type CounterVec struct {
+	name, help string
+	labels     []string
+	mu         sync.Mutex
+	keys       []string
+	values     map[string]float64
+	labelVals  map[string][]string
+}
+
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{name: name, help: help, labels: labels, values: make(map[string]float64), labelVals: make(map[string][]string)}
+	return Default.register(name, c).(*CounterVec)
+}
+
+func (c *CounterVec) Add(v float64, values ...string) {
+	k := key(values)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.values[k]; !ok {
+		c.keys = append(c.keys, k)
+		c.labelVals[k] = append([]string(nil), values...)
+	}
+	c.values[k] += v
+}
+
+func (c *CounterVec) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+func (c *CounterVec) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+	for _, k := range c.keys {
+		fmt.Fprintf(w, "%s%s %s\n", c.name, formatLabels(c.labels, c.labelVals[k]), formatFloat(c.values[k]))
+	}
+}
+
+type histogram struct {
+	labelVals []string
+	counts    []uint64
+	sum       float64
+	count     uint64
+}
+
+type HistogramVec struct {
+	name, help string
+	labels     []string
+	buckets    []float64
+	mu         sync.Mutex
+	keys       []string
+	series     map[string]*histogram
+}
+
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{name: name, help: help, labels: labels, buckets: buckets, series: make(map[string]*histogram)}
+	return Default.register(name, h).(*HistogramVec)
+}
+
+func (h *HistogramVec) Observe(v float64, values ...string) {
+	k := key(values)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[k]
+	if !ok {
+		s = &histogram{labelVals: append([]string(nil), values...), counts: make([]uint64, len(h.buckets))}
+		h.series[k] = s
+		h.keys = append(h.keys, k)
+	}
+	for i, b := range h.buckets {
+		if v <= b {
+			s.counts[i]++
+		}
+	}
+	s.sum += v
+	s.count++
+}
+
+func (h *HistogramVec) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", h.name, h.help, h.name)
+	for _, k := range h.keys {
+		s := h.series[k]
+		for i, b := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(h.labels, s.labelVals, "le", formatFloat(b)), s.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(h.labels, s.labelVals, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, formatLabels(h.labels, s.labelVals), formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, formatLabels(h.labels, s.labelVals), s.count)
+	}
+}
+
+type Sample struct {
+	Values []string
+	Value  float64
+}
+
+type gaugeFamily struct {
+	name, help string
+	labels     []string
+	mu         sync.Mutex
+	funcs      []func() []Sample
+	gauges     map[string]*Sample
+	keys       []string
+}
+
+func gauges(name, help string, labels []string) *gaugeFamily {
+	g := &gaugeFamily{name: name, help: help, labels: labels, gauges: make(map[string]*Sample)}
+	return Default.register(name, g).(*gaugeFamily)
+}
+
+// NewGaugeFunc adds a collector evaluated on every scrape. Several services
+// may add collectors under the same name as long as their label values
+// differ.
+func NewGaugeFunc(name, help string, labels []string, fn func() []Sample) {
+	g := gauges(name, help, labels)
+	g.mu.Lock()
+	g.funcs = append(g.funcs, fn)
+	g.mu.Unlock()
+}
+
+type GaugeVec struct {
+	fam *gaugeFamily
+}
+
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return &GaugeVec{fam: gauges(name, help, labels)}
+}
+
+func (g *GaugeVec) Add(v float64, values ...string) {
+	k := key(values)
+	g.fam.mu.Lock()
+	defer g.fam.mu.Unlock()
+	s, ok := g.fam.gauges[k]
+	if !ok {
+		s = &Sample{Values: append([]string(nil), values...)}
+		g.fam.gauges[k] = s
+		g.fam.keys = append(g.fam.keys, k)
+	}
+	s.Value += v
+}
+
+func (g *GaugeVec) Set(v float64, values ...string) {
+	g.Add(0, values...)
+	g.fam.mu.Lock()
+	g.fam.gauges[key(values)].Value = v
+	g.fam.mu.Unlock()
+}
+
+func (g *gaugeFamily) write(w io.Writer) {
+	g.mu.Lock()
+	funcs := append([]func() []Sample(nil), g.funcs...)
+	var samples []Sample
+	for _, k := range g.keys {
+		samples = append(samples, *g.gauges[k])
+	}
+	g.mu.Unlock()
+	for _, fn := range funcs {
+		samples = append(samples, fn()...)
+	}
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", g.name, g.help, g.name)
+	for _, s := range samples {
+		fmt.Fprintf(w, "%s%s %s\n", g.name, formatLabels(g.labels, s.Values), formatFloat(s.Value))
+	}
+}
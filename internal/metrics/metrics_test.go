@@ -0,0 +1,25 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegisterTypeMismatch(t *testing.T) {
+	r := NewRegistry()
+	c := &CounterVec{name: "pdea_things", values: make(map[string]float64), labelVals: make(map[string][]string)}
+	if got := r.register("pdea_things", c); got != c {
+		t.Fatalf("register = %v, want the counter", got)
+	}
+	if got := r.register("pdea_things", &CounterVec{}); got != c {
+		t.Errorf("registering a counter again = %v, want the first one", got)
+	}
+
+	defer func() {
+		msg, _ := recover().(string)
+		if !strings.Contains(msg, "pdea_things registered as a counter") || !strings.Contains(msg, "as a histogram") {
+			t.Errorf("registering a counter's name as a histogram panicked with %q", msg)
+		}
+	}()
+	r.register("pdea_things", &HistogramVec{})
+}
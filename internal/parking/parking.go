@@ -0,0 +1,183 @@
+// Package parking holds the domain types shared by the spot and vehicle
+// services and their clients.
+package parking
+
+import (
+	"strings"
+	"time"
+
+	"PDEA/internal/timefmt"
+)
+
+// ParkingSpot is a spot as stored and served by the spot service.
+// IsAvailable is kept as the database renders it ("true"/"false") for
+// compatibility with existing clients; use Available to test it.
+type ParkingSpot struct {
+	ID          int    `json:"id"`
+	SpotNumber  string `json:"spot_number"`
+	Type        string `json:"type"`
+	IsAvailable string `json:"is_available"`
+	Zone        string `json:"zone"`
+	// Connector names the charger's plug ("CCS2", "Type2", ...) and MaxKW
+	// its power; both are unset for spots without a charger. EVOnly spots
+	// turn away vehicles not registered as electric.
+	Connector string  `json:"connector,omitempty"`
+	MaxKW     float64 `json:"max_kw,omitempty"`
+	EVOnly    bool    `json:"ev_only,omitempty"`
+	// Accessible spots are kept for vehicles with an accessibility permit.
+	Accessible bool `json:"accessible,omitempty"`
+}
+
+func (p ParkingSpot) HasCharger() bool {
+	return p.Connector != ""
+}
+
+// ValidCharger reports whether the charger fields make sense together: a
+// connector needs a positive MaxKW, and MaxKW needs a connector.
+func (p ParkingSpot) ValidCharger() bool {
+	return (p.Connector == "" && p.MaxKW == 0) || (p.Connector != "" && p.MaxKW > 0)
+}
+
+func (p ParkingSpot) Available() bool {
+	switch strings.ToLower(p.IsAvailable) {
+	case "t", "true", "y", "yes", "on", "1":
+		return true
+	}
+	return false
+}
+
+// ParseAvailable reads an IsAvailable value as Available does, reporting
+// false for ok if it is neither true nor false.
+func ParseAvailable(s string) (v, ok bool) {
+	switch strings.ToLower(s) {
+	case "t", "true", "y", "yes", "on", "1":
+		return true, true
+	case "f", "false", "n", "no", "off", "0":
+		return false, true
+	}
+	return false, false
+}
+
+func AvailableString(v bool) string {
+	if v {
+		return "true"
+	}
+	return "false"
+}
+
+// Occupancy counts the spots of one type, in one zone or, with Zone empty,
+// across all zones. The accessible counts are the part of Free and Total
+// that are accessible spots.
+type Occupancy struct {
+	Type            string `json:"type"`
+	Zone            string `json:"zone"`
+	Free            int    `json:"free"`
+	Total           int    `json:"total"`
+	AccessibleFree  int    `json:"accessible_free"`
+	AccessibleTotal int    `json:"accessible_total"`
+}
+
+// OccupiedPct is the share of Total that is taken, from 0 to 100.
+func (o Occupancy) OccupiedPct() float64 {
+	if o.Total == 0 {
+		return 0
+	}
+	return float64(o.Total-o.Free) * 100 / float64(o.Total)
+}
+
+// TypeOccupancy sums the per-zone counts in occ for spotType.
+func TypeOccupancy(occ []Occupancy, spotType string) Occupancy {
+	res := Occupancy{Type: spotType}
+	for _, o := range occ {
+		if o.Type == spotType {
+			res.Free += o.Free
+			res.Total += o.Total
+			res.AccessibleFree += o.AccessibleFree
+			res.AccessibleTotal += o.AccessibleTotal
+		}
+	}
+	return res
+}
+
+// Vehichle is one stay: an entry and, once the vehicle leaves, its exit.
+type Vehichle struct {
+	ID            int       `json:"id"`
+	SpotNumber    string    `json:"spot_number"`
+	License_plate string    `json:"license_plate"`
+	EntryTime     time.Time `json:"entry_time"`
+	ExitTime      time.Time `json:"exit_time"`
+	// HourlyCents is the rate locked in at entry and FeeCents what the stay
+	// cost at exit; both are unset for stays without a rate.
+	HourlyCents *int `json:"hourly_cents,omitempty"`
+	FeeCents    *int `json:"fee_cents,omitempty"`
+	// LostTicketCents is the part of FeeCents charged for a lost ticket.
+	LostTicketCents *int `json:"lost_ticket_cents,omitempty"`
+	// DiscountCents is what discount codes took off FeeCents; Discounts
+	// breaks it down by code and is only set on the exit response.
+	DiscountCents *int              `json:"discount_cents,omitempty"`
+	Discounts     []AppliedDiscount `json:"discounts,omitempty"`
+	TicketID      string            `json:"ticket_id,omitempty"`
+	// TicketToken is only set on the entry that issued it; it is never
+	// stored or published.
+	TicketToken string `json:"-"`
+	// PaymentOverride is the reason given for letting an unpaid stay out.
+	PaymentOverride string `json:"payment_override,omitempty"`
+	// EV is set at entry for electric vehicles; EnergyCents is the part of
+	// FeeCents charged for charging sessions.
+	EV          bool `json:"ev,omitempty"`
+	EnergyCents *int `json:"energy_cents,omitempty"`
+	// PermitNumber is the accessibility permit presented at entry.
+	// PermitWarning says why it did not cover an accessible spot, for
+	// entries let in with a warning.
+	PermitNumber  string `json:"permit_number,omitempty"`
+	PermitWarning string `json:"permit_warning,omitempty"`
+	// GateID names the barrier the vehicle is at on entry or exit, and Gate
+	// is how it answered the open command. Neither is stored.
+	GateID string      `json:"gate_id,omitempty"`
+	Gate   *GateResult `json:"gate,omitempty"`
+}
+
+// GateResult is the outcome of opening a gate: Status is opened, timeout or
+// fault, with Error saying what went wrong.
+type GateResult struct {
+	GateID string `json:"gate_id"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// AppliedDiscount is what one discount code took off a stay's fee. Value is
+// a percentage, cents or minutes by Kind.
+type AppliedDiscount struct {
+	Code  string `json:"code"`
+	Kind  string `json:"kind"`
+	Value int    `json:"value"`
+	Cents int    `json:"cents"`
+}
+
+// VehichleRes is the API rendering of a Vehichle, with times in the
+// caller's requested format and an open stay's exit left out.
+type VehichleRes struct {
+	ID              int               `json:"id"`
+	SpotNumber      string            `json:"spot_number"`
+	License_plate   string            `json:"license_plate"`
+	EntryTime       string            `json:"entry_time,omitempty"`
+	ExitTime        string            `json:"exit_time,omitempty"`
+	HourlyCents     *int              `json:"hourly_cents,omitempty"`
+	FeeCents        *int              `json:"fee_cents,omitempty"`
+	LostTicketCents *int              `json:"lost_ticket_cents,omitempty"`
+	DiscountCents   *int              `json:"discount_cents,omitempty"`
+	Discounts       []AppliedDiscount `json:"discounts,omitempty"`
+	TicketID        string            `json:"ticket_id,omitempty"`
+	TicketToken     string            `json:"ticket_token,omitempty"`
+	PaymentOverride string            `json:"payment_override,omitempty"`
+	EV              bool              `json:"ev,omitempty"`
+	EnergyCents     *int              `json:"energy_cents,omitempty"`
+	PermitNumber    string            `json:"permit_number,omitempty"`
+	PermitWarning   string            `json:"permit_warning,omitempty"`
+	GateID          string            `json:"gate_id,omitempty"`
+	Gate            *GateResult       `json:"gate,omitempty"`
+}
+
+func ToVehichleRes(v Vehichle, tf timefmt.Formatter) VehichleRes {
+	return VehichleRes{ID: v.ID, SpotNumber: v.SpotNumber, License_plate: v.License_plate, EntryTime: tf.Format(v.EntryTime), ExitTime: tf.Format(v.ExitTime), HourlyCents: v.HourlyCents, FeeCents: v.FeeCents, LostTicketCents: v.LostTicketCents, DiscountCents: v.DiscountCents, Discounts: v.Discounts, TicketID: v.TicketID, TicketToken: v.TicketToken, PaymentOverride: v.PaymentOverride, EV: v.EV, EnergyCents: v.EnergyCents, PermitNumber: v.PermitNumber, PermitWarning: v.PermitWarning, GateID: v.GateID, Gate: v.Gate}
+}
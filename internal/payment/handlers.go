@@ -0,0 +1,114 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"PDEA/internal/audit"
+	"PDEA/internal/logging"
+
+	"github.com/gorilla/mux"
+)
+
+// RegisterRoutes adds the intent lookups and state changes. Creating an
+// intent is left to the vehicle service, which knows what a stay owes.
+func (s *Service) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/payments", s.ListHandler).Methods("GET")
+	router.HandleFunc("/api/payments/{id}", s.GetHandler).Methods("GET")
+	router.HandleFunc("/api/payments/{id}/authorize", s.AuthorizeHandler).Methods("POST")
+	router.HandleFunc("/api/payments/{id}/capture", s.CaptureHandler).Methods("POST")
+	router.HandleFunc("/api/payments/{id}/refund", s.RefundHandler).Methods("POST")
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	resJson, _ := json.Marshal(v)
+	w.WriteHeader(status)
+	w.Write(resJson)
+}
+
+// WriteError maps the package's errors to responses.
+func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		http.Error(w, "Payment not found", http.StatusNotFound)
+	case errors.Is(err, ErrState), errors.Is(err, ErrInProgress):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, ErrDeclined):
+		http.Error(w, "Payment declined", http.StatusPaymentRequired)
+	default:
+		logging.FromContext(r.Context()).Error("payment failed", "error", err)
+		http.Error(w, "Payment provider error", http.StatusBadGateway)
+	}
+}
+
+func (s *Service) ListHandler(w http.ResponseWriter, r *http.Request) {
+	stayID, err := strconv.Atoi(r.URL.Query().Get("stay_id"))
+	if err != nil {
+		http.Error(w, "stay_id is required", http.StatusBadRequest)
+		return
+	}
+	res, err := s.ForStay(stayID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("list payments failed", "error", err)
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *Service) GetHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid id", http.StatusBadRequest)
+		return
+	}
+	in, err := s.Get(id)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			WriteError(w, r, err)
+			return
+		}
+		logging.FromContext(r.Context()).Error("get payment failed", "error", err)
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, in)
+}
+
+func (s *Service) AuthorizeHandler(w http.ResponseWriter, r *http.Request) {
+	var reqBody struct {
+		Method string `json:"method"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil || reqBody.Method == "" {
+		http.Error(w, "Invalid request body, method is required", http.StatusBadRequest)
+		return
+	}
+	s.change(w, r, func(ctx context.Context, id int) (Intent, error) {
+		return s.Authorize(ctx, id, reqBody.Method)
+	})
+}
+
+func (s *Service) CaptureHandler(w http.ResponseWriter, r *http.Request) {
+	s.change(w, r, s.Capture)
+}
+
+func (s *Service) RefundHandler(w http.ResponseWriter, r *http.Request) {
+	s.change(w, r, s.Refund)
+}
+
+func (s *Service) change(w http.ResponseWriter, r *http.Request, fn func(ctx context.Context, id int) (Intent, error)) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid id", http.StatusBadRequest)
+		return
+	}
+	in, err := fn(audit.ContextFromRequest(r), id)
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, in)
+}
@@ -0,0 +1,347 @@
+// Package payment takes payments for stays through a pluggable Provider.
+// Each payment is an intent in payment_intents that moves through
+//
+//	pending -> authorized -> captured -> refunded
+//	pending | authorized -> failed
+//
+// Only captured intents count towards what a stay has paid.
+package payment
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"PDEA/internal/audit"
+	"PDEA/internal/metrics"
+)
+
+const (
+	StatusPending    = "pending"
+	StatusAuthorized = "authorized"
+	StatusCaptured   = "captured"
+	StatusFailed     = "failed"
+	StatusRefunded   = "refunded"
+)
+
+var (
+	ErrNotFound = errors.New("payment not found")
+	// ErrState is returned for a transition the intent's status does not
+	// allow, e.g. capturing a pending intent.
+	ErrState = errors.New("payment is not in a state that allows this")
+	// ErrDeclined is returned, possibly wrapped, by providers that refuse
+	// an authorization or capture outright, e.g. with a 4xx answer.
+	ErrDeclined = errors.New("payment declined")
+	// ErrInProgress is returned while another call is at the provider for
+	// the same intent.
+	ErrInProgress = errors.New("payment is being processed")
+)
+
+// providerTimeout bounds each provider call. An intent is claimed for
+// longer than that, so a claim only runs out if its holder died.
+const (
+	providerTimeout = 30 * time.Second
+	claimLease      = providerTimeout + 30*time.Second
+)
+
+var transitions = metrics.NewCounterVec("pdea_payment_transitions_total", "Payment intent state changes by provider and new status.", "provider", "status")
+
+// Provider talks to a payment processor. Authorize reserves the amount on
+// the customer's payment method and returns the processor's reference for
+// it; Capture and Refund act on that reference. An error wrapping
+// ErrDeclined is final; any other is taken as transient and the call may be
+// retried.
+type Provider interface {
+	Name() string
+	Authorize(ctx context.Context, amountCents int, currency, method string) (string, error)
+	Capture(ctx context.Context, ref string, amountCents int) error
+	Refund(ctx context.Context, ref string, amountCents int) error
+}
+
+type Intent struct {
+	ID          int       `json:"id"`
+	StayID      int       `json:"stay_id"`
+	AmountCents int       `json:"amount_cents"`
+	Currency    string    `json:"currency"`
+	Status      string    `json:"status"`
+	Provider    string    `json:"provider"`
+	ProviderRef string    `json:"provider_ref,omitempty"`
+	Method      string    `json:"method,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type Service struct {
+	DB       *sql.DB
+	Provider Provider
+	Currency string
+	// Grace is how long after a capture the stay may leave for the amount
+	// it was quoted, without the time since then being charged.
+	Grace time.Duration
+	Audit *audit.Logger
+}
+
+func New(db *sql.DB, provider Provider) *Service {
+	return &Service{DB: db, Provider: provider, Currency: "USD", Grace: 15 * time.Minute}
+}
+
+// NewFromEnv picks the provider named by PDEA_PAYMENT_PROVIDER ("fake" is
+// the default and the only one built in), the currency from PDEA_CURRENCY
+// and the grace period after a capture from PDEA_PAYMENT_GRACE.
+func NewFromEnv(db *sql.DB) (*Service, error) {
+	var provider Provider
+	switch name := os.Getenv("PDEA_PAYMENT_PROVIDER"); name {
+	case "", "fake":
+		provider = &Fake{}
+	default:
+		return nil, fmt.Errorf("unknown payment provider %q", name)
+	}
+	s := New(db, provider)
+	if v := os.Getenv("PDEA_CURRENCY"); v != "" {
+		s.Currency = v
+	}
+	if v := os.Getenv("PDEA_PAYMENT_GRACE"); v != "" {
+		var err error
+		if s.Grace, err = time.ParseDuration(v); err != nil || s.Grace < 0 {
+			return nil, fmt.Errorf("invalid PDEA_PAYMENT_GRACE %q", v)
+		}
+	}
+	return s, nil
+}
+
+func (s *Service) Migrate() error {
+	schemaSQL := `
+CREATE TABLE IF NOT EXISTS payment_intents (
+id SERIAL PRIMARY KEY,
+stay_id INTEGER NOT NULL,
+amount_cents INTEGER NOT NULL,
+currency TEXT NOT NULL,
+status TEXT NOT NULL,
+provider TEXT NOT NULL,
+provider_ref TEXT NOT NULL DEFAULT '',
+method TEXT NOT NULL DEFAULT '',
+error TEXT NOT NULL DEFAULT '',
+created_at TIMESTAMPTZ NOT NULL,
+updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS payment_intents_stay ON payment_intents (stay_id);
+
+ALTER TABLE payment_intents ADD COLUMN IF NOT EXISTS processing_until TIMESTAMPTZ;
+`
+	_, err := s.DB.Exec(schemaSQL)
+	return err
+}
+
+const intentColumns = `id, stay_id, amount_cents, currency, status, provider, provider_ref, method, error, created_at, updated_at`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanIntent(row scanner) (Intent, error) {
+	var in Intent
+	err := row.Scan(&in.ID, &in.StayID, &in.AmountCents, &in.Currency, &in.Status, &in.Provider, &in.ProviderRef, &in.Method, &in.Error, &in.CreatedAt, &in.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return in, ErrNotFound
+	}
+	return in, err
+}
+
+// Create opens a pending intent for amountCents against a stay.
+func (s *Service) Create(ctx context.Context, stayID, amountCents int) (Intent, error) {
+	now := time.Now().UTC()
+	qr := `INSERT INTO payment_intents(stay_id, amount_cents, currency, status, provider, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6) returning ` + intentColumns
+	in, err := scanIntent(s.DB.QueryRow(qr, stayID, amountCents, s.Currency, StatusPending, s.Provider.Name(), now))
+	if err != nil {
+		return in, err
+	}
+	transitions.Inc(in.Provider, in.Status)
+	s.Audit.RecordContext(ctx, "create", "payment_intent", strconv.Itoa(in.ID), nil, in)
+	return in, nil
+}
+
+func (s *Service) Get(id int) (Intent, error) {
+	return scanIntent(s.DB.QueryRow(`select `+intentColumns+` from payment_intents where id = $1`, id))
+}
+
+// ForStay lists a stay's intents, oldest first.
+func (s *Service) ForStay(stayID int) ([]Intent, error) {
+	rows, err := s.DB.Query(`select `+intentColumns+` from payment_intents where stay_id = $1 order by id`, stayID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := []Intent{}
+	for rows.Next() {
+		in, err := scanIntent(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, in)
+	}
+	return res, rows.Err()
+}
+
+// Paid sums the captured intents of a stay.
+func (s *Service) Paid(stayID int) (int, error) {
+	var paid int
+	err := s.DB.QueryRow(`select coalesce(sum(amount_cents), 0) from payment_intents where stay_id = $1 and status = $2`, stayID, StatusCaptured).Scan(&paid)
+	return paid, err
+}
+
+// LastCapture returns the stay's most recently captured intent, or
+// ErrNotFound if it has none.
+func (s *Service) LastCapture(stayID int) (Intent, error) {
+	qr := `select ` + intentColumns + ` from payment_intents where stay_id = $1 and status = $2 order by updated_at desc, id desc limit 1`
+	return scanIntent(s.DB.QueryRow(qr, stayID, StatusCaptured))
+}
+
+// transition claims the intent, checks it is in status from and applies fn,
+// which talks to the provider and sets the new status. The claim is a lease
+// in processing_until rather than a row lock, so no transaction is held open
+// across the provider call; other calls for the intent get ErrInProgress
+// meanwhile, so the same intent is never authorized or captured twice.
+func (s *Service) transition(ctx context.Context, id int, action, from string, fn func(ctx context.Context, in *Intent) error) (Intent, error) {
+	before, err := s.claim(id, from)
+	if err != nil {
+		return before, err
+	}
+	in := before
+	pctx, cancel := context.WithTimeout(ctx, providerTimeout)
+	fnErr := fn(pctx, &in)
+	cancel()
+	in.UpdatedAt = time.Now().UTC()
+	qr := `UPDATE payment_intents SET status = $1, provider_ref = $2, method = $3, error = $4, updated_at = $5, processing_until = NULL
+		where id = $6 and status = $7`
+	res, err := s.DB.Exec(qr, in.Status, in.ProviderRef, in.Method, in.Error, in.UpdatedAt, in.ID, from)
+	if err != nil {
+		return before, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return before, err
+	} else if n == 0 {
+		return before, ErrState
+	}
+	if in.Status != before.Status {
+		transitions.Inc(in.Provider, in.Status)
+	}
+	s.Audit.RecordContext(ctx, action, "payment_intent", strconv.Itoa(in.ID), before, in)
+	return in, fnErr
+}
+
+// claim leases an intent in status from for one provider call. It fails
+// with ErrState if the intent is in another status and with ErrInProgress
+// if another call holds it.
+func (s *Service) claim(id int, from string) (Intent, error) {
+	qr := `UPDATE payment_intents SET processing_until = now() + make_interval(secs => $3)
+		where id = $1 and status = $2 and (processing_until is null or processing_until < now()) returning ` + intentColumns
+	in, err := scanIntent(s.DB.QueryRow(qr, id, from, claimLease.Seconds()))
+	if err != ErrNotFound {
+		return in, err
+	}
+	if in, err = s.Get(id); err != nil {
+		return in, err
+	}
+	if in.Status != from {
+		return in, ErrState
+	}
+	return in, ErrInProgress
+}
+
+// settle sets the intent's status after a provider call: to on success and
+// failed on a decline. Any other error may be transient, so the status is
+// left for the call to be retried.
+func settle(in *Intent, err error, to string) {
+	switch {
+	case err == nil:
+		in.Status = to
+		in.Error = ""
+	case errors.Is(err, ErrDeclined):
+		in.Status = StatusFailed
+		in.Error = err.Error()
+	default:
+		in.Error = err.Error()
+	}
+}
+
+// Authorize reserves a pending intent's amount on method. A decline leaves
+// the intent failed and returns ErrDeclined; after another error it stays
+// pending.
+func (s *Service) Authorize(ctx context.Context, id int, method string) (Intent, error) {
+	return s.transition(ctx, id, "authorize", StatusPending, func(ctx context.Context, in *Intent) error {
+		in.Method = method
+		ref, err := s.Provider.Authorize(ctx, in.AmountCents, in.Currency, method)
+		if err == nil {
+			in.ProviderRef = ref
+		}
+		settle(in, err, StatusAuthorized)
+		return err
+	})
+}
+
+// Capture settles an authorized intent. If the provider declines, the
+// intent is marked failed; after another error it stays authorized.
+func (s *Service) Capture(ctx context.Context, id int) (Intent, error) {
+	return s.transition(ctx, id, "capture", StatusAuthorized, func(ctx context.Context, in *Intent) error {
+		err := s.Provider.Capture(ctx, in.ProviderRef, in.AmountCents)
+		settle(in, err, StatusCaptured)
+		return err
+	})
+}
+
+// Refund returns a captured intent's amount. A provider error leaves the
+// intent captured.
+func (s *Service) Refund(ctx context.Context, id int) (Intent, error) {
+	return s.transition(ctx, id, "refund", StatusCaptured, func(ctx context.Context, in *Intent) error {
+		if err := s.Provider.Refund(ctx, in.ProviderRef, in.AmountCents); err != nil {
+			in.Error = err.Error()
+			return err
+		}
+		in.Status = StatusRefunded
+		in.Error = ""
+		return nil
+	})
+}
+
+// Fake is a provider for local testing. It approves everything except the
+// payment methods "fake_decline", which is declined at authorization, and
+// "fake_capture_fail", which authorizes but fails to capture.
+type Fake struct{}
+
+func (*Fake) Name() string { return "fake" }
+
+func (*Fake) Authorize(ctx context.Context, amountCents int, currency, method string) (string, error) {
+	if method == "fake_decline" {
+		return "", ErrDeclined
+	}
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	ref := "fake_" + hex.EncodeToString(b)
+	if method == "fake_capture_fail" {
+		ref += "_capture_fail"
+	}
+	return ref, nil
+}
+
+func (*Fake) Capture(ctx context.Context, ref string, amountCents int) error {
+	if strings.HasSuffix(ref, "_capture_fail") {
+		return fmt.Errorf("%w: fake capture failure", ErrDeclined)
+	}
+	return nil
+}
+
+func (*Fake) Refund(ctx context.Context, ref string, amountCents int) error {
+	return nil
+}
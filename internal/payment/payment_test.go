@@ -0,0 +1,191 @@
+package payment
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	_ "github.com/lib/pq"
+)
+
+func TestSettle(t *testing.T) {
+	transient := errors.New("connection reset")
+	tests := []struct {
+		name   string
+		from   string
+		err    error
+		to     string
+		status string
+		errMsg string
+	}{
+		{"authorized", StatusPending, nil, StatusAuthorized, StatusAuthorized, ""},
+		{"captured", StatusAuthorized, nil, StatusCaptured, StatusCaptured, ""},
+		{"declined", StatusPending, ErrDeclined, StatusAuthorized, StatusFailed, "payment declined"},
+		{"capture declined", StatusAuthorized, fmt.Errorf("%w: card expired", ErrDeclined), StatusCaptured, StatusFailed, "payment declined: card expired"},
+		{"transient authorize", StatusPending, transient, StatusAuthorized, StatusPending, "connection reset"},
+		{"transient capture", StatusAuthorized, context.DeadlineExceeded, StatusCaptured, StatusAuthorized, "context deadline exceeded"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := Intent{Status: tt.from, Error: "earlier failure"}
+			settle(&in, tt.err, tt.to)
+			if in.Status != tt.status || in.Error != tt.errMsg {
+				t.Errorf("settle(%s, %v) = %s %q, want %s %q", tt.from, tt.err, in.Status, in.Error, tt.status, tt.errMsg)
+			}
+		})
+	}
+}
+
+// scripted is a provider whose calls fail with the queued errors, then
+// succeed. A call waits on block while it is set.
+type scripted struct {
+	errs  []error
+	block chan struct{}
+	calls int
+}
+
+func (*scripted) Name() string { return "scripted" }
+
+func (p *scripted) next() error {
+	p.calls++
+	if p.block != nil {
+		<-p.block
+	}
+	if len(p.errs) == 0 {
+		return nil
+	}
+	err := p.errs[0]
+	p.errs = p.errs[1:]
+	return err
+}
+
+func (p *scripted) Authorize(ctx context.Context, amountCents int, currency, method string) (string, error) {
+	if err := p.next(); err != nil {
+		return "", err
+	}
+	return "ref", nil
+}
+
+func (p *scripted) Capture(ctx context.Context, ref string, amountCents int) error {
+	return p.next()
+}
+
+func (p *scripted) Refund(ctx context.Context, ref string, amountCents int) error {
+	return p.next()
+}
+
+func testService(t *testing.T, p Provider) *Service {
+	t.Helper()
+	dsn := os.Getenv("PDEA_TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("PDEA_TEST_DATABASE_URL not set")
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	s := New(db, p)
+	if err := s.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestTransition(t *testing.T) {
+	transient := errors.New("provider unreachable")
+	tests := []struct {
+		name    string
+		errs    []error
+		capture bool
+		status  string
+		err     error
+	}{
+		{"authorize", nil, false, StatusAuthorized, nil},
+		{"authorize declined", []error{ErrDeclined}, false, StatusFailed, ErrDeclined},
+		{"authorize unreachable", []error{transient}, false, StatusPending, transient},
+		{"capture", nil, true, StatusCaptured, nil},
+		{"capture declined", []error{nil, ErrDeclined}, true, StatusFailed, ErrDeclined},
+		{"capture unreachable", []error{nil, transient}, true, StatusAuthorized, transient},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &scripted{errs: tt.errs}
+			s := testService(t, p)
+			ctx := context.Background()
+			in, err := s.Create(ctx, -1, 500)
+			if err != nil {
+				t.Fatal(err)
+			}
+			in, err = s.Authorize(ctx, in.ID, "card")
+			if tt.capture {
+				if err != nil {
+					t.Fatal(err)
+				}
+				in, err = s.Capture(ctx, in.ID)
+			}
+			if !errors.Is(err, tt.err) || in.Status != tt.status {
+				t.Errorf("%s = %s, %v, want %s, %v", tt.name, in.Status, err, tt.status, tt.err)
+			}
+			if stored, err := s.Get(in.ID); err != nil || stored.Status != tt.status {
+				t.Errorf("stored intent = %+v, %v, want %s", stored, err, tt.status)
+			}
+		})
+	}
+
+	t.Run("retry after a transient error", func(t *testing.T) {
+		s := testService(t, &scripted{errs: []error{transient}})
+		ctx := context.Background()
+		in, err := s.Create(ctx, -1, 500)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.Authorize(ctx, in.ID, "card"); err != transient {
+			t.Fatalf("first Authorize = %v, want %v", err, transient)
+		}
+		if in, err = s.Authorize(ctx, in.ID, "card"); err != nil || in.Status != StatusAuthorized || in.Error != "" {
+			t.Errorf("retried Authorize = %+v, %v, want authorized without an error", in, err)
+		}
+	})
+
+	t.Run("concurrent call while at the provider", func(t *testing.T) {
+		p := &scripted{block: make(chan struct{})}
+		s := testService(t, p)
+		ctx := context.Background()
+		in, err := s.Create(ctx, -1, 500)
+		if err != nil {
+			t.Fatal(err)
+		}
+		done := make(chan error, 1)
+		go func() {
+			_, err := s.Authorize(ctx, in.ID, "card")
+			done <- err
+		}()
+		// wait until the first call holds the claim
+		for {
+			var claimed bool
+			if err := s.DB.QueryRow(`select processing_until is not null from payment_intents where id = $1`, in.ID).Scan(&claimed); err != nil {
+				t.Fatal(err)
+			}
+			if claimed {
+				break
+			}
+		}
+		if _, err := s.Authorize(ctx, in.ID, "card"); err != ErrInProgress {
+			t.Errorf("concurrent Authorize = %v, want ErrInProgress", err)
+		}
+		close(p.block)
+		if err := <-done; err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.Authorize(ctx, in.ID, "card"); err != ErrState {
+			t.Errorf("Authorize of an authorized intent = %v, want ErrState", err)
+		}
+		if p.calls != 1 {
+			t.Errorf("provider called %d times, want 1", p.calls)
+		}
+	})
+}
@@ -0,0 +1,92 @@
+package permit
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"PDEA/internal/logging"
+
+	"github.com/gorilla/mux"
+)
+
+func (g *Registry) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/accessibility-permits", g.ListHandler).Methods("GET")
+	router.HandleFunc("/api/accessibility-permits/{number}", g.GetHandler).Methods("GET")
+	router.HandleFunc("/api/accessibility-permits/{number}", g.PutHandler).Methods("PUT")
+	router.HandleFunc("/api/accessibility-permits/{number}", g.DeleteHandler).Methods("DELETE")
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	resJson, _ := json.Marshal(v)
+	w.WriteHeader(status)
+	w.Write(resJson)
+}
+
+func serverError(w http.ResponseWriter, r *http.Request, msg string, err error) {
+	logging.FromContext(r.Context()).Error(msg, "error", err)
+	http.Error(w, "Server error", http.StatusInternalServerError)
+}
+
+func (g *Registry) ListHandler(w http.ResponseWriter, r *http.Request) {
+	res, err := g.List()
+	if err != nil {
+		serverError(w, r, "list permits failed", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (g *Registry) GetHandler(w http.ResponseWriter, r *http.Request) {
+	p, err := g.Get(mux.Vars(r)["number"])
+	if err == ErrNotFound {
+		http.Error(w, "Permit not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		serverError(w, r, "get permit failed", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, p)
+}
+
+// PutHandler registers or replaces the permit named in the path; set
+// "revoked": true to withdraw one.
+func (g *Registry) PutHandler(w http.ResponseWriter, r *http.Request) {
+	var reqBody Permit
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	reqBody.Number = mux.Vars(r)["number"]
+	before, err := g.Put(reqBody)
+	if errors.Is(err, ErrInvalid) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		serverError(w, r, "put permit failed", err)
+		return
+	}
+	p, err := g.Get(reqBody.Number)
+	if err != nil {
+		serverError(w, r, "get permit failed", err)
+		return
+	}
+	g.Audit.Record(r, "set", "accessibility_permit", p.Number, before, p)
+	writeJSON(w, http.StatusOK, p)
+}
+
+func (g *Registry) DeleteHandler(w http.ResponseWriter, r *http.Request) {
+	before, err := g.Delete(mux.Vars(r)["number"])
+	if err == ErrNotFound {
+		http.Error(w, "Permit not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		serverError(w, r, "delete permit failed", err)
+		return
+	}
+	g.Audit.Record(r, "delete", "accessibility_permit", before.Number, before, nil)
+	w.WriteHeader(http.StatusOK)
+}
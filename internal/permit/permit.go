@@ -0,0 +1,165 @@
+// Package permit keeps the register of accessibility permits that entitle
+// a vehicle to park in accessible spots. A permit may be tied to one plate
+// and may expire; revoked permits stay on file so their history is kept.
+package permit
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"PDEA/internal/audit"
+)
+
+var (
+	ErrInvalid  = errors.New("invalid permit")
+	ErrNotFound = errors.New("permit not found")
+)
+
+// Verification failures; each says why a permit does not cover an entry.
+var (
+	ErrMissing = errors.New("no permit presented")
+	ErrUnknown = errors.New("permit is not registered")
+	ErrExpired = errors.New("permit has expired")
+	ErrRevoked = errors.New("permit has been revoked")
+	ErrPlate   = errors.New("permit is registered to another vehicle")
+)
+
+type Permit struct {
+	Number string `json:"number"`
+	Holder string `json:"holder"`
+	// Plate ties the permit to one vehicle; empty permits travel with the
+	// holder.
+	Plate     string     `json:"plate,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Revoked   bool       `json:"revoked"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type Registry struct {
+	DB    *sql.DB
+	Audit *audit.Logger
+}
+
+func New(db *sql.DB) *Registry {
+	return &Registry{DB: db}
+}
+
+func (g *Registry) Migrate() error {
+	schemaSQL := `
+CREATE TABLE IF NOT EXISTS accessibility_permits (
+number TEXT PRIMARY KEY,
+holder TEXT NOT NULL DEFAULT '',
+plate TEXT NOT NULL DEFAULT '',
+expires_at TIMESTAMPTZ,
+revoked BOOLEAN NOT NULL DEFAULT false,
+created_at TIMESTAMPTZ NOT NULL
+);
+`
+	_, err := g.DB.Exec(schemaSQL)
+	return err
+}
+
+const permitColumns = `number, holder, plate, expires_at, revoked, created_at`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanPermit(row scanner) (Permit, error) {
+	var p Permit
+	var expires sql.NullTime
+	err := row.Scan(&p.Number, &p.Holder, &p.Plate, &expires, &p.Revoked, &p.CreatedAt)
+	if err == sql.ErrNoRows {
+		return p, ErrNotFound
+	}
+	if expires.Valid {
+		p.ExpiresAt = &expires.Time
+	}
+	return p, err
+}
+
+func (g *Registry) List() ([]Permit, error) {
+	rows, err := g.DB.Query(`select ` + permitColumns + ` from accessibility_permits order by number`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := []Permit{}
+	for rows.Next() {
+		p, err := scanPermit(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, p)
+	}
+	return res, rows.Err()
+}
+
+func (g *Registry) Get(number string) (Permit, error) {
+	return scanPermit(g.DB.QueryRow(`select `+permitColumns+` from accessibility_permits where number = $1`, number))
+}
+
+// Put registers or replaces a permit and returns the previous version, if
+// any.
+func (g *Registry) Put(p Permit) (*Permit, error) {
+	if p.Number == "" || strings.ContainsAny(p.Number, " /") {
+		return nil, fmt.Errorf("%w: number must be non-empty without spaces or slashes", ErrInvalid)
+	}
+	old, err := g.Get(p.Number)
+	if err != nil && err != ErrNotFound {
+		return nil, err
+	}
+	qr := `INSERT INTO accessibility_permits(number, holder, plate, expires_at, revoked, created_at) VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (number) DO UPDATE SET holder = excluded.holder, plate = excluded.plate, expires_at = excluded.expires_at, revoked = excluded.revoked;`
+	if _, err = g.DB.Exec(qr, p.Number, p.Holder, p.Plate, p.ExpiresAt, p.Revoked, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+	if old.Number == "" {
+		return nil, nil
+	}
+	return &old, nil
+}
+
+func (g *Registry) Delete(number string) (Permit, error) {
+	p, err := g.Get(number)
+	if err != nil {
+		return p, err
+	}
+	_, err = g.DB.Exec(`DELETE FROM accessibility_permits where number = $1`, number)
+	return p, err
+}
+
+// Verify checks that permit number covers plate at the given time. It
+// returns one of the verification errors above, or a database error.
+func (g *Registry) Verify(number, plate string, at time.Time) error {
+	if number == "" {
+		return ErrMissing
+	}
+	p, err := g.Get(number)
+	switch {
+	case err == ErrNotFound:
+		return ErrUnknown
+	case err != nil:
+		return err
+	case p.Revoked:
+		return ErrRevoked
+	case p.ExpiresAt != nil && !at.Before(*p.ExpiresAt):
+		return ErrExpired
+	case p.Plate != "" && !strings.EqualFold(p.Plate, plate):
+		return ErrPlate
+	}
+	return nil
+}
+
+// Refused reports whether err is a verification failure rather than a
+// database error.
+func Refused(err error) bool {
+	switch err {
+	case ErrMissing, ErrUnknown, ErrExpired, ErrRevoked, ErrPlate:
+		return true
+	}
+	return false
+}
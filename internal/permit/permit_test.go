@@ -0,0 +1,58 @@
+package permit
+
+import (
+	"testing"
+	"time"
+
+	"PDEA/internal/testdb"
+)
+
+func TestVerify(t *testing.T) {
+	db := testdb.Open(t, "permit_test")
+	if _, err := db.Exec(`DROP TABLE IF EXISTS accessibility_permits;`); err != nil {
+		t.Fatal(err)
+	}
+	g := New(db)
+	if err := g.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	expires := now.Add(time.Hour)
+	for _, p := range []Permit{
+		{Number: "P-CAR", Holder: "Ann", Plate: "ABC123"},
+		{Number: "P-HOLDER", Holder: "Ben"},
+		{Number: "P-EXPIRING", Holder: "Cy", ExpiresAt: &expires},
+		{Number: "P-REVOKED", Holder: "Di", Revoked: true},
+	} {
+		if _, err := g.Put(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name, number, plate string
+		at                  time.Time
+		want                error
+	}{
+		{"plate permit", "P-CAR", "ABC123", now, nil},
+		{"plate in other case", "P-CAR", "abc123", now, nil},
+		{"other vehicle", "P-CAR", "XYZ789", now, ErrPlate},
+		{"holder permit in any vehicle", "P-HOLDER", "XYZ789", now, nil},
+		{"before expiry", "P-EXPIRING", "XYZ789", now, nil},
+		{"at expiry", "P-EXPIRING", "XYZ789", expires, ErrExpired},
+		{"revoked", "P-REVOKED", "XYZ789", now, ErrRevoked},
+		{"unknown", "P-NONE", "XYZ789", now, ErrUnknown},
+		{"none presented", "", "XYZ789", now, ErrMissing},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := g.Verify(tt.number, tt.plate, tt.at)
+			if err != tt.want {
+				t.Errorf("Verify(%q, %q) = %v, want %v", tt.number, tt.plate, err, tt.want)
+			}
+			if err != nil && !Refused(err) {
+				t.Errorf("Refused(%v) = false, want true", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,356 @@
+// Package platform wires what every PDEA service shares: the database pool,
+// authentication, audit log, event store, live events, webhooks, health
+// checks and the HTTP and gRPC server lifecycle. The spot and vehicle
+// packages add their own schema, routes and gRPC services on top.
+package platform
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"PDEA/internal/audit"
+	"PDEA/internal/auth"
+	"PDEA/internal/events"
+	"PDEA/internal/eventstore"
+	"PDEA/internal/grpcapi"
+	"PDEA/internal/grpcapi/pdeapb"
+	"PDEA/internal/health"
+	"PDEA/internal/logging"
+	"PDEA/internal/metrics"
+	"PDEA/internal/ratelimit"
+	"PDEA/internal/server"
+	"PDEA/internal/webhook"
+
+	"github.com/gorilla/mux"
+	"google.golang.org/grpc"
+)
+
+const defaultDSN = "host=localhost port=5432 user=robot password=cisco123 dbname=pdea sslmode=disable"
+
+// CommonPolicy covers the routes Router registers for every service; the
+// webhook, audit and key admin routes are left to the admin default.
+var CommonPolicy = auth.Policy{
+	"GET /healthz":                {auth.Anonymous},
+	"GET /readyz":                 {auth.Anonymous},
+	"GET /api/events/stream":      auth.AllRoles,
+	"GET /api/history/events":     {auth.RoleAttendant, auth.RoleAnalyst},
+	"GET /api/history/spots":      {auth.RoleAttendant, auth.RoleAnalyst},
+	"GET /api/history/open-stays": {auth.RoleAttendant, auth.RoleAnalyst},
+	"GET /metrics":                {auth.RoleAnalyst},
+}
+
+// CommonGRPCPolicy covers the gRPC services NewGRPC registers for every
+// service.
+var CommonGRPCPolicy = auth.Policy{
+	"GRPC /pdea.v1.Availability/Watch": auth.AllRoles,
+}
+
+type Service struct {
+	Name     string
+	Addr     string
+	DB       *sql.DB
+	Logger   *slog.Logger
+	Broker   *events.Broker
+	Webhooks *webhook.Dispatcher
+	History  *eventstore.Store
+	Audit    *audit.Logger
+	Auth     *auth.Authenticator
+	Checker  *health.Checker
+	Handler  http.Handler
+	GRPCAddr string
+	GRPC     *grpc.Server
+
+	// Workers run alongside the HTTP server and are stopped after it has
+	// drained.
+	Workers []func(ctx context.Context)
+
+	limiter *ratelimit.Limiter
+}
+
+// DSN returns PDEA_DATABASE_URL, or the local development database if unset.
+func DSN() string {
+	if dsn := os.Getenv("PDEA_DATABASE_URL"); dsn != "" {
+		return dsn
+	}
+	return defaultDSN
+}
+
+// OpenDB connects to DSN(), sizes the pool from PDEA_DB_MAX_CONNS and waits up to
+// PDEA_DB_STARTUP_TIMEOUT for the server to accept connections.
+func OpenDB(service string, logger *slog.Logger) (*sql.DB, error) {
+	db, err := metrics.OpenDB(service, DSN())
+	if err != nil {
+		return nil, fmt.Errorf("opening database: %w", err)
+	}
+
+	maxConns := 40
+	if v, err := strconv.Atoi(os.Getenv("PDEA_DB_MAX_CONNS")); err == nil && v > 0 {
+		maxConns = v
+	}
+	db.SetMaxOpenConns(maxConns)
+
+	timeout := time.Minute
+	if v, err := time.ParseDuration(os.Getenv("PDEA_DB_STARTUP_TIMEOUT")); err == nil {
+		timeout = v
+	}
+	if err = health.WaitForDB(db, timeout, logger); err != nil {
+		db.Close()
+		return nil, err
+	}
+	logger.Info("Successfully connected to the PostgreSQL database!")
+	return db, nil
+}
+
+// New connects to the database and creates the shared tables. The caller
+// runs its own migrations next and then calls Ready.
+func New(name, addr string, logger *slog.Logger) (*Service, error) {
+	db, err := OpenDB(name, logger)
+	if err != nil {
+		return nil, err
+	}
+	s := &Service{
+		Name:    name,
+		Addr:    addr,
+		DB:      db,
+		Logger:  logger,
+		Checker: health.NewChecker(),
+	}
+	if err = s.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Service) migrate() error {
+	var err error
+	s.Audit = audit.New(s.DB, s.Name)
+	if err = s.Audit.Migrate(); err != nil {
+		return fmt.Errorf("creating audit schema: %w", err)
+	}
+	s.Auth, err = auth.NewFromEnv(s.DB)
+	if err != nil {
+		return fmt.Errorf("setting up authentication: %w", err)
+	}
+	s.Auth.Keys.Audit = s.Audit
+	s.Auth.QueryToken = map[string]bool{"GET /api/events/stream": true}
+	s.History = eventstore.New(s.DB)
+	if err = s.History.Migrate(); err != nil {
+		return fmt.Errorf("creating event store schema: %w", err)
+	}
+	s.Broker = events.NewBroker(s.History)
+	s.Webhooks = webhook.NewDispatcher(s.DB)
+	s.Webhooks.Audit = s.Audit
+	s.Webhooks.Log = s.Logger
+	if err = s.Webhooks.Migrate(); err != nil {
+		return fmt.Errorf("creating webhook schema: %w", err)
+	}
+	s.Workers = append(s.Workers, s.Webhooks.Run)
+	return nil
+}
+
+// Ready records the service's schema version and registers the standard
+// health checks; readiness fails if the database reports an older version.
+func (s *Service) Ready(schemaVersion int) error {
+	if err := health.RecordSchemaVersion(s.DB, s.Name, schemaVersion); err != nil {
+		return fmt.Errorf("recording schema version: %w", err)
+	}
+	s.Logger.Info("Schema created successfully", "version", schemaVersion)
+	s.Checker.Add("database", true, health.Ping(s.DB))
+	s.Checker.Add("schema", true, health.SchemaVersion(s.DB, s.Name, schemaVersion))
+	s.Checker.Add("webhook-dispatcher", false, s.Webhooks.Check)
+	return nil
+}
+
+// Record appends ev to the event store and queues its webhook deliveries in
+// tx, the transaction making the change ev reports, so the change cannot be
+// committed without its event or its deliveries. The caller fails the change
+// if Record fails, and hands the returned event, whose ID is now its
+// sequence number, to Publish once tx has committed.
+func (s *Service) Record(tx *sql.Tx, ev events.Event) (events.Event, error) {
+	if ev.Time.IsZero() {
+		ev.Time = time.Now().UTC()
+	}
+	rec, err := eventstore.AppendTx(tx, ev)
+	if err != nil {
+		return ev, fmt.Errorf("recording %s event: %w", ev.Type, err)
+	}
+	ev.ID = uint64(rec.Seq)
+	if err = s.Webhooks.EnqueueTx(tx, ev); err != nil {
+		return ev, fmt.Errorf("queueing %s webhooks: %w", ev.Type, err)
+	}
+	return ev, nil
+}
+
+// Publish fans events recorded with Record out to live subscribers. Only
+// call it once their transaction has committed.
+func (s *Service) Publish(evs ...events.Event) {
+	for _, ev := range evs {
+		s.Broker.Publish(ev)
+	}
+}
+
+// Router returns a router with the middleware chain and the shared routes
+// already registered. policy is merged over CommonPolicy.
+func (s *Service) Router(policy auth.Policy, limits map[string]ratelimit.Rule) (*mux.Router, error) {
+	limiter, err := s.rateLimiter(limits)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := auth.Policy{}
+	for k, v := range CommonPolicy {
+		merged[k] = v
+	}
+	for k, v := range policy {
+		merged[k] = v
+	}
+
+	router := mux.NewRouter()
+	router.Use(logging.Middleware(s.Logger), metrics.Middleware(s.Name), limiter.Shed, limiter.PreAuth, s.Auth.Middleware(merged), limiter.Middleware)
+	router.HandleFunc("/api/events/stream", s.Broker.ServeSSE).Methods("GET")
+	s.Webhooks.RegisterRoutes(router)
+	s.History.RegisterRoutes(router)
+	router.HandleFunc("/api/admin/audit", s.Audit.ListHandler).Methods("GET")
+	s.Auth.Keys.RegisterRoutes(router)
+	router.Handle("/metrics", metrics.Handler()).Methods("GET")
+	router.HandleFunc("/healthz", s.Checker.Liveness).Methods("GET")
+	router.HandleFunc("/readyz", s.Checker.Readiness).Methods("GET")
+	return router, nil
+}
+
+// rateLimiter returns the limiter shared by the HTTP and gRPC servers, so
+// both count against one in-flight cap, with limits added to its rules.
+func (s *Service) rateLimiter(limits map[string]ratelimit.Rule) (*ratelimit.Limiter, error) {
+	if s.limiter == nil {
+		limiter, err := ratelimit.NewFromEnv(ratelimit.Rule{Rate: 20, Burst: 40}, nil, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate limit config: %w", err)
+		}
+		limiter.LongLived = map[string]bool{
+			"GET /api/events/stream":           true,
+			"GRPC /pdea.v1.Availability/Watch": true,
+		}
+		s.limiter = limiter
+	}
+	s.limiter.AddDefaults(limits)
+	return s.limiter, nil
+}
+
+// NewGRPC creates the service's gRPC server with the same interceptors as
+// Router's middleware: logging, metrics, load shedding, the per-address
+// limit, authentication with policy merged over CommonGRPCPolicy, and rate
+// limiting with limits, all keyed by full method name. It registers the availability stream; the
+// caller registers its own services on the result.
+func (s *Service) NewGRPC(addr string, policy auth.Policy, limits map[string]ratelimit.Rule) (*grpc.Server, error) {
+	limiter, err := s.rateLimiter(limits)
+	if err != nil {
+		return nil, err
+	}
+	merged := auth.Policy{}
+	for k, v := range CommonGRPCPolicy {
+		merged[k] = v
+	}
+	for k, v := range policy {
+		merged[k] = v
+	}
+	s.GRPCAddr = addr
+	s.GRPC = grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			logging.UnaryInterceptor(s.Logger),
+			metrics.UnaryInterceptor(s.Name),
+			limiter.UnaryShed(),
+			limiter.UnaryPreAuth(),
+			s.Auth.UnaryInterceptor(merged),
+			limiter.UnaryInterceptor(),
+		),
+		grpc.ChainStreamInterceptor(
+			logging.StreamInterceptor(s.Logger),
+			metrics.StreamInterceptor(s.Name),
+			limiter.StreamShed(),
+			limiter.StreamPreAuth(),
+			s.Auth.StreamInterceptor(merged),
+			limiter.StreamInterceptor(),
+		),
+	)
+	pdeapb.RegisterAvailabilityServer(s.GRPC, &grpcapi.AvailabilityServer{Broker: s.Broker})
+	return s.GRPC, nil
+}
+
+// serveGRPC runs the gRPC server until ctx is cancelled, then lets
+// in-flight calls finish for up to timeout. Set PDEA_GRPC=off to disable it.
+func (s *Service) serveGRPC(ctx context.Context, timeout time.Duration) (wait func(), err error) {
+	if s.GRPC == nil || os.Getenv("PDEA_GRPC") == "off" {
+		return func() {}, nil
+	}
+	ln, err := net.Listen("tcp", s.GRPCAddr)
+	if err != nil {
+		return nil, err
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Logger.Info("start listening", "addr", ln.Addr().String(), "protocol", "grpc")
+		if err := s.GRPC.Serve(ln); err != nil {
+			s.Logger.Error("grpc server failed", "error", err)
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		// open Watch streams only end once the broker is closed
+		s.Broker.Close()
+		stopped := make(chan struct{})
+		go func() {
+			s.GRPC.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-time.After(timeout):
+			s.GRPC.Stop()
+		}
+	}()
+	return func() { <-done }, nil
+}
+
+// Serve runs the HTTP and gRPC servers and workers until ctx is cancelled.
+// Shutdown drains in-flight requests first, then stops the workers, then closes the
+// database pool.
+func (s *Service) Serve(ctx context.Context) error {
+	cfg, err := server.ConfigFromEnv(s.Addr)
+	if err != nil {
+		return fmt.Errorf("invalid server config: %w", err)
+	}
+	grpcCtx, stopGRPC := context.WithCancel(ctx)
+	defer stopGRPC()
+	waitGRPC, err := s.serveGRPC(grpcCtx, cfg.ShutdownTimeout)
+	if err != nil {
+		return fmt.Errorf("grpc listen: %w", err)
+	}
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	for _, run := range s.Workers {
+		workers.Add(1)
+		go func(run func(context.Context)) {
+			defer workers.Done()
+			run(workerCtx)
+		}(run)
+	}
+
+	err = server.ListenAndServe(ctx, cfg, s.Handler, s.Logger, s.Broker.Close)
+	stopGRPC()
+	waitGRPC()
+	stopWorkers()
+	workers.Wait()
+	s.DB.Close()
+	s.Logger.Info("stopped")
+	return err
+}
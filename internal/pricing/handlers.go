@@ -0,0 +1,131 @@
+package pricing
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"PDEA/internal/logging"
+
+	"github.com/gorilla/mux"
+)
+
+func (e *Engine) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/pricing/rates", e.ListRatesHandler).Methods("GET")
+	router.HandleFunc("/api/pricing/rates/{spot_type}", e.SetRateHandler).Methods("PUT")
+	router.HandleFunc("/api/pricing/rates/{spot_type}", e.DeleteRateHandler).Methods("DELETE")
+	router.HandleFunc("/api/pricing/rules", e.ListRulesHandler).Methods("GET")
+	router.HandleFunc("/api/pricing/rules", e.ReplaceRulesHandler).Methods("PUT")
+	router.HandleFunc("/api/pricing/decisions", e.ListDecisionsHandler).Methods("GET")
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	resJson, _ := json.Marshal(v)
+	w.WriteHeader(status)
+	w.Write(resJson)
+}
+
+func serverError(w http.ResponseWriter, r *http.Request, msg string, err error) {
+	logging.FromContext(r.Context()).Error(msg, "error", err)
+	http.Error(w, "Server error", http.StatusInternalServerError)
+}
+
+func (e *Engine) ListRatesHandler(w http.ResponseWriter, r *http.Request) {
+	res, err := e.Rates()
+	if err != nil {
+		serverError(w, r, "list rates failed", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (e *Engine) SetRateHandler(w http.ResponseWriter, r *http.Request) {
+	var reqBody Rate
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	reqBody.SpotType = mux.Vars(r)["spot_type"]
+	before, err := e.SetRate(reqBody)
+	if errors.Is(err, ErrInvalid) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		serverError(w, r, "set rate failed", err)
+		return
+	}
+	e.Audit.Record(r, "set", "pricing_rate", reqBody.SpotType, before, reqBody)
+	writeJSON(w, http.StatusOK, reqBody)
+}
+
+func (e *Engine) DeleteRateHandler(w http.ResponseWriter, r *http.Request) {
+	spotType := mux.Vars(r)["spot_type"]
+	before, err := e.DeleteRate(spotType)
+	if err != nil {
+		serverError(w, r, "delete rate failed", err)
+		return
+	}
+	if before == nil {
+		http.Error(w, "Rate not found", http.StatusNotFound)
+		return
+	}
+	e.Audit.Record(r, "delete", "pricing_rate", spotType, before, nil)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (e *Engine) ListRulesHandler(w http.ResponseWriter, r *http.Request) {
+	res, err := e.Rules()
+	if err != nil {
+		serverError(w, r, "list pricing rules failed", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// ReplaceRulesHandler takes the complete rule set as a JSON array.
+func (e *Engine) ReplaceRulesHandler(w http.ResponseWriter, r *http.Request) {
+	var reqBody []Rule
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	before, err := e.Rules()
+	if err != nil {
+		serverError(w, r, "list pricing rules failed", err)
+		return
+	}
+	res, err := e.ReplaceRules(reqBody)
+	if errors.Is(err, ErrInvalid) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		serverError(w, r, "replace pricing rules failed", err)
+		return
+	}
+	e.Audit.Record(r, "replace", "pricing_rules", "", before, res)
+	writeJSON(w, http.StatusOK, res)
+}
+
+// ListDecisionsHandler supports the stay_id, spot_number and limit query
+// parameters.
+func (e *Engine) ListDecisionsHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	stayID, _ := strconv.Atoi(q.Get("stay_id"))
+	limit := 100
+	if v := q.Get("limit"); v != "" {
+		var err error
+		if limit, err = strconv.Atoi(v); err != nil || limit <= 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+	res, err := e.Decisions(stayID, q.Get("spot_number"), limit)
+	if err != nil {
+		serverError(w, r, "list pricing decisions failed", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
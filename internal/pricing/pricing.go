@@ -0,0 +1,298 @@
+// Package pricing sets the hourly rate of a stay when it starts: the spot
+// type's base rate, adjusted by the rule matching how full that type is at
+// that moment. The rate is stored with the stay, so later rule changes do
+// not alter what an exit costs, and every decision is kept in
+// pricing_decisions.
+package pricing
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"PDEA/internal/audit"
+	"PDEA/internal/parking"
+)
+
+var ErrInvalid = errors.New("invalid pricing")
+
+type Rate struct {
+	SpotType    string `json:"spot_type"`
+	HourlyCents int    `json:"hourly_cents"`
+}
+
+// Rule adjusts the base rate by AdjustPct percent once the spot type is at
+// least MinOccupancyPct full. An empty SpotType applies to every type.
+type Rule struct {
+	ID              int    `json:"id"`
+	SpotType        string `json:"spot_type"`
+	MinOccupancyPct int    `json:"min_occupancy_pct"`
+	AdjustPct       int    `json:"adjust_pct"`
+}
+
+// Decision is the rate given to one stay and how it was arrived at.
+type Decision struct {
+	ID           int       `json:"id"`
+	StayID       int       `json:"stay_id"`
+	SpotNumber   string    `json:"spot_number"`
+	SpotType     string    `json:"spot_type"`
+	Free         int       `json:"free"`
+	Total        int       `json:"total"`
+	OccupancyPct float64   `json:"occupancy_pct"`
+	BaseCents    int       `json:"base_cents"`
+	RuleID       int       `json:"rule_id,omitempty"`
+	AdjustPct    int       `json:"adjust_pct"`
+	HourlyCents  int       `json:"hourly_cents"`
+	DecidedAt    time.Time `json:"decided_at"`
+}
+
+type Engine struct {
+	DB    *sql.DB
+	Audit *audit.Logger
+}
+
+func New(db *sql.DB) *Engine {
+	return &Engine{DB: db}
+}
+
+func (e *Engine) Migrate() error {
+	schemaSQL := `
+CREATE TABLE IF NOT EXISTS pricing_rates (
+spot_type TEXT PRIMARY KEY,
+hourly_cents INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS pricing_rules (
+id SERIAL PRIMARY KEY,
+spot_type TEXT NOT NULL DEFAULT '',
+min_occupancy_pct INTEGER NOT NULL,
+adjust_pct INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS pricing_decisions (
+id SERIAL PRIMARY KEY,
+stay_id INTEGER NOT NULL,
+spot_number TEXT NOT NULL,
+spot_type TEXT NOT NULL,
+free INTEGER NOT NULL,
+total INTEGER NOT NULL,
+occupancy_pct DOUBLE PRECISION NOT NULL,
+base_cents INTEGER NOT NULL,
+rule_id INTEGER,
+adjust_pct INTEGER NOT NULL,
+hourly_cents INTEGER NOT NULL,
+decided_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS pricing_decisions_stay ON pricing_decisions (stay_id);
+`
+	_, err := e.DB.Exec(schemaSQL)
+	return err
+}
+
+func (e *Engine) Rates() ([]Rate, error) {
+	rows, err := e.DB.Query(`select spot_type, hourly_cents from pricing_rates order by spot_type;`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := []Rate{}
+	for rows.Next() {
+		var r Rate
+		if err := rows.Scan(&r.SpotType, &r.HourlyCents); err != nil {
+			return nil, err
+		}
+		res = append(res, r)
+	}
+	return res, rows.Err()
+}
+
+func (e *Engine) rate(spotType string) (int, bool, error) {
+	var cents int
+	err := e.DB.QueryRow(`select hourly_cents from pricing_rates where spot_type = $1;`, spotType).Scan(&cents)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	return cents, err == nil, err
+}
+
+// SetRate creates or replaces the base rate for r.SpotType and returns the
+// previous one, if any.
+func (e *Engine) SetRate(r Rate) (*Rate, error) {
+	if r.SpotType == "" || r.HourlyCents < 0 {
+		return nil, fmt.Errorf("%w: spot_type is required and hourly_cents must not be negative", ErrInvalid)
+	}
+	old, found, err := e.rate(r.SpotType)
+	if err != nil {
+		return nil, err
+	}
+	qr := `INSERT INTO pricing_rates(spot_type, hourly_cents) VALUES ($1, $2)
+		ON CONFLICT (spot_type) DO UPDATE SET hourly_cents = excluded.hourly_cents;`
+	if _, err = e.DB.Exec(qr, r.SpotType, r.HourlyCents); err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, nil
+	}
+	return &Rate{SpotType: r.SpotType, HourlyCents: old}, nil
+}
+
+// DeleteRate removes the base rate; stays of that type are then unpriced.
+func (e *Engine) DeleteRate(spotType string) (*Rate, error) {
+	var cents int
+	err := e.DB.QueryRow(`DELETE FROM pricing_rates where spot_type = $1 returning hourly_cents;`, spotType).Scan(&cents)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Rate{SpotType: spotType, HourlyCents: cents}, nil
+}
+
+func (e *Engine) Rules() ([]Rule, error) {
+	rows, err := e.DB.Query(`select id, spot_type, min_occupancy_pct, adjust_pct from pricing_rules order by spot_type, min_occupancy_pct;`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := []Rule{}
+	for rows.Next() {
+		var r Rule
+		if err := rows.Scan(&r.ID, &r.SpotType, &r.MinOccupancyPct, &r.AdjustPct); err != nil {
+			return nil, err
+		}
+		res = append(res, r)
+	}
+	return res, rows.Err()
+}
+
+// ReplaceRules swaps the whole rule set in one transaction, so entries never
+// see half of an edit.
+func (e *Engine) ReplaceRules(rules []Rule) ([]Rule, error) {
+	seen := make(map[Rule]bool)
+	for _, r := range rules {
+		if r.MinOccupancyPct < 0 || r.MinOccupancyPct > 100 || r.AdjustPct < -100 {
+			return nil, fmt.Errorf("%w: min_occupancy_pct must be 0-100 and adjust_pct at least -100", ErrInvalid)
+		}
+		k := Rule{SpotType: r.SpotType, MinOccupancyPct: r.MinOccupancyPct}
+		if seen[k] {
+			return nil, fmt.Errorf("%w: more than one rule for %q at %d%%", ErrInvalid, r.SpotType, r.MinOccupancyPct)
+		}
+		seen[k] = true
+	}
+	tx, err := e.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	if _, err = tx.Exec(`DELETE FROM pricing_rules;`); err != nil {
+		return nil, err
+	}
+	for _, r := range rules {
+		qr := `INSERT INTO pricing_rules(spot_type, min_occupancy_pct, adjust_pct) VALUES ($1, $2, $3);`
+		if _, err = tx.Exec(qr, r.SpotType, r.MinOccupancyPct, r.AdjustPct); err != nil {
+			return nil, err
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return e.Rules()
+}
+
+// match picks the rule with the highest threshold the occupancy has reached,
+// preferring a rule for spotType over a catch-all one at the same threshold.
+func match(rules []Rule, spotType string, pct float64) (Rule, bool) {
+	var best Rule
+	found := false
+	for _, r := range rules {
+		if (r.SpotType != "" && r.SpotType != spotType) || float64(r.MinOccupancyPct) > pct {
+			continue
+		}
+		if !found || r.MinOccupancyPct > best.MinOccupancyPct ||
+			(r.MinOccupancyPct == best.MinOccupancyPct && best.SpotType == "") {
+			best, found = r, true
+		}
+	}
+	return best, found
+}
+
+// Quote prices a stay starting now at a spot with the given occupancy. It
+// reports false when the spot type has no base rate.
+func (e *Engine) Quote(spotNumber string, occ parking.Occupancy) (Decision, bool, error) {
+	d := Decision{
+		SpotNumber:   spotNumber,
+		SpotType:     occ.Type,
+		Free:         occ.Free,
+		Total:        occ.Total,
+		OccupancyPct: occ.OccupiedPct(),
+		DecidedAt:    time.Now().UTC(),
+	}
+	base, found, err := e.rate(occ.Type)
+	if err != nil || !found {
+		return d, false, err
+	}
+	rules, err := e.Rules()
+	if err != nil {
+		return d, false, err
+	}
+	d.BaseCents = base
+	d.HourlyCents = base
+	if r, ok := match(rules, occ.Type, d.OccupancyPct); ok {
+		d.RuleID = r.ID
+		d.AdjustPct = r.AdjustPct
+		// round half up to whole cents
+		d.HourlyCents = (base*(100+r.AdjustPct) + 50) / 100
+	}
+	return d, true, nil
+}
+
+// Execer is satisfied by *sql.DB and *sql.Tx.
+type Execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// Record stores a decision, normally in the transaction that creates the
+// stay it priced.
+func Record(q Execer, d Decision) error {
+	var ruleID sql.NullInt64
+	if d.RuleID != 0 {
+		ruleID = sql.NullInt64{Int64: int64(d.RuleID), Valid: true}
+	}
+	qr := `INSERT INTO pricing_decisions(stay_id, spot_number, spot_type, free, total, occupancy_pct, base_cents, rule_id, adjust_pct, hourly_cents, decided_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11);`
+	_, err := q.Exec(qr, d.StayID, d.SpotNumber, d.SpotType, d.Free, d.Total, d.OccupancyPct, d.BaseCents, ruleID, d.AdjustPct, d.HourlyCents, d.DecidedAt)
+	return err
+}
+
+// Decisions lists recorded decisions, newest first, optionally for one stay
+// or one spot.
+func (e *Engine) Decisions(stayID int, spotNumber string, limit int) ([]Decision, error) {
+	qr := `select id, stay_id, spot_number, spot_type, free, total, occupancy_pct, base_cents, coalesce(rule_id, 0), adjust_pct, hourly_cents, decided_at
+		from pricing_decisions where ($1 = 0 or stay_id = $1) and ($2 = '' or spot_number = $2) order by id desc limit $3;`
+	rows, err := e.DB.Query(qr, stayID, spotNumber, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := []Decision{}
+	for rows.Next() {
+		var d Decision
+		if err := rows.Scan(&d.ID, &d.StayID, &d.SpotNumber, &d.SpotType, &d.Free, &d.Total, &d.OccupancyPct, &d.BaseCents, &d.RuleID, &d.AdjustPct, &d.HourlyCents, &d.DecidedAt); err != nil {
+			return nil, err
+		}
+		res = append(res, d)
+	}
+	return res, rows.Err()
+}
+
+// Fee charges hourlyCents for every started hour, and at least one hour.
+func Fee(hourlyCents int, entry, exit time.Time) int {
+	hours := int(exit.Sub(entry) / time.Hour)
+	if exit.Sub(entry)%time.Hour > 0 || hours == 0 {
+		hours++
+	}
+	return hours * hourlyCents
+}
@@ -0,0 +1,68 @@
+package pricing
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFee(t *testing.T) {
+	entry := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		stay   time.Duration
+		hourly int
+		want   int
+	}{
+		{"no time", 0, 100, 100},
+		{"one minute", time.Minute, 100, 100},
+		{"exactly an hour", time.Hour, 100, 100},
+		{"an hour and a second", time.Hour + time.Second, 100, 200},
+		{"59 minutes", 59 * time.Minute, 250, 250},
+		{"61 minutes", 61 * time.Minute, 250, 500},
+		{"exactly three hours", 3 * time.Hour, 250, 750},
+		{"a day", 24 * time.Hour, 100, 2400},
+		{"free spot type", 5 * time.Hour, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Fee(tt.hourly, entry, entry.Add(tt.stay)); got != tt.want {
+				t.Errorf("Fee(%d, %v stay) = %d, want %d", tt.hourly, tt.stay, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	rules := []Rule{
+		{ID: 1, MinOccupancyPct: 0, AdjustPct: -10},
+		{ID: 2, MinOccupancyPct: 80, AdjustPct: 20},
+		{ID: 3, SpotType: "compact", MinOccupancyPct: 80, AdjustPct: 30},
+		{ID: 4, SpotType: "compact", MinOccupancyPct: 95, AdjustPct: 50},
+		{ID: 5, SpotType: "large", MinOccupancyPct: 50, AdjustPct: 10},
+	}
+	tests := []struct {
+		name     string
+		rules    []Rule
+		spotType string
+		pct      float64
+		want     int
+	}{
+		{"catch-all below thresholds", rules, "compact", 10, 1},
+		{"threshold reached exactly", rules, "large", 50, 5},
+		{"just below threshold", rules, "large", 49.9, 1},
+		{"highest threshold reached wins", rules, "large", 85, 2},
+		{"type rule beats catch-all at same threshold", rules, "compact", 80, 3},
+		{"higher type threshold", rules, "compact", 100, 4},
+		{"other type's rules ignored", rules, "motorcycle", 97, 2},
+		{"no rules", nil, "compact", 100, 0},
+		{"nothing reached", []Rule{{ID: 7, MinOccupancyPct: 60}}, "compact", 30, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, ok := match(tt.rules, tt.spotType, tt.pct)
+			if ok != (tt.want != 0) || r.ID != tt.want {
+				t.Errorf("match(%q, %v) = rule %d, %v, want rule %d", tt.spotType, tt.pct, r.ID, ok, tt.want)
+			}
+		})
+	}
+}
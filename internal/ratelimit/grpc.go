@@ -0,0 +1,157 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"PDEA/internal/auth"
+	"PDEA/internal/metrics"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	grpcRejected = metrics.NewCounterVec("pdea_grpc_requests_rejected_total", "gRPC calls rejected by the rate limiter (ResourceExhausted) or load shedding (Unavailable).", "code", "method")
+	grpcInFlight = metrics.NewGaugeVec("pdea_grpc_requests_in_flight", "gRPC calls currently being served.")
+)
+
+// grpcRoute keys gRPC calls like auth.Policy does, e.g.
+// "GRPC /pdea.v1.Vehicles/RegisterEntry", so Rules, LongLived and
+// PDEA_RATE_LIMITS name them the same way.
+func grpcRoute(fullMethod string) string {
+	return auth.GRPCMethod + " " + fullMethod
+}
+
+// grpcClientKey is clientKey for gRPC calls, falling back to the peer
+// address for anonymous callers.
+func grpcClientKey(ctx context.Context) string {
+	if p, ok := auth.FromContext(ctx); ok {
+		return "principal:" + p.String()
+	}
+	return grpcIPKey(ctx)
+}
+
+func grpcIPKey(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok {
+		return ipKey(p.Addr.String())
+	}
+	return "ip:unknown"
+}
+
+// limit is Middleware for a gRPC call.
+func (l *Limiter) limit(ctx context.Context, fullMethod string) error {
+	route := grpcRoute(fullMethod)
+	if ok, wait := l.allow(route, grpcClientKey(ctx), time.Now()); !ok {
+		grpcRejected.Inc(codes.ResourceExhausted.String(), fullMethod)
+		grpc.SetHeader(ctx, metadata.Pairs("retry-after", retryAfter(wait)))
+		return status.Error(codes.ResourceExhausted, "Too many requests")
+	}
+	return nil
+}
+
+// preAuth is PreAuth for a gRPC call.
+func (l *Limiter) preAuth(ctx context.Context) error {
+	if ok, wait := l.allowIP(grpcIPKey(ctx), time.Now()); !ok {
+		grpcRejected.Inc(codes.ResourceExhausted.String(), "ip")
+		grpc.SetHeader(ctx, metadata.Pairs("retry-after", retryAfter(wait)))
+		return status.Error(codes.ResourceExhausted, "Too many requests")
+	}
+	return nil
+}
+
+// shed is Shed for a gRPC call; release must be called once the call is
+// done when err is nil.
+func (l *Limiter) shed(fullMethod string) (release func(), err error) {
+	held := false
+	if l.sem != nil && !l.LongLived[grpcRoute(fullMethod)] {
+		timer := time.NewTimer(l.QueueWait)
+		select {
+		case l.sem <- struct{}{}:
+			timer.Stop()
+			held = true
+		case <-timer.C:
+			grpcRejected.Inc(codes.Unavailable.String(), fullMethod)
+			return nil, status.Error(codes.Unavailable, "Server busy")
+		}
+	}
+	grpcInFlight.Add(1)
+	return func() {
+		grpcInFlight.Add(-1)
+		if held {
+			<-l.sem
+		}
+	}, nil
+}
+
+// UnaryInterceptor rejects with ResourceExhausted the calls over their
+// client's rate. It must be chained after authentication.
+func (l *Limiter) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := l.limit(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamInterceptor is UnaryInterceptor for streams; opening a stream
+// takes one token.
+func (l *Limiter) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := l.limit(ss.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// UnaryPreAuth rejects with ResourceExhausted the calls over their peer
+// address's PerIP rate. It must be chained before authentication.
+func (l *Limiter) UnaryPreAuth() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := l.preAuth(ctx); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamPreAuth is UnaryPreAuth for streams.
+func (l *Limiter) StreamPreAuth() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := l.preAuth(ss.Context()); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// UnaryShed rejects with Unavailable the calls that find the in-flight cap
+// reached for longer than QueueWait. HTTP requests and gRPC calls share the
+// cap.
+func (l *Limiter) UnaryShed() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		release, err := l.shed(info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+		return handler(ctx, req)
+	}
+}
+
+// StreamShed is UnaryShed for streams; list long-lived ones in LongLived.
+func (l *Limiter) StreamShed() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		release, err := l.shed(info.FullMethod)
+		if err != nil {
+			return err
+		}
+		defer release()
+		return handler(srv, ss)
+	}
+}
@@ -0,0 +1,90 @@
+package ratelimit
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const checkMethod = "GRPC /grpc.health.v1.Health/Check"
+
+// healthClient serves the gRPC health service behind l's interceptors over
+// an in-memory connection.
+func healthClient(t *testing.T, l *Limiter, extra ...grpc.UnaryServerInterceptor) healthpb.HealthClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(append([]grpc.UnaryServerInterceptor{l.UnaryShed(), l.UnaryInterceptor()}, extra...)...))
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return healthpb.NewHealthClient(conn)
+}
+
+func TestUnaryInterceptorLimitsByMethod(t *testing.T) {
+	l := New(Rule{}, map[string]Rule{checkMethod: {Rate: 0.001, Burst: 2}}, 0)
+	client := healthClient(t, l)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+			t.Fatalf("call %d within the burst: %v", i+1, err)
+		}
+	}
+	var header metadata.MD
+	_, err := client.Check(ctx, &healthpb.HealthCheckRequest{}, grpc.Header(&header))
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("call over the burst = %v, want ResourceExhausted", err)
+	}
+	if v := header.Get("retry-after"); len(v) != 1 || v[0] == "0" {
+		t.Errorf("retry-after = %v, want a positive number of seconds", v)
+	}
+}
+
+func TestUnaryShed(t *testing.T) {
+	l := New(Rule{}, nil, 1)
+	l.QueueWait = 10 * time.Millisecond
+	entered, release := make(chan struct{}), make(chan struct{})
+	block := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if md, _ := metadata.FromIncomingContext(ctx); len(md.Get("block")) > 0 {
+			close(entered)
+			<-release
+		}
+		return handler(ctx, req)
+	}
+	client := healthClient(t, l, block)
+	ctx := context.Background()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.Check(metadata.AppendToOutgoingContext(ctx, "block", "1"), &healthpb.HealthCheckRequest{})
+		done <- err
+	}()
+	<-entered
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}); status.Code(err) != codes.Unavailable {
+		t.Errorf("call with the cap reached = %v, want Unavailable", err)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("blocked call: %v", err)
+	}
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Errorf("call after the slot was freed: %v", err)
+	}
+}
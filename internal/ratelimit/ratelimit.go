@@ -0,0 +1,273 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"PDEA/internal/auth"
+	"PDEA/internal/metrics"
+
+	"github.com/gorilla/mux"
+)
+
+// Rule is a token bucket: Rate tokens per second refilled up to Burst.
+type Rule struct {
+	Rate  float64
+	Burst int
+}
+
+var (
+	rejected = metrics.NewCounterVec("pdea_http_requests_rejected_total", "Requests rejected by the rate limiter (429) or load shedding (503).", "code", "route")
+	inFlight = metrics.NewGaugeVec("pdea_http_requests_in_flight", "Requests currently being served.")
+)
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter applies a per-client token bucket for every route and a global cap
+// on in-flight requests. The cap should stay below the database pool size so
+// excess load is shed with 503 instead of queueing on connections. Shed
+// applies the cap and PreAuth a bucket per address over all routes, both
+// before authentication, so failed credential checks are throttled too;
+// Middleware applies the route buckets and goes after it, so clients are
+// told apart by who they proved to be.
+type Limiter struct {
+	Default Rule
+	// PerIP is the bucket PreAuth applies to each remote address.
+	PerIP     Rule
+	Rules     map[string]Rule
+	QueueWait time.Duration
+	// LongLived routes such as event streams skip the in-flight cap,
+	// otherwise a few idle subscribers would hold every slot.
+	LongLived map[string]bool
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	sem       chan struct{}
+}
+
+func New(def Rule, rules map[string]Rule, maxInFlight int) *Limiter {
+	l := &Limiter{Default: def, PerIP: Rule{Rate: 50, Burst: 100}, Rules: rules, QueueWait: 100 * time.Millisecond, buckets: make(map[string]*bucket)}
+	if maxInFlight > 0 {
+		l.sem = make(chan struct{}, maxInFlight)
+	}
+	return l
+}
+
+// NewFromEnv starts from the service defaults and applies PDEA_RATE_LIMITS
+// and PDEA_MAX_INFLIGHT. PDEA_RATE_LIMITS is a ';' separated list of
+// "<route>=<rate>:<burst>" where route is "default", "ip" (the PerIP bucket)
+// or "METHOD /template", e.g. "POST /api/vehicle-entries=1:5;default=50:100".
+func NewFromEnv(def Rule, rules map[string]Rule, maxInFlight int) (*Limiter, error) {
+	merged := make(map[string]Rule)
+	perIP, setIP := Rule{}, false
+	for k, v := range rules {
+		merged[k] = v
+	}
+	if spec := os.Getenv("PDEA_RATE_LIMITS"); spec != "" {
+		parsed, err := ParseRules(spec)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range parsed {
+			switch k {
+			case "default":
+				def = v
+			case "ip":
+				perIP, setIP = v, true
+			default:
+				merged[k] = v
+			}
+		}
+	}
+	if v := os.Getenv("PDEA_MAX_INFLIGHT"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PDEA_MAX_INFLIGHT %q", v)
+		}
+		maxInFlight = n
+	}
+	l := New(def, merged, maxInFlight)
+	if setIP {
+		l.PerIP = perIP
+	}
+	return l, nil
+}
+
+// AddDefaults adds rules for the routes that have none yet, so limits set in
+// PDEA_RATE_LIMITS win over ones registered later. Call it before serving.
+func (l *Limiter) AddDefaults(rules map[string]Rule) {
+	if l.Rules == nil {
+		l.Rules = make(map[string]Rule)
+	}
+	for k, v := range rules {
+		if _, ok := l.Rules[k]; !ok {
+			l.Rules[k] = v
+		}
+	}
+}
+
+func ParseRules(spec string) (map[string]Rule, error) {
+	res := make(map[string]Rule)
+	for _, item := range strings.Split(spec, ";") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		route, limit, ok := strings.Cut(item, "=")
+		rate, burst, ok2 := strings.Cut(limit, ":")
+		if !ok || !ok2 {
+			return nil, fmt.Errorf("invalid rate limit %q", item)
+		}
+		var r Rule
+		var err error
+		if r.Rate, err = strconv.ParseFloat(rate, 64); err != nil || r.Rate <= 0 {
+			return nil, fmt.Errorf("invalid rate in %q", item)
+		}
+		if r.Burst, err = strconv.Atoi(burst); err != nil || r.Burst < 1 {
+			return nil, fmt.Errorf("invalid burst in %q", item)
+		}
+		res[strings.TrimSpace(route)] = r
+	}
+	return res, nil
+}
+
+// clientKey identifies the caller by its authenticated principal, so
+// clients behind the same NAT with different keys get separate buckets.
+// Anonymous callers are identified by their address; an unverified
+// credential is never used, or random keys would each get a fresh bucket.
+func clientKey(r *http.Request) string {
+	if p, ok := auth.FromContext(r.Context()); ok {
+		return "principal:" + p.String()
+	}
+	return ipKey(r.RemoteAddr)
+}
+
+func ipKey(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	return "ip:" + host
+}
+
+func routeKey(r *http.Request) string {
+	template := r.URL.Path
+	if route := mux.CurrentRoute(r); route != nil {
+		if t, err := route.GetPathTemplate(); err == nil {
+			template = t
+		}
+	}
+	return r.Method + " " + template
+}
+
+// allow takes a token from client's bucket for route and, when none is
+// left, reports how long until one is.
+func (l *Limiter) allow(route, client string, now time.Time) (bool, time.Duration) {
+	rule, ok := l.Rules[route]
+	if !ok {
+		rule = l.Default
+	}
+	return l.take(route+"|"+client, rule, now)
+}
+
+// allowIP takes a token from the PerIP bucket of the address key ip.
+func (l *Limiter) allowIP(ip string, now time.Time) (bool, time.Duration) {
+	return l.take("*|"+ip, l.PerIP, now)
+}
+
+func (l *Limiter) take(key string, rule Rule, now time.Time) (bool, time.Duration) {
+	if rule.Rate <= 0 {
+		return true, 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Sub(l.lastSweep) > time.Minute {
+		for k, b := range l.buckets {
+			if now.Sub(b.last) > 10*time.Minute {
+				delete(l.buckets, k)
+			}
+		}
+		l.lastSweep = now
+	}
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(rule.Burst), last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(rule.Burst), b.tokens+now.Sub(b.last).Seconds()*rule.Rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / rule.Rate * float64(time.Second))
+}
+
+func retryAfter(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+// Middleware rejects with 429 the requests over their client's rate. It
+// must be installed after authentication.
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := routeKey(r)
+		if ok, wait := l.allow(route, clientKey(r), time.Now()); !ok {
+			rejected.Inc("429", route)
+			w.Header().Set("Retry-After", retryAfter(wait))
+			http.Error(w, "Too many requests", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// PreAuth rejects with 429 the requests over their address's PerIP rate,
+// whatever the route. It must be installed before authentication, so
+// guessed credentials are limited like any other request.
+func (l *Limiter) PreAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ok, wait := l.allowIP(ipKey(r.RemoteAddr), time.Now()); !ok {
+			rejected.Inc("429", "ip")
+			w.Header().Set("Retry-After", retryAfter(wait))
+			http.Error(w, "Too many requests", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Shed rejects with 503 the requests that find the in-flight cap reached
+// for longer than QueueWait.
+func (l *Limiter) Shed(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := routeKey(r)
+		if l.sem != nil && !l.LongLived[route] {
+			timer := time.NewTimer(l.QueueWait)
+			select {
+			case l.sem <- struct{}{}:
+				timer.Stop()
+			case <-timer.C:
+				rejected.Inc("503", route)
+				w.Header().Set("Retry-After", "1")
+				http.Error(w, "Server busy", http.StatusServiceUnavailable)
+				return
+			}
+			defer func() { <-l.sem }()
+		}
+		inFlight.Add(1)
+		defer inFlight.Add(-1)
+		next.ServeHTTP(w, r)
+	})
+}
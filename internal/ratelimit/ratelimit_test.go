@@ -0,0 +1,60 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPreAuthLimitsFailedAuthentication(t *testing.T) {
+	l := New(Rule{}, nil, 0)
+	l.PerIP = Rule{Rate: 0.001, Burst: 3}
+	// every request fails authentication, as a credential guesser's would
+	denied := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	})
+	h := l.PreAuth(denied)
+
+	tests := []struct {
+		remote string
+		path   string
+		want   int
+	}{
+		{"10.0.0.1:1000", "/api/a", http.StatusUnauthorized},
+		{"10.0.0.1:1001", "/api/b", http.StatusUnauthorized},
+		{"10.0.0.1:1002", "/api/c", http.StatusUnauthorized},
+		{"10.0.0.1:1003", "/api/a", http.StatusTooManyRequests},
+		{"10.0.0.1:1004", "/api/d", http.StatusTooManyRequests},
+		{"10.0.0.2:1000", "/api/a", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		r.RemoteAddr = tt.remote
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != tt.want {
+			t.Errorf("%s from %s = %d, want %d", tt.path, tt.remote, w.Code, tt.want)
+		}
+		if w.Code == http.StatusTooManyRequests && w.Header().Get("Retry-After") == "" {
+			t.Errorf("%s from %s: 429 without Retry-After", tt.path, tt.remote)
+		}
+	}
+}
+
+func TestNewFromEnvPerIP(t *testing.T) {
+	t.Setenv("PDEA_RATE_LIMITS", "ip=5:10;default=1:2;GET /x=3:4")
+	t.Setenv("PDEA_MAX_INFLIGHT", "")
+	l, err := NewFromEnv(Rule{Rate: 20, Burst: 40}, map[string]Rule{"GET /x": {Rate: 9, Burst: 9}, "GET /y": {Rate: 7, Burst: 7}}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.PerIP != (Rule{Rate: 5, Burst: 10}) || l.Default != (Rule{Rate: 1, Burst: 2}) {
+		t.Errorf("PerIP, Default = %+v, %+v, want {5 10}, {1 2}", l.PerIP, l.Default)
+	}
+	if l.Rules["GET /x"] != (Rule{Rate: 3, Burst: 4}) || l.Rules["GET /y"] != (Rule{Rate: 7, Burst: 7}) {
+		t.Errorf("Rules = %+v, want the environment over the service's", l.Rules)
+	}
+	if _, ok := l.Rules["ip"]; ok {
+		t.Errorf("Rules = %+v, want ip kept out of the route rules", l.Rules)
+	}
+}
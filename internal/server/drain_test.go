@@ -0,0 +1,141 @@
+package server_test
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"PDEA/internal/platform"
+	"PDEA/internal/server"
+	"PDEA/internal/vehicle"
+)
+
+var quiet = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// newVehicleService starts the vehicle service against
+// PDEA_TEST_DATABASE_URL with auth off and a free spot in parking_rec, and
+// returns the service and the spot's number.
+func newVehicleService(t *testing.T) (*platform.Service, string) {
+	t.Helper()
+	dsn := os.Getenv("PDEA_TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("PDEA_TEST_DATABASE_URL not set")
+	}
+	t.Setenv("PDEA_DATABASE_URL", dsn)
+	t.Setenv("PDEA_AUTH", "off")
+	t.Setenv("PDEA_SPOT_MODE", "sql")
+	t.Setenv("PDEA_RATE_LIMITS", "default=1000:1000")
+
+	// the first start creates the schema the spot goes into; the second
+	// loads the spot into its cache
+	s, err := vehicle.New(quiet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 4)
+	rand.Read(buf)
+	spot := "D" + strings.ToUpper(hex.EncodeToString(buf))
+	_, err = s.DB.Exec(`INSERT INTO parking_rec (spot_number, type, is_available, zone) VALUES ($1, 'compact', true, 'drain');`, spot)
+	s.DB.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, err = vehicle.New(quiet); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		s.DB.Exec(`DELETE FROM vehicle_records WHERE spot_number = $1;`, spot)
+		s.DB.Exec(`DELETE FROM parking_rec WHERE spot_number = $1;`, spot)
+		s.DB.Close()
+	})
+	return s, spot
+}
+
+func TestServeDrainsVehicleEntry(t *testing.T) {
+	svc, spot := newVehicleService(t)
+	plate := "DR-" + spot
+
+	// the entry is held until shutdown has started, so it is written to the
+	// database while the server drains
+	entered, release := make(chan struct{}), make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-release
+		svc.Handler.ServeHTTP(w, r)
+	})
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- server.Serve(ctx, server.New(server.Config{}, handler), ln, 10*time.Second, quiet, svc.Broker.Close)
+	}()
+
+	type result struct {
+		status int
+		body   string
+		err    error
+	}
+	got := make(chan result, 1)
+	go func() {
+		body := `{"spot_number":"` + spot + `","license_plate":"` + plate + `"}`
+		resp, err := http.Post("http://"+ln.Addr().String()+"/api/vehicle-entries", "application/json", strings.NewReader(body))
+		if err != nil {
+			got <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		got <- result{resp.StatusCode, string(b), err}
+	}()
+
+	<-entered
+	cancel()
+	// wait until the listener is closed, so the entry really runs during
+	// the drain
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		c, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			break
+		}
+		c.Close()
+		if time.Now().After(deadline) {
+			t.Fatal("server still accepting connections after shutdown started")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	close(release)
+
+	res := <-got
+	if res.err != nil || res.status != http.StatusCreated {
+		t.Fatalf("entry during shutdown = %d %q, %v, want 201", res.status, res.body, res.err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Serve = %v, want nil", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Serve did not return after the entry finished")
+	}
+
+	var n int
+	if err := svc.DB.QueryRow(`SELECT count(*) FROM vehicle_records WHERE spot_number = $1 AND license_plate = $2 AND exit_time IS NULL;`, spot, plate).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("%d open stays for %s after the drain, want 1", n, plate)
+	}
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+type Config struct {
+	Addr              string
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	ShutdownTimeout   time.Duration
+}
+
+func envDuration(name string, def time.Duration) (time.Duration, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", name, v, err)
+	}
+	return d, nil
+}
+
+// ConfigFromEnv reads PDEA_HTTP_READ_HEADER_TIMEOUT, PDEA_HTTP_READ_TIMEOUT,
+// PDEA_HTTP_WRITE_TIMEOUT, PDEA_HTTP_IDLE_TIMEOUT and PDEA_SHUTDOWN_TIMEOUT
+// as Go durations.
+func ConfigFromEnv(addr string) (Config, error) {
+	cfg := Config{Addr: addr}
+	var err error
+	if cfg.ReadHeaderTimeout, err = envDuration("PDEA_HTTP_READ_HEADER_TIMEOUT", 5*time.Second); err != nil {
+		return cfg, err
+	}
+	if cfg.ReadTimeout, err = envDuration("PDEA_HTTP_READ_TIMEOUT", 15*time.Second); err != nil {
+		return cfg, err
+	}
+	if cfg.WriteTimeout, err = envDuration("PDEA_HTTP_WRITE_TIMEOUT", 30*time.Second); err != nil {
+		return cfg, err
+	}
+	if cfg.IdleTimeout, err = envDuration("PDEA_HTTP_IDLE_TIMEOUT", 2*time.Minute); err != nil {
+		return cfg, err
+	}
+	if cfg.ShutdownTimeout, err = envDuration("PDEA_SHUTDOWN_TIMEOUT", 30*time.Second); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+func New(cfg Config, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+}
+
+// Serve runs srv on ln until ctx is cancelled, then stops accepting new
+// connections and waits up to shutdownTimeout for in-flight requests.
+// onShutdown hooks run as soon as shutdown starts, which is where long-lived
+// streams are told to finish; Serve waits for them within the same timeout.
+func Serve(ctx context.Context, srv *http.Server, ln net.Listener, shutdownTimeout time.Duration, logger *slog.Logger, onShutdown ...func()) error {
+	// Shutdown starts the hooks in their own goroutines and does not wait
+	// for them
+	var hooks sync.WaitGroup
+	for _, f := range onShutdown {
+		f := f
+		hooks.Add(1)
+		srv.RegisterOnShutdown(func() {
+			defer hooks.Done()
+			f()
+		})
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		logger.Info("start listening", "addr", ln.Addr().String())
+		errCh <- srv.Serve(ln)
+	}()
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+	logger.Info("shutting down, draining in-flight requests", "timeout", shutdownTimeout.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	err := srv.Shutdown(shutdownCtx)
+	hooksDone := make(chan struct{})
+	go func() {
+		hooks.Wait()
+		close(hooksDone)
+	}()
+	select {
+	case <-hooksDone:
+	case <-shutdownCtx.Done():
+		if err == nil {
+			err = shutdownCtx.Err()
+		}
+	}
+	if err != nil {
+		srv.Close()
+		return fmt.Errorf("shutdown: %w", err)
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	logger.Info("http server stopped")
+	return nil
+}
+
+func ListenAndServe(ctx context.Context, cfg Config, handler http.Handler, logger *slog.Logger, onShutdown ...func()) error {
+	ln, err := net.Listen("tcp", cfg.Addr)
+	if err != nil {
+		return err
+	}
+	return Serve(ctx, New(cfg, handler), ln, cfg.ShutdownTimeout, logger, onShutdown...)
+}
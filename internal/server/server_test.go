@@ -0,0 +1,189 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"PDEA/internal/events"
+)
+
+var quiet = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// serve starts Serve on a loopback listener and returns its URL and the
+// channel Serve's result arrives on.
+func serve(t *testing.T, ctx context.Context, handler http.Handler, shutdownTimeout time.Duration, onShutdown ...func()) (string, <-chan error) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- Serve(ctx, New(Config{}, handler), ln, shutdownTimeout, quiet, onShutdown...)
+	}()
+	return "http://" + ln.Addr().String(), done
+}
+
+func TestServeDrainsInFlightRequest(t *testing.T) {
+	entered, release := make(chan struct{}), make(chan struct{})
+	var finished atomic.Bool
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-release
+		w.WriteHeader(http.StatusOK)
+		io.WriteString(w, "done")
+		finished.Store(true)
+	})
+
+	broker := events.NewBroker(nil)
+	_, sub, _, err := broker.Subscribe(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// hook stands in for a stream that takes longer to wind down than the
+	// request takes to finish
+	hookRelease := make(chan struct{})
+	var hookRan atomic.Bool
+	hook := func() {
+		<-hookRelease
+		hookRan.Store(true)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	url, done := serve(t, ctx, handler, 5*time.Second, broker.Close, hook)
+
+	type result struct {
+		status int
+		body   string
+		err    error
+	}
+	got := make(chan result, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			got <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		got <- result{resp.StatusCode, string(body), err}
+	}()
+
+	<-entered
+	cancel()
+
+	// Closing the broker is how shutdown tells open streams to finish; it
+	// happens while the request is still being served.
+	select {
+	case _, ok := <-sub:
+		if ok {
+			t.Fatal("subscriber received an event, want its channel closed")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("broker not closed after shutdown started")
+	}
+	select {
+	case err := <-done:
+		t.Fatalf("Serve returned %v with a request in flight", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+	res := <-got
+	if res.err != nil || res.status != http.StatusOK || res.body != "done" {
+		t.Fatalf("in-flight request = %d %q, %v, want 200 \"done\"", res.status, res.body, res.err)
+	}
+	select {
+	case err := <-done:
+		t.Fatalf("Serve returned %v before its shutdown hooks finished", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(hookRelease)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Serve = %v, want nil", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve did not return after the request finished")
+	}
+	if !finished.Load() {
+		t.Error("Serve returned before the handler finished")
+	}
+	if !hookRan.Load() {
+		t.Error("Serve returned before its shutdown hooks finished")
+	}
+	if _, err := http.Get(url); err == nil {
+		t.Error("server still accepting connections after Serve returned")
+	}
+}
+
+func TestServeShutdownTimeout(t *testing.T) {
+	entered, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-release
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	url, done := serve(t, ctx, handler, 50*time.Millisecond)
+	go func() {
+		if resp, err := http.Get(url); err == nil {
+			resp.Body.Close()
+		}
+	}()
+
+	<-entered
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("Serve = %v, want the shutdown deadline exceeded", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve did not give up on a request that outlived the shutdown timeout")
+	}
+}
+
+func TestServeListenerError(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln.Close()
+	var hookRan atomic.Bool
+	err = Serve(context.Background(), New(Config{}, http.NotFoundHandler()), ln, time.Second, quiet, func() { hookRan.Store(true) })
+	if err == nil || errors.Is(err, http.ErrServerClosed) {
+		t.Errorf("Serve on a closed listener = %v, want the accept error", err)
+	}
+	if hookRan.Load() {
+		t.Error("shutdown hook ran although the server never shut down")
+	}
+}
+
+func TestServeHookOutlivesTimeout(t *testing.T) {
+	stuck := make(chan struct{})
+	defer close(stuck)
+	ctx, cancel := context.WithCancel(context.Background())
+	_, done := serve(t, ctx, http.NotFoundHandler(), 50*time.Millisecond, func() { <-stuck })
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("Serve = %v, want the shutdown deadline exceeded", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve waited past the shutdown timeout for a stuck hook")
+	}
+}
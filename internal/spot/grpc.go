@@ -0,0 +1,118 @@
+package spot
+
+import (
+	"context"
+
+	"PDEA/internal/auth"
+	"PDEA/internal/grpcapi/pdeapb"
+	"PDEA/internal/logging"
+	"PDEA/internal/parking"
+	"PDEA/internal/ratelimit"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const grpcAddr = ":9080"
+
+// grpcPolicy mirrors routePolicy for the same operations.
+var grpcPolicy = auth.Policy{
+	"GRPC /pdea.v1.ParkingSpots/ListSpots":  auth.AllRoles,
+	"GRPC /pdea.v1.ParkingSpots/GetSpot":    auth.AllRoles,
+	"GRPC /pdea.v1.ParkingSpots/UpdateSpot": {auth.RoleAttendant},
+}
+
+// grpcLimits mirrors routeLimits for the same operations.
+var grpcLimits = map[string]ratelimit.Rule{
+	"GRPC /pdea.v1.ParkingSpots/CreateSpot": {Rate: 1, Burst: 10},
+	"GRPC /pdea.v1.ParkingSpots/UpdateSpot": {Rate: 1, Burst: 10},
+	"GRPC /pdea.v1.ParkingSpots/DeleteSpot": {Rate: 1, Burst: 10},
+}
+
+type grpcServer struct {
+	pdeapb.UnimplementedParkingSpotsServer
+}
+
+// registerGRPC creates the service's gRPC server and registers the ParkingSpots
+// service on it.
+func registerGRPC() error {
+	srv, err := svc.NewGRPC(grpcAddr, grpcPolicy, grpcLimits)
+	if err != nil {
+		return err
+	}
+	pdeapb.RegisterParkingSpotsServer(srv, grpcServer{})
+	return nil
+}
+
+func toProto(p parking.ParkingSpot) *pdeapb.ParkingSpot {
+	return &pdeapb.ParkingSpot{Id: int32(p.ID), SpotNumber: p.SpotNumber, Type: p.Type, IsAvailable: p.Available(), Zone: p.Zone, Connector: p.Connector, MaxKw: p.MaxKW, EvOnly: p.EVOnly, Accessible: p.Accessible}
+}
+
+func fromProto(p *pdeapb.ParkingSpot) parking.ParkingSpot {
+	return parking.ParkingSpot{ID: int(p.GetId()), SpotNumber: p.GetSpotNumber(), Type: p.GetType(), IsAvailable: parking.AvailableString(p.GetIsAvailable()), Zone: p.GetZone(), Connector: p.GetConnector(), MaxKW: p.GetMaxKw(), EVOnly: p.GetEvOnly(), Accessible: p.GetAccessible()}
+}
+
+func grpcError(ctx context.Context, err error) error {
+	switch err {
+	case errNotFound:
+		return status.Error(codes.NotFound, err.Error())
+	case errDuplicate:
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errUnavailable:
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errBadCharger, errBadAvailable:
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	logging.FromContext(ctx).Error("grpc call failed", "error", err)
+	return status.Error(codes.Internal, "server error")
+}
+
+func (grpcServer) ListSpots(ctx context.Context, req *pdeapb.ListSpotsRequest) (*pdeapb.ListSpotsResponse, error) {
+	datas, err := getParkinspotsDataAll()
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	res := &pdeapb.ListSpotsResponse{}
+	for _, d := range datas {
+		res.Spots = append(res.Spots, toProto(d))
+	}
+	return res, nil
+}
+
+func (grpcServer) GetSpot(ctx context.Context, req *pdeapb.GetSpotRequest) (*pdeapb.ParkingSpot, error) {
+	p, err := getSpot(int(req.GetId()))
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	return toProto(p), nil
+}
+
+func (grpcServer) CreateSpot(ctx context.Context, req *pdeapb.CreateSpotRequest) (*pdeapb.ParkingSpot, error) {
+	if req.GetSpot() == nil {
+		return nil, status.Error(codes.InvalidArgument, "spot is required")
+	}
+	p, err := createSpot(ctx, fromProto(req.GetSpot()))
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	return toProto(p), nil
+}
+
+func (grpcServer) UpdateSpot(ctx context.Context, req *pdeapb.UpdateSpotRequest) (*pdeapb.ParkingSpot, error) {
+	if req.GetSpot() == nil {
+		return nil, status.Error(codes.InvalidArgument, "spot is required")
+	}
+	p, err := updateSpot(ctx, int(req.GetId()), fromProto(req.GetSpot()))
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	return toProto(p), nil
+}
+
+func (grpcServer) DeleteSpot(ctx context.Context, req *pdeapb.DeleteSpotRequest) (*pdeapb.ParkingSpot, error) {
+	p, err := deleteSpot(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	return toProto(p), nil
+}
@@ -0,0 +1,141 @@
+package spot
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+	"time"
+
+	"PDEA/internal/events"
+	"PDEA/internal/grpcapi/grpctest"
+	"PDEA/internal/grpcapi/pdeapb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// dial starts the spot service against PDEA_TEST_DATABASE_URL with auth
+// off and connects to its gRPC server.
+func dial(t *testing.T) *grpc.ClientConn {
+	t.Helper()
+	grpctest.Env(t)
+	s, err := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.DB.Close() })
+	return grpctest.Dial(t, s.GRPC)
+}
+
+func TestGRPC(t *testing.T) {
+	conn := dial(t)
+	spots := pdeapb.NewParkingSpotsClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	zone := grpctest.Unique("zone-")
+	var before uint64
+	if err := db.QueryRow(`SELECT coalesce(max(seq), 0) FROM parking_events;`).Scan(&before); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("crud", func(t *testing.T) {
+		number := grpctest.Unique("G")
+		created, err := spots.CreateSpot(ctx, &pdeapb.CreateSpotRequest{Spot: &pdeapb.ParkingSpot{SpotNumber: number, Type: "compact", IsAvailable: true, Zone: zone}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if created.GetId() == 0 || created.GetSpotNumber() != number || !created.GetIsAvailable() {
+			t.Errorf("CreateSpot = %+v, want free spot %s with an id", created, number)
+		}
+		if _, err := spots.CreateSpot(ctx, &pdeapb.CreateSpotRequest{Spot: &pdeapb.ParkingSpot{SpotNumber: number, Type: "compact"}}); status.Code(err) != codes.AlreadyExists {
+			t.Errorf("CreateSpot of a taken number = %v, want AlreadyExists", err)
+		}
+		if _, err := spots.CreateSpot(ctx, &pdeapb.CreateSpotRequest{}); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("CreateSpot without a spot = %v, want InvalidArgument", err)
+		}
+
+		got, err := spots.GetSpot(ctx, &pdeapb.GetSpotRequest{Id: created.GetId()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.GetSpotNumber() != number || got.GetZone() != zone {
+			t.Errorf("GetSpot = %+v, want %s in %s", got, number, zone)
+		}
+		list, err := spots.ListSpots(ctx, &pdeapb.ListSpotsRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := false
+		for _, p := range list.GetSpots() {
+			found = found || p.GetId() == created.GetId()
+		}
+		if !found {
+			t.Errorf("ListSpots does not include spot %d", created.GetId())
+		}
+
+		updated, err := spots.UpdateSpot(ctx, &pdeapb.UpdateSpotRequest{Id: created.GetId(), Spot: &pdeapb.ParkingSpot{SpotNumber: number, Type: "large", IsAvailable: false, Zone: zone}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if updated.GetType() != "large" || updated.GetIsAvailable() {
+			t.Errorf("UpdateSpot = %+v, want a taken large spot", updated)
+		}
+		if _, err := spots.UpdateSpot(ctx, &pdeapb.UpdateSpotRequest{Id: -1, Spot: &pdeapb.ParkingSpot{SpotNumber: number}}); status.Code(err) != codes.NotFound {
+			t.Errorf("UpdateSpot of a missing spot = %v, want NotFound", err)
+		}
+
+		deleted, err := spots.DeleteSpot(ctx, &pdeapb.DeleteSpotRequest{Id: created.GetId()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if deleted.GetSpotNumber() != number {
+			t.Errorf("DeleteSpot = %+v, want %s", deleted, number)
+		}
+		if _, err := spots.GetSpot(ctx, &pdeapb.GetSpotRequest{Id: created.GetId()}); status.Code(err) != codes.NotFound {
+			t.Errorf("GetSpot after delete = %v, want NotFound", err)
+		}
+	})
+
+	t.Run("watch resumes from last event id", func(t *testing.T) {
+		watch := pdeapb.NewAvailabilityClient(conn)
+		// the crud subtest recorded a create, an update and a delete in
+		// zone since before
+		stream, err := watch.Watch(ctx, &pdeapb.WatchRequest{LastEventId: before, Zones: []string{zone}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var types []string
+		last := before
+		for len(types) < 3 {
+			change, err := stream.Recv()
+			if err != nil {
+				t.Fatalf("Recv after %v: %v", types, err)
+			}
+			if change.GetEventId() <= last {
+				t.Errorf("event %d replayed after %d", change.GetEventId(), last)
+			}
+			last = change.GetEventId()
+			types = append(types, change.GetEventType())
+		}
+		if want := []string{events.SpotCreated, events.SpotUpdated, events.SpotDeleted}; !reflect.DeepEqual(types, want) {
+			t.Errorf("replayed %v, want %v", types, want)
+		}
+
+		created, err := spots.CreateSpot(ctx, &pdeapb.CreateSpotRequest{Spot: &pdeapb.ParkingSpot{SpotNumber: grpctest.Unique("G"), Type: "compact", IsAvailable: true, Zone: zone}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		change, err := stream.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if change.GetEventId() <= last || change.GetSpotNumber() != created.GetSpotNumber() {
+			t.Errorf("live change = %+v, want the creation of %s after event %d", change, created.GetSpotNumber(), last)
+		}
+		spots.DeleteSpot(ctx, &pdeapb.DeleteSpotRequest{Id: created.GetId()})
+	})
+}
@@ -0,0 +1,553 @@
+// Package spot serves the parking-spot API on :8080 and its gRPC
+// counterpart on :9080.
+//
+// Local database:
+//
+//	podman run -dit --restart on-failure --log-driver=json-file --log-opt max-file=2  --log-opt max-size=20m --name robot-postgres -p 5432:5432 -e POSTGRES_DB=pdea -e POSTGRES_USER=robot -e POSTGRES_PASSWORD=cisco123 postgres:11.5
+package spot
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"PDEA/internal/audit"
+	"PDEA/internal/auth"
+	"PDEA/internal/events"
+	"PDEA/internal/logging"
+	"PDEA/internal/metrics"
+	"PDEA/internal/parking"
+	"PDEA/internal/platform"
+	"PDEA/internal/ratelimit"
+	"PDEA/internal/spotcache"
+
+	"github.com/gorilla/mux"
+)
+
+var (
+	svc      *platform.Service
+	db       *sql.DB
+	auditLog *audit.Logger
+	// cache serves every spot read; writes go to parking_spots and then
+	// refresh it.
+	cache *spotcache.Cache
+
+	spotChanges = metrics.NewCounterVec("pdea_spot_changes_total", "Parking spot changes by event type.", "event")
+)
+
+var routeLimits = map[string]ratelimit.Rule{
+	"POST /api/parking-spots":                              {Rate: 1, Burst: 10},
+	"PUT /api/parking-spots/{id}":                          {Rate: 1, Burst: 10},
+	"DELETE /api/parking-spots/{id}":                       {Rate: 1, Burst: 10},
+	"POST /api/parking-spots/number/{spot_number}/reserve": {Rate: 5, Burst: 20},
+	"POST /api/parking-spots/number/{spot_number}/release": {Rate: 5, Burst: 20},
+}
+
+// schemaVersion is bumped whenever migrate gains a migration; readiness
+// fails if the database reports an older version.
+const schemaVersion = 4
+
+// Admins may call every route; anything not listed here or in
+// platform.CommonPolicy is admin only.
+var routePolicy = auth.Policy{
+	"GET /api/parking-spots/all":                           auth.AllRoles,
+	"GET /api/parking-spots/occupancy":                     auth.AllRoles,
+	"GET /api/parking-spots/{id}":                          auth.AllRoles,
+	"GET /api/parking-spots/number/{spot_number}":          auth.AllRoles,
+	"POST /api/parking-spots/number/{spot_number}/reserve": {auth.RoleGate, auth.RoleAttendant},
+	"POST /api/parking-spots/number/{spot_number}/release": {auth.RoleGate, auth.RoleAttendant},
+	"PUT /api/parking-spots/{id}":                          {auth.RoleAttendant},
+}
+
+// New sets up the spot service on :8080 (gRPC on :9080): the shared platform
+// pieces, the parking_spots schema, the spot cache, metrics and routes.
+func New(logger *slog.Logger) (*platform.Service, error) {
+	var err error
+	svc, err = platform.New("spot", ":8080", logger)
+	if err != nil {
+		return nil, err
+	}
+	db = svc.DB
+	auditLog = svc.Audit
+	cache = spotcache.New(db, platform.DSN(), "parking_spots", "id")
+	cache.Log = logger
+	if err = migrate(); err == nil {
+		err = svc.Ready(schemaVersion)
+	}
+	if err == nil {
+		err = cache.Load()
+	}
+	if err == nil {
+		svc.Workers = append(svc.Workers, cache.Run)
+		svc.Checker.Add("spot-cache", false, cache.Check)
+		registerMetrics()
+		svc.Handler, err = registerRoutes()
+	}
+	if err == nil {
+		err = registerGRPC()
+	}
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return svc, nil
+}
+
+func migrate() error {
+	// Define the SQL to create a schema
+	schemaSQL := `CREATE TABLE IF NOT EXISTS parking_spots (
+id SERIAL PRIMARY KEY,
+spot_number TEXT ,
+type TEXT NOT NULL,
+is_available  BOOLEAN NOT NULL
+);
+
+ALTER TABLE parking_spots ADD COLUMN IF NOT EXISTS zone TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE parking_spots ADD COLUMN IF NOT EXISTS connector TEXT NOT NULL DEFAULT '';
+ALTER TABLE parking_spots ADD COLUMN IF NOT EXISTS max_kw DOUBLE PRECISION NOT NULL DEFAULT 0;
+ALTER TABLE parking_spots ADD COLUMN IF NOT EXISTS ev_only BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TABLE parking_spots ADD COLUMN IF NOT EXISTS accessible BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TABLE parking_spots ADD COLUMN IF NOT EXISTS reserved_by TEXT NOT NULL DEFAULT '';
+`
+	// Execute the SQL statement
+	_, err := db.Exec(schemaSQL)
+	if err != nil {
+		return fmt.Errorf("creating schema: %w", err)
+	}
+	return spotcache.Migrate(db, "parking_spots")
+}
+
+func registerMetrics() {
+	metrics.NewGaugeFunc("pdea_spots", "Parking spots by type and state (free or occupied).", []string{"service", "type", "state"}, func() []metrics.Sample {
+		return cache.Samples("spot")
+	})
+	metrics.NewGaugeFunc("pdea_accessible_spots", "Accessible parking spots by type and state (free or occupied).", []string{"service", "type", "state"}, func() []metrics.Sample {
+		return cache.AccessibleSamples("spot")
+	})
+}
+
+func getParkinspotsDataAll() ([]parking.ParkingSpot, error) {
+	return cache.All(), nil
+}
+func insertParkData(p parking.ParkingSpot) (events.Event, error) {
+	qr := `INSERT INTO parking_spots(id, spot_number, type, is_available, zone, connector, max_kw, ev_only, accessible) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+	ev, err := changeSpot(events.SpotCreated, p, func(tx *sql.Tx) error {
+		_, err := tx.Exec(qr, p.ID, p.SpotNumber, p.Type, p.IsAvailable, p.Zone, p.Connector, p.MaxKW, p.EVOnly, p.Accessible)
+		return err
+	})
+	if err == nil {
+		cache.Refresh(p.SpotNumber)
+	}
+	return ev, err
+}
+func updateParkingData(before, p parking.ParkingSpot) (events.Event, error) {
+	qr := `UPDATE parking_spots SET type = $1 , is_available = $2 ,  spot_number = $3, zone = $4, connector = $5, max_kw = $6, ev_only = $7, accessible = $8 where id = $9;`
+	ev, err := changeSpot(events.SpotUpdated, p, func(tx *sql.Tx) error {
+		_, err := tx.Exec(qr, p.Type, p.IsAvailable, p.SpotNumber, p.Zone, p.Connector, p.MaxKW, p.EVOnly, p.Accessible, p.ID)
+		return err
+	})
+	if err == nil {
+		cache.Refresh(before.SpotNumber, p.SpotNumber)
+	}
+	return ev, err
+}
+func deleteParkingData(p parking.ParkingSpot) (events.Event, error) {
+	qr := `DELETE from parking_spots where id = $1;`
+	ev, err := changeSpot(events.SpotDeleted, p, func(tx *sql.Tx) error {
+		_, err := tx.Exec(qr, p.ID)
+		return err
+	})
+	if err == nil {
+		cache.Refresh(p.SpotNumber)
+	}
+	return ev, err
+}
+
+// changeSpot runs fn, which writes a change to p, in a transaction that
+// also records the event reporting the change, so neither is committed
+// without the other. The event is returned for publishSpot.
+func changeSpot(evType string, p parking.ParkingSpot, fn func(tx *sql.Tx) error) (events.Event, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return events.Event{}, err
+	}
+	defer tx.Rollback()
+	if err = fn(tx); err != nil {
+		return events.Event{}, err
+	}
+	ev, err := svc.Record(tx, spotEvent(evType, p))
+	if err != nil {
+		return ev, err
+	}
+	return ev, tx.Commit()
+}
+func getSpotByNumber(spotNumber string) (parking.ParkingSpot, error) {
+	p, ok := cache.Get(spotNumber)
+	if !ok {
+		return p, errNotFound
+	}
+	return p, nil
+}
+
+func spotEvent(evType string, p parking.ParkingSpot) events.Event {
+	return events.Event{Type: evType, SpotNumber: p.SpotNumber, SpotType: p.Type, Zone: p.Zone, IsAvailable: events.Bool(p.Available()), Data: p}
+}
+
+// publishSpot sends out an event changeSpot recorded, once committed.
+func publishSpot(ev events.Event) {
+	spotChanges.Inc(ev.Type)
+	svc.Publish(ev)
+}
+
+// The operations below are shared by the REST handlers and the gRPC
+// server; each transport maps these errors to its own responses.
+var (
+	errNotFound     = errors.New("parking spot not found")
+	errDuplicate    = errors.New("spot already exists")
+	errUnavailable  = errors.New("parking spot not available")
+	errBadCharger   = errors.New("connector and max_kw must be set together, with max_kw positive")
+	errBadAvailable = errors.New("is_available must be true or false")
+)
+
+func getSpot(id int) (parking.ParkingSpot, error) {
+	datas, err := getParkinspotsDataAll()
+	if err != nil {
+		return parking.ParkingSpot{}, err
+	}
+	for _, d := range datas {
+		if d.ID == id {
+			return d, nil
+		}
+	}
+	return parking.ParkingSpot{}, errNotFound
+}
+
+func createSpot(ctx context.Context, p parking.ParkingSpot) (parking.ParkingSpot, error) {
+	if !p.ValidCharger() {
+		return p, errBadCharger
+	}
+	datas, err := getParkinspotsDataAll()
+	if err != nil {
+		return p, err
+	}
+	var static int
+	for _, d := range datas {
+		if d.SpotNumber == p.SpotNumber {
+			logging.FromContext(ctx).Warn("Duplicate entry", "spot_number", p.SpotNumber)
+			return p, errDuplicate
+		}
+		if static <= d.ID {
+			static = d.ID
+		}
+	}
+	static++
+	p.ID = static
+	ev, err := insertParkData(p)
+	if err != nil {
+		return p, err
+	}
+	auditLog.RecordContext(ctx, "create", "parking_spot", strconv.Itoa(p.ID), nil, p)
+	publishSpot(ev)
+	return p, nil
+}
+
+func updateSpot(ctx context.Context, id int, in parking.ParkingSpot) (parking.ParkingSpot, error) {
+	p, err := getSpot(id)
+	if err != nil {
+		return p, err
+	}
+	if !in.ValidCharger() {
+		return p, errBadCharger
+	}
+	if in.SpotNumber != p.SpotNumber {
+		// the cache and the number-based routes assume spot numbers are unique
+		if _, taken := cache.Get(in.SpotNumber); taken {
+			return p, errDuplicate
+		}
+	}
+	before := p
+	// an omitted is_available keeps the stored value
+	if in.IsAvailable != "" {
+		available, ok := parking.ParseAvailable(in.IsAvailable)
+		if !ok {
+			return p, errBadAvailable
+		}
+		p.IsAvailable = parking.AvailableString(available)
+	}
+	p.Type = in.Type
+	p.SpotNumber = in.SpotNumber
+	p.Zone = in.Zone
+	p.Connector = in.Connector
+	p.MaxKW = in.MaxKW
+	p.EVOnly = in.EVOnly
+	p.Accessible = in.Accessible
+	ev, err := updateParkingData(before, p)
+	if err != nil {
+		return p, err
+	}
+	auditLog.RecordContext(ctx, "update", "parking_spot", strconv.Itoa(p.ID), before, p)
+	publishSpot(ev)
+	return p, nil
+}
+
+func deleteSpot(ctx context.Context, id int) (parking.ParkingSpot, error) {
+	p, err := getSpot(id)
+	if err != nil {
+		return p, err
+	}
+	ev, err := deleteParkingData(p)
+	if err != nil {
+		return p, err
+	}
+	auditLog.RecordContext(ctx, "delete", "parking_spot", strconv.Itoa(p.ID), p, nil)
+	publishSpot(ev)
+	return p, nil
+}
+
+// setAvailability flips is_available in a single conditional UPDATE, so
+// concurrent reservations of the same spot cannot both succeed. Reserving a
+// taken spot fails with errUnavailable; releasing a free spot succeeds
+// without a change.
+//
+// A reservation may name its holder, such as the stay it is for. Reserving
+// again for the same holder then succeeds without a change, so a client
+// that lost the answer can retry, and a release naming a holder only frees
+// the spot if it is reserved for that holder.
+func setAvailability(ctx context.Context, spotNumber string, available bool, holder string) (parking.ParkingSpot, error) {
+	before, err := getSpotByNumber(spotNumber)
+	if err != nil {
+		return before, err
+	}
+	var p parking.ParkingSpot
+	var ev events.Event
+	qr := `UPDATE parking_spots SET is_available = false, reserved_by = $2 where spot_number = $1 and is_available
+		returning id, spot_number, type, is_available, zone, connector, max_kw, ev_only, accessible`
+	if available {
+		qr = `UPDATE parking_spots SET is_available = true, reserved_by = '' where spot_number = $1 and not is_available and ($2 = '' or reserved_by = $2)
+		returning id, spot_number, type, is_available, zone, connector, max_kw, ev_only, accessible`
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return before, err
+	}
+	defer tx.Rollback()
+	err = tx.QueryRow(qr, spotNumber, holder).Scan(&p.ID, &p.SpotNumber, &p.Type, &p.IsAvailable, &p.Zone, &p.Connector, &p.MaxKW, &p.EVOnly, &p.Accessible)
+	if err == nil {
+		if ev, err = svc.Record(tx, spotEvent(events.SpotAvailability, p)); err == nil {
+			err = tx.Commit()
+		}
+	}
+	// refresh even when nothing changed, in case the cached state was stale
+	cache.Refresh(spotNumber)
+	if err == sql.ErrNoRows {
+		if !available {
+			return before, reservedFor(spotNumber, holder)
+		}
+		return before, nil
+	}
+	if err != nil {
+		return before, err
+	}
+	action := "release"
+	if !available {
+		action = "reserve"
+	}
+	auditLog.RecordContext(ctx, action, "parking_spot", strconv.Itoa(p.ID), before, p)
+	publishSpot(ev)
+	return p, nil
+}
+
+// reservedFor returns nil if the taken spot is reserved for holder and
+// errUnavailable otherwise.
+func reservedFor(spotNumber, holder string) error {
+	if holder == "" {
+		return errUnavailable
+	}
+	var ok bool
+	qr := `select exists(select 1 from parking_spots where spot_number = $1 and not is_available and reserved_by = $2);`
+	if err := db.QueryRow(qr, spotNumber, holder).Scan(&ok); err != nil {
+		return err
+	}
+	if !ok {
+		return errUnavailable
+	}
+	return nil
+}
+
+func ParkingSpotsEntry(w http.ResponseWriter, r *http.Request) {
+	var reqBody parking.ParkingSpot
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		http.Error(w, "Invalid request bodt", http.StatusBadRequest)
+		return
+	}
+	p, err := createSpot(audit.ContextFromRequest(r), reqBody)
+	if err == errDuplicate {
+		http.Error(w, "Spot is already exist", http.StatusConflict)
+		return
+	}
+	if err == errBadCharger {
+		http.Error(w, "Invalid charger: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		logging.FromContext(r.Context()).Error("get query error on entry", "error", err)
+		http.Error(w, "server error", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	resJson, _ := json.Marshal(p)
+	w.Write(resJson)
+}
+func ParkingSpotsGetAll(w http.ResponseWriter, r *http.Request) {
+	datas, err := getParkinspotsDataAll()
+	if err != nil {
+		logging.FromContext(r.Context()).Error("get all parking data error", "error", err)
+		http.Error(w, "server error", http.StatusInternalServerError)
+		return
+	}
+	resJason, _ := json.Marshal(datas)
+	w.WriteHeader(http.StatusOK)
+	w.Write(resJason)
+}
+
+func ParkingSpotsGetById(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Path[len("/api/parking-spots/"):]
+	idInt, _ := strconv.Atoi(id)
+	d, err := getSpot(idInt)
+	if err == errNotFound {
+		http.Error(w, "Id not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logging.FromContext(r.Context()).Error("get all parking data error", "error", err)
+		http.Error(w, "server error", http.StatusInternalServerError)
+		return
+	}
+	resJson, _ := json.Marshal(d)
+	w.WriteHeader(http.StatusOK)
+	w.Write(resJson)
+}
+
+func ParkingSpotsUpdate(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Path[len("/api/parking-spots/"):]
+	idInt, _ := strconv.Atoi(id)
+	var reqBody parking.ParkingSpot
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		logging.FromContext(r.Context()).Warn("update parking data decode error", "error", err)
+		http.Error(w, "server error", http.StatusBadRequest)
+		return
+	}
+	p, err := updateSpot(audit.ContextFromRequest(r), idInt, reqBody)
+	if err == errNotFound {
+		http.Error(w, "Parking sopt not found", http.StatusNotFound)
+		return
+	}
+	if err == errDuplicate {
+		http.Error(w, "Spot is already exist", http.StatusConflict)
+		return
+	}
+	if err == errBadCharger {
+		http.Error(w, "Invalid charger: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err == errBadAvailable {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		logging.FromContext(r.Context()).Error("update parking data error", "error", err)
+		http.Error(w, "server error", http.StatusInternalServerError)
+		return
+	}
+	resJson, _ := json.Marshal(p)
+	w.WriteHeader(http.StatusAccepted)
+	w.Write(resJson)
+}
+func ParkingSpotsDelete(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Path[len("/api/parking-spots/"):]
+	idInt, _ := strconv.Atoi(id)
+	_, err := deleteSpot(audit.ContextFromRequest(r), idInt)
+	if err == errNotFound {
+		http.Error(w, "Parking sopt not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logging.FromContext(r.Context()).Error("delete parking data error", "error", err)
+		http.Error(w, "server error", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	res := "Parking spot has been deleted successfully."
+	resJson, _ := json.Marshal(res)
+	w.Write(resJson)
+}
+
+func ParkingSpotsGetByNumber(w http.ResponseWriter, r *http.Request) {
+	p, err := getSpotByNumber(mux.Vars(r)["spot_number"])
+	if err == errNotFound {
+		http.Error(w, "Parking spot not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logging.FromContext(r.Context()).Error("get parking spot by number error", "error", err)
+		http.Error(w, "server error", http.StatusInternalServerError)
+		return
+	}
+	resJson, _ := json.Marshal(p)
+	w.WriteHeader(http.StatusOK)
+	w.Write(resJson)
+}
+
+// availabilityHandler serves reserve and release, with the optional holder
+// query parameter naming who the reservation is for.
+func availabilityHandler(available bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, err := setAvailability(audit.ContextFromRequest(r), mux.Vars(r)["spot_number"], available, r.URL.Query().Get("holder"))
+		switch {
+		case err == errNotFound:
+			http.Error(w, "Parking spot not found", http.StatusNotFound)
+			return
+		case err == errUnavailable:
+			http.Error(w, "Parking spot not available", http.StatusConflict)
+			return
+		case err != nil:
+			logging.FromContext(r.Context()).Error("set parking spot availability error", "error", err)
+			http.Error(w, "server error", http.StatusInternalServerError)
+			return
+		}
+		resJson, _ := json.Marshal(p)
+		w.WriteHeader(http.StatusOK)
+		w.Write(resJson)
+	}
+}
+
+var (
+	ParkingSpotsReserve = availabilityHandler(false)
+	ParkingSpotsRelease = availabilityHandler(true)
+)
+
+func registerRoutes() (*mux.Router, error) {
+	router, err := svc.Router(routePolicy, routeLimits)
+	if err != nil {
+		return nil, err
+	}
+	router.HandleFunc("/api/parking-spots", ParkingSpotsEntry).Methods("POST")
+	router.HandleFunc("/api/parking-spots/all", ParkingSpotsGetAll).Methods("GET")
+	router.HandleFunc("/api/parking-spots/occupancy", cache.OccupancyHandler).Methods("GET")
+	router.HandleFunc("/api/parking-spots/{id}", ParkingSpotsGetById).Methods("GET")
+	router.HandleFunc("/api/parking-spots/number/{spot_number}", ParkingSpotsGetByNumber).Methods("GET")
+	router.HandleFunc("/api/parking-spots/number/{spot_number}/reserve", ParkingSpotsReserve).Methods("POST")
+	router.HandleFunc("/api/parking-spots/number/{spot_number}/release", ParkingSpotsRelease).Methods("POST")
+	router.HandleFunc("/api/parking-spots/{id}", ParkingSpotsUpdate).Methods("PUT")
+	router.HandleFunc("/api/parking-spots/{id}", ParkingSpotsDelete).Methods("DELETE")
+	cache.RegisterRoutes(router)
+	return router, nil
+}
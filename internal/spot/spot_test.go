@@ -0,0 +1,44 @@
+package spot
+
+import (
+	"context"
+	"testing"
+
+	"PDEA/internal/grpcapi/grpctest"
+	"PDEA/internal/parking"
+)
+
+func TestUpdateSpotAvailability(t *testing.T) {
+	dial(t)
+	ctx := context.Background()
+	p, err := createSpot(ctx, parking.ParkingSpot{SpotNumber: grpctest.Unique("G"), Type: "compact", IsAvailable: "true", Zone: grpctest.Unique("zone-")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer deleteSpot(ctx, p.ID)
+
+	for _, tc := range []struct {
+		in, want string
+		err      error
+	}{
+		{in: "", want: "true"},
+		{in: "0", want: "false"},
+		{in: "", want: "false"},
+		{in: "maybe", want: "false", err: errBadAvailable},
+		{in: "TRUE", want: "true"},
+	} {
+		in := p
+		in.IsAvailable = tc.in
+		_, err := updateSpot(ctx, p.ID, in)
+		if err != tc.err {
+			t.Errorf("updateSpot with is_available %q = %v, want %v", tc.in, err, tc.err)
+		}
+		got, err := getSpot(p.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.IsAvailable != tc.want {
+			t.Errorf("after updateSpot with is_available %q the spot is %q, want %q", tc.in, got.IsAvailable, tc.want)
+		}
+	}
+}
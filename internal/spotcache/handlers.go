@@ -0,0 +1,36 @@
+package spotcache
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"PDEA/internal/logging"
+
+	"github.com/gorilla/mux"
+)
+
+func (c *Cache) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/admin/spot-cache/verify", c.VerifyHandler).Methods("POST")
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	resJson, _ := json.Marshal(v)
+	w.WriteHeader(status)
+	w.Write(resJson)
+}
+
+// VerifyHandler runs the consistency check now instead of waiting for the
+// next interval.
+func (c *Cache) VerifyHandler(w http.ResponseWriter, r *http.Request) {
+	n, err := c.Verify()
+	if err != nil {
+		logging.FromContext(r.Context()).Error("spot cache verify failed", "error", err)
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"table": c.Table, "repaired": n})
+}
+
+func (c *Cache) OccupancyHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, c.Occupancy())
+}
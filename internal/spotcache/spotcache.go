@@ -0,0 +1,378 @@
+// Package spotcache keeps an in-process index of parking spots and free
+// spots per type and zone, so lookups and list requests do not hit the
+// database. Local writes refresh the affected spots directly; a trigger on
+// the spot table sends a NOTIFY on every change so other replicas refresh
+// them too, and a periodic Verify repairs anything both of those missed.
+package spotcache
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"PDEA/internal/metrics"
+	"PDEA/internal/parking"
+
+	"github.com/lib/pq"
+)
+
+// Channel carries "<table>:<spot_number>" for every changed row.
+const Channel = "pdea_spot_changes"
+
+var drift = metrics.NewCounterVec("pdea_spotcache_drift_total", "Cached spots found to differ from the database by Verify.", "table")
+
+// Migrate installs the notify trigger on table. Every writer, including
+// rebuild and manual SQL, then invalidates the caches.
+func Migrate(db *sql.DB, table string) error {
+	schemaSQL := `
+CREATE OR REPLACE FUNCTION pdea_notify_spot_change() RETURNS trigger AS $$
+BEGIN
+	IF TG_OP <> 'INSERT' THEN
+		PERFORM pg_notify('` + Channel + `', TG_TABLE_NAME || ':' || coalesce(OLD.spot_number, ''));
+	END IF;
+	IF TG_OP = 'INSERT' OR (TG_OP = 'UPDATE' AND NEW.spot_number IS DISTINCT FROM OLD.spot_number) THEN
+		PERFORM pg_notify('` + Channel + `', TG_TABLE_NAME || ':' || coalesce(NEW.spot_number, ''));
+	END IF;
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DO $$
+BEGIN
+	IF NOT EXISTS (select 1 from pg_trigger where tgname = 'pdea_spot_changes' and tgrelid = '` + table + `'::regclass) THEN
+		CREATE TRIGGER pdea_spot_changes AFTER INSERT OR UPDATE OR DELETE ON ` + table + `
+		FOR EACH ROW EXECUTE PROCEDURE pdea_notify_spot_change();
+	END IF;
+END $$;
+`
+	if _, err := db.Exec(schemaSQL); err != nil {
+		return fmt.Errorf("creating %s notify trigger: %w", table, err)
+	}
+	return nil
+}
+
+type group struct{ typ, zone string }
+
+type Cache struct {
+	DB  *sql.DB
+	DSN string
+	// Table has spot_number, type, is_available, zone, accessible and the
+	// charger columns connector, max_kw and ev_only; IDColumn selects the
+	// spot ID ("0" for tables without one).
+	Table          string
+	IDColumn       string
+	VerifyInterval time.Duration
+	Log            *slog.Logger
+
+	// refreshMu orders reads from the database, so a slow refresh cannot
+	// overwrite a newer one; mu only guards the maps.
+	refreshMu sync.Mutex
+	mu        sync.RWMutex
+	spots     map[string]parking.ParkingSpot
+	groups    map[group]*parking.Occupancy
+
+	loaded     atomic.Bool
+	listening  atomic.Bool
+	lastVerify atomic.Int64
+}
+
+func New(db *sql.DB, dsn, table, idColumn string) *Cache {
+	return &Cache{
+		DB:             db,
+		DSN:            dsn,
+		Table:          table,
+		IDColumn:       idColumn,
+		VerifyInterval: time.Minute,
+		Log:            slog.Default(),
+	}
+}
+
+func (c *Cache) query(where string, args ...interface{}) ([]parking.ParkingSpot, error) {
+	qr := `select ` + c.IDColumn + `, coalesce(spot_number, ''), type, is_available, zone, connector, max_kw, ev_only, accessible from ` + c.Table + where
+	rows, err := c.DB.Query(qr, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []parking.ParkingSpot
+	for rows.Next() {
+		var p parking.ParkingSpot
+		if err := rows.Scan(&p.ID, &p.SpotNumber, &p.Type, &p.IsAvailable, &p.Zone, &p.Connector, &p.MaxKW, &p.EVOnly, &p.Accessible); err != nil {
+			return nil, err
+		}
+		res = append(res, p)
+	}
+	return res, rows.Err()
+}
+
+// Load replaces the cache with the current table contents.
+func (c *Cache) Load() error {
+	_, err := c.Verify()
+	return err
+}
+
+// Verify compares the cache with the table, repairs any differences and
+// returns how many spots differed.
+func (c *Cache) Verify() (int, error) {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+	all, err := c.query("")
+	if err != nil {
+		return 0, fmt.Errorf("loading %s: %w", c.Table, err)
+	}
+	fresh := make(map[string]parking.ParkingSpot, len(all))
+	for _, p := range all {
+		fresh[p.SpotNumber] = p
+	}
+
+	c.mu.Lock()
+	n := 0
+	for k, p := range c.spots {
+		if q, ok := fresh[k]; !ok || q != p {
+			n++
+		}
+	}
+	for k := range fresh {
+		if _, ok := c.spots[k]; !ok {
+			n++
+		}
+	}
+	c.spots = make(map[string]parking.ParkingSpot, len(fresh))
+	c.groups = make(map[group]*parking.Occupancy)
+	for _, p := range fresh {
+		c.add(p)
+	}
+	c.mu.Unlock()
+
+	if c.loaded.Swap(true) && n > 0 {
+		drift.Add(float64(n), c.Table)
+		c.Log.Warn("spot cache was out of date", "table", c.Table, "spots", n)
+	}
+	c.lastVerify.Store(time.Now().UnixNano())
+	return n, nil
+}
+
+// Refresh re-reads the given spots. Failures are only logged: the change
+// notification or the next Verify brings the cache back in line.
+func (c *Cache) Refresh(spotNumbers ...string) {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+	for _, n := range spotNumbers {
+		rows, err := c.query(` where coalesce(spot_number, '') = $1`, n)
+		if err != nil {
+			c.Log.Error("spot cache refresh failed", "table", c.Table, "spot_number", n, "error", err)
+			continue
+		}
+		c.mu.Lock()
+		c.remove(n)
+		for _, p := range rows {
+			c.add(p)
+		}
+		c.mu.Unlock()
+	}
+}
+
+func (c *Cache) add(p parking.ParkingSpot) {
+	c.spots[p.SpotNumber] = p
+	g := group{p.Type, p.Zone}
+	o := c.groups[g]
+	if o == nil {
+		o = &parking.Occupancy{Type: p.Type, Zone: p.Zone}
+		c.groups[g] = o
+	}
+	o.Total++
+	if p.Available() {
+		o.Free++
+	}
+	if p.Accessible {
+		o.AccessibleTotal++
+		if p.Available() {
+			o.AccessibleFree++
+		}
+	}
+}
+
+func (c *Cache) remove(spotNumber string) {
+	p, ok := c.spots[spotNumber]
+	if !ok {
+		return
+	}
+	delete(c.spots, spotNumber)
+	g := group{p.Type, p.Zone}
+	o := c.groups[g]
+	o.Total--
+	if p.Available() {
+		o.Free--
+	}
+	if p.Accessible {
+		o.AccessibleTotal--
+		if p.Available() {
+			o.AccessibleFree--
+		}
+	}
+	if o.Total == 0 {
+		delete(c.groups, g)
+	}
+}
+
+func (c *Cache) Get(spotNumber string) (parking.ParkingSpot, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	p, ok := c.spots[spotNumber]
+	return p, ok
+}
+
+// All returns every spot ordered by ID, then spot number.
+func (c *Cache) All() []parking.ParkingSpot {
+	c.mu.RLock()
+	res := make([]parking.ParkingSpot, 0, len(c.spots))
+	for _, p := range c.spots {
+		res = append(res, p)
+	}
+	c.mu.RUnlock()
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].ID != res[j].ID {
+			return res[i].ID < res[j].ID
+		}
+		return res[i].SpotNumber < res[j].SpotNumber
+	})
+	return res
+}
+
+// Occupancy returns the counts for every type and zone that has spots,
+// ordered by type, then zone.
+func (c *Cache) Occupancy() []parking.Occupancy {
+	c.mu.RLock()
+	res := make([]parking.Occupancy, 0, len(c.groups))
+	for _, o := range c.groups {
+		res = append(res, *o)
+	}
+	c.mu.RUnlock()
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Type != res[j].Type {
+			return res[i].Type < res[j].Type
+		}
+		return res[i].Zone < res[j].Zone
+	})
+	return res
+}
+
+// Samples renders the counts summed over zones for the pdea_spots gauge,
+// labelled with service, type and state.
+func (c *Cache) Samples(service string) []metrics.Sample {
+	occ := c.Occupancy()
+	var res []metrics.Sample
+	for i := 0; i < len(occ); {
+		spotType := occ[i].Type
+		free, total := 0, 0
+		for ; i < len(occ) && occ[i].Type == spotType; i++ {
+			free += occ[i].Free
+			total += occ[i].Total
+		}
+		res = append(res,
+			metrics.Sample{Values: []string{service, spotType, "free"}, Value: float64(free)},
+			metrics.Sample{Values: []string{service, spotType, "occupied"}, Value: float64(total - free)})
+	}
+	return res
+}
+
+// AccessibleSamples renders the accessible counts summed over zones for the
+// pdea_accessible_spots gauge, labelled like Samples.
+func (c *Cache) AccessibleSamples(service string) []metrics.Sample {
+	occ := c.Occupancy()
+	var res []metrics.Sample
+	for i := 0; i < len(occ); {
+		spotType := occ[i].Type
+		free, total := 0, 0
+		for ; i < len(occ) && occ[i].Type == spotType; i++ {
+			free += occ[i].AccessibleFree
+			total += occ[i].AccessibleTotal
+		}
+		if total == 0 {
+			continue
+		}
+		res = append(res,
+			metrics.Sample{Values: []string{service, spotType, "free"}, Value: float64(free)},
+			metrics.Sample{Values: []string{service, spotType, "occupied"}, Value: float64(total - free)})
+	}
+	return res
+}
+
+// Run listens for change notifications and verifies the cache every
+// VerifyInterval until ctx is cancelled. Notifications lost while the
+// listener was disconnected are covered by a full reload on reconnect.
+func (c *Cache) Run(ctx context.Context) {
+	l := pq.NewListener(c.DSN, time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		switch ev {
+		case pq.ListenerEventConnected, pq.ListenerEventReconnected:
+			c.listening.Store(true)
+		case pq.ListenerEventDisconnected:
+			c.listening.Store(false)
+			c.Log.Warn("spot cache listener disconnected", "table", c.Table, "error", err)
+		case pq.ListenerEventConnectionAttemptFailed:
+			c.Log.Warn("spot cache listener cannot connect", "table", c.Table, "error", err)
+		}
+	})
+	defer l.Close()
+	stop := context.AfterFunc(ctx, func() { l.Close() })
+	defer stop()
+	if err := l.Listen(Channel); err != nil {
+		if ctx.Err() == nil {
+			c.Log.Error("spot cache listen failed", "table", c.Table, "error", err)
+		}
+		return
+	}
+	// changes between the initial load and LISTEN were not notified
+	c.reload()
+
+	ticker := time.NewTicker(c.VerifyInterval)
+	defer ticker.Stop()
+	prefix := c.Table + ":"
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case n, ok := <-l.Notify:
+			if !ok {
+				return
+			}
+			if n == nil {
+				c.reload()
+				continue
+			}
+			if strings.HasPrefix(n.Extra, prefix) {
+				c.Refresh(n.Extra[len(prefix):])
+			}
+		case <-ticker.C:
+			c.reload()
+			go l.Ping()
+		}
+	}
+}
+
+func (c *Cache) reload() {
+	if _, err := c.Verify(); err != nil {
+		c.Log.Error("spot cache verify failed", "table", c.Table, "error", err)
+	}
+}
+
+// Check reports whether the cache is receiving notifications and has been
+// verified against the database recently.
+func (c *Cache) Check(ctx context.Context) error {
+	if !c.loaded.Load() {
+		return fmt.Errorf("spot cache not loaded")
+	}
+	if !c.listening.Load() {
+		return fmt.Errorf("spot cache listener not connected")
+	}
+	if age := time.Since(time.Unix(0, c.lastVerify.Load())); age > 3*c.VerifyInterval+time.Minute {
+		return fmt.Errorf("spot cache last verified %s ago", age.Round(time.Second))
+	}
+	return nil
+}
@@ -0,0 +1,320 @@
+// Package spotclient is a typed client for the parking-spot service API,
+// used by other services instead of reading the spot tables directly.
+package spotclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"PDEA/internal/logging"
+	"PDEA/internal/metrics"
+	"PDEA/internal/parking"
+)
+
+var (
+	ErrNotFound    = errors.New("parking spot not found")
+	ErrUnavailable = errors.New("parking spot not available")
+	ErrCircuitOpen = errors.New("spot service circuit open")
+)
+
+var requests = metrics.NewCounterVec("pdea_spotclient_requests_total", "Calls to the spot service by operation and outcome.", "op", "outcome")
+
+type Spot = parking.ParkingSpot
+
+// StatusError is a response the spot service answered with but that has no
+// more specific error.
+type StatusError struct {
+	Code    int
+	Message string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("spot service: %d %s", e.Code, e.Message)
+}
+
+type Client struct {
+	BaseURL string
+	APIKey  string
+	HTTP    *http.Client
+	Retries int
+	Backoff time.Duration
+	Breaker *Breaker
+}
+
+func New(baseURL, apiKey string) *Client {
+	return &Client{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		APIKey:  apiKey,
+		HTTP:    &http.Client{Timeout: 2 * time.Second},
+		Retries: 2,
+		Backoff: 100 * time.Millisecond,
+		Breaker: NewBreaker(5, 30*time.Second),
+	}
+}
+
+// NewFromEnv builds a client from PDEA_SPOT_URL, PDEA_SPOT_API_KEY and
+// PDEA_SPOT_TIMEOUT.
+func NewFromEnv() (*Client, error) {
+	base := os.Getenv("PDEA_SPOT_URL")
+	if base == "" {
+		base = "http://localhost:8080"
+	}
+	if _, err := url.ParseRequestURI(base); err != nil {
+		return nil, fmt.Errorf("PDEA_SPOT_URL: %w", err)
+	}
+	c := New(base, os.Getenv("PDEA_SPOT_API_KEY"))
+	if v := os.Getenv("PDEA_SPOT_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("PDEA_SPOT_TIMEOUT: invalid duration %q", v)
+		}
+		c.HTTP.Timeout = d
+	}
+	return c, nil
+}
+
+func (c *Client) Get(ctx context.Context, spotNumber string) (Spot, error) {
+	var s Spot
+	err := c.call(ctx, "get", "GET", "/api/parking-spots/number/"+url.PathEscape(spotNumber), true, &s)
+	return s, err
+}
+
+// All returns every spot.
+func (c *Client) All(ctx context.Context) ([]Spot, error) {
+	var res []Spot
+	err := c.call(ctx, "all", "GET", "/api/parking-spots/all", true, &res)
+	return res, err
+}
+
+// Reserve marks a free spot as taken for holder. It fails with
+// ErrUnavailable if the spot is already occupied, so two gates cannot
+// reserve the same spot. Reserving again for the same holder succeeds, so
+// a reservation with a holder is retried like an idempotent call.
+func (c *Client) Reserve(ctx context.Context, spotNumber, holder string) (Spot, error) {
+	var s Spot
+	err := c.call(ctx, "reserve", "POST", availabilityPath(spotNumber, "reserve", holder), holder != "", &s)
+	return s, err
+}
+
+// Release marks a spot as free again. Releasing a free spot is not an error.
+// With a holder, the spot is only freed if it is reserved for holder.
+func (c *Client) Release(ctx context.Context, spotNumber, holder string) (Spot, error) {
+	var s Spot
+	err := c.call(ctx, "release", "POST", availabilityPath(spotNumber, "release", holder), true, &s)
+	return s, err
+}
+
+func availabilityPath(spotNumber, action, holder string) string {
+	path := "/api/parking-spots/number/" + url.PathEscape(spotNumber) + "/" + action
+	if holder != "" {
+		path += "?holder=" + url.QueryEscape(holder)
+	}
+	return path
+}
+
+// Answered reports whether err is the spot service's answer to a request,
+// rather than a failure to get one. After any other error a reservation
+// may or may not have been made.
+func Answered(err error) bool {
+	var se *StatusError
+	return errors.Is(err, ErrNotFound) || errors.Is(err, ErrUnavailable) || errors.Is(err, ErrCircuitOpen) || errors.As(err, &se)
+}
+
+// Occupancy returns free and total spots per type and zone.
+func (c *Client) Occupancy(ctx context.Context) ([]parking.Occupancy, error) {
+	var occ []parking.Occupancy
+	err := c.call(ctx, "occupancy", "GET", "/api/parking-spots/occupancy", true, &occ)
+	return occ, err
+}
+
+// Check is a health check that reports an open circuit or an unready spot
+// service.
+func (c *Client) Check(ctx context.Context) error {
+	if c.Breaker.State() == "open" {
+		return ErrCircuitOpen
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", c.BaseURL+"/readyz", nil)
+	if err != nil {
+		return err
+	}
+	res, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return &StatusError{Code: res.StatusCode, Message: "readiness check failed"}
+	}
+	return nil
+}
+
+// call runs one request through the breaker. Idempotent calls are retried
+// on transport errors and 5xx; a reserve without a holder is only retried
+// when the server shed the request before handling it (429/503).
+func (c *Client) call(ctx context.Context, op, method, path string, idempotent bool, out interface{}) error {
+	var err error
+	for attempt := 0; ; attempt++ {
+		if !c.Breaker.Allow() {
+			requests.Inc(op, "circuit_open")
+			return ErrCircuitOpen
+		}
+		var retry bool
+		retry, err = c.do(ctx, method, path, out)
+		if healthy(err) {
+			c.Breaker.Success()
+		} else {
+			c.Breaker.Failure()
+		}
+		requests.Inc(op, outcome(err))
+		if err == nil || !retry || attempt >= c.Retries {
+			break
+		}
+		var se *StatusError
+		if !idempotent && !(errors.As(err, &se) && (se.Code == http.StatusTooManyRequests || se.Code == http.StatusServiceUnavailable)) {
+			break
+		}
+		logging.FromContext(ctx).Warn("retrying spot service call", "op", op, "attempt", attempt+1, "error", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(c.Backoff << attempt):
+		}
+	}
+	return err
+}
+
+// healthy reports whether err leaves the breaker closed: no error, or a
+// well-formed 4xx answer, which is the service working as intended. Transport,
+// decoding and 5xx errors and shed requests (429) count as failures.
+func healthy(err error) bool {
+	var se *StatusError
+	switch {
+	case err == nil, errors.Is(err, ErrNotFound), errors.Is(err, ErrUnavailable):
+		return true
+	case errors.As(err, &se):
+		return se.Code >= 400 && se.Code < 500 && se.Code != http.StatusTooManyRequests
+	}
+	return false
+}
+
+// do reports whether a failure is worth retrying.
+func (c *Client) do(ctx context.Context, method, path string, out interface{}) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.APIKey != "" {
+		req.Header.Set("X-API-Key", c.APIKey)
+	}
+	if id := logging.RequestID(ctx); id != "" {
+		req.Header.Set("X-Request-ID", id)
+	}
+	res, err := c.HTTP.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return true, err
+	}
+	switch {
+	case res.StatusCode == http.StatusNotFound:
+		return false, ErrNotFound
+	case res.StatusCode == http.StatusConflict:
+		return false, ErrUnavailable
+	case res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500:
+		return true, &StatusError{Code: res.StatusCode, Message: string(bytes.TrimSpace(body))}
+	case res.StatusCode < 200 || res.StatusCode > 299:
+		return false, &StatusError{Code: res.StatusCode, Message: string(bytes.TrimSpace(body))}
+	}
+	if out == nil {
+		return false, nil
+	}
+	if err = json.Unmarshal(body, out); err != nil {
+		return false, fmt.Errorf("decoding spot service response: %w", err)
+	}
+	return false, nil
+}
+
+func outcome(err error) string {
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, ErrNotFound):
+		return "not_found"
+	case errors.Is(err, ErrUnavailable):
+		return "unavailable"
+	}
+	return "error"
+}
+
+// Breaker opens after Threshold consecutive failures and rejects calls until
+// Cooldown has passed, then lets a single probe through.
+type Breaker struct {
+	Threshold int
+	Cooldown  time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func NewBreaker(threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{Threshold: threshold, Cooldown: cooldown}
+}
+
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < b.Threshold {
+		return true
+	}
+	if time.Since(b.openedAt) < b.Cooldown || b.probing {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	b.failures = 0
+	b.probing = false
+	b.mu.Unlock()
+}
+
+func (b *Breaker) Failure() {
+	b.mu.Lock()
+	b.failures++
+	if b.failures >= b.Threshold {
+		b.openedAt = time.Now()
+	}
+	b.probing = false
+	b.mu.Unlock()
+}
+
+// State is "closed", "open" or "half-open".
+func (b *Breaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch {
+	case b.failures < b.Threshold:
+		return "closed"
+	case time.Since(b.openedAt) < b.Cooldown:
+		return "open"
+	}
+	return "half-open"
+}
@@ -0,0 +1,95 @@
+package spotclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReserveRetriesOnlyWithHolder(t *testing.T) {
+	var calls atomic.Int32
+	var holder atomic.Value
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		holder.Store(r.URL.Query().Get("holder"))
+		if calls.Add(1) == 1 {
+			http.Error(w, "bad gateway", http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"spot_number":"A1"}`))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		holder string
+		calls  int32
+		ok     bool
+	}{
+		{"", 1, false},
+		{"stay:7", 2, true},
+	}
+	for _, tt := range tests {
+		calls.Store(0)
+		c := New(srv.URL, "")
+		c.Backoff = time.Millisecond
+		_, err := c.Reserve(context.Background(), "A1", tt.holder)
+		if (err == nil) != tt.ok || calls.Load() != tt.calls {
+			t.Errorf("Reserve with holder %q = %v after %d calls, want ok %v after %d", tt.holder, err, calls.Load(), tt.ok, tt.calls)
+		}
+		if got := holder.Load(); got != tt.holder {
+			t.Errorf("holder sent = %q, want %q", got, tt.holder)
+		}
+	}
+}
+
+func TestAnswered(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{ErrNotFound, true},
+		{ErrUnavailable, true},
+		{ErrCircuitOpen, true},
+		{&StatusError{Code: 500}, true},
+		{context.DeadlineExceeded, false},
+	}
+	for _, tt := range tests {
+		if got := Answered(tt.err); got != tt.want {
+			t.Errorf("Answered(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestBreakerCountsBadAnswers(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		opens  bool
+	}{
+		{"spot", http.StatusOK, `{"spot_number":"A1"}`, false},
+		{"not found", http.StatusNotFound, "", false},
+		{"bad request", http.StatusBadRequest, "", false},
+		{"undecodable body", http.StatusOK, `<html>`, true},
+		{"server error", http.StatusInternalServerError, "", true},
+		{"shed", http.StatusTooManyRequests, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+			c := New(srv.URL, "")
+			c.Retries = 0
+			c.Breaker = NewBreaker(1, time.Minute)
+			c.Get(context.Background(), "A1")
+			if got := c.Breaker.State() == "open"; got != tt.opens {
+				t.Errorf("breaker %s after a %d %q answer, want open %v", c.Breaker.State(), tt.status, tt.body, tt.opens)
+			}
+		})
+	}
+}
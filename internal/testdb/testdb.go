@@ -0,0 +1,50 @@
+// Package testdb connects tests to the database named by
+// PDEA_TEST_DATABASE_URL.
+package testdb
+
+import (
+	"database/sql"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	_ "github.com/lib/pq"
+)
+
+// Open connects to PDEA_TEST_DATABASE_URL with schema, created if needed,
+// as the search path, so a test that drops and recreates its tables does not
+// disturb other packages' tests running at the same time. It skips t when
+// the variable is not set.
+func Open(t testing.TB, schema string) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("PDEA_TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("PDEA_TEST_DATABASE_URL not set")
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`CREATE SCHEMA IF NOT EXISTS ` + schema)
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db, err = sql.Open("postgres", withSearchPath(dsn, schema)); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// withSearchPath adds search_path to a URL or key=value connection string.
+func withSearchPath(dsn, schema string) string {
+	if u, err := url.Parse(dsn); err == nil && (u.Scheme == "postgres" || u.Scheme == "postgresql") {
+		q := u.Query()
+		q.Set("search_path", schema)
+		u.RawQuery = q.Encode()
+		return u.String()
+	}
+	return strings.TrimSpace(dsn) + " search_path=" + schema
+}
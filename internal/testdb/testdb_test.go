@@ -0,0 +1,19 @@
+package testdb
+
+import "testing"
+
+func TestWithSearchPath(t *testing.T) {
+	tests := []struct {
+		dsn  string
+		want string
+	}{
+		{"postgres://u:p@localhost/pdea", "postgres://u:p@localhost/pdea?search_path=s"},
+		{"postgresql://localhost/pdea?sslmode=disable", "postgresql://localhost/pdea?search_path=s&sslmode=disable"},
+		{"host=localhost dbname=pdea sslmode=disable", "host=localhost dbname=pdea sslmode=disable search_path=s"},
+	}
+	for _, tt := range tests {
+		if got := withSearchPath(tt.dsn, "s"); got != tt.want {
+			t.Errorf("withSearchPath(%q) = %q, want %q", tt.dsn, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+// Package ticket issues and checks the parking tickets handed out at entry.
+// A ticket has a short random ID for people and a signed token for the QR
+// code:
+//
+//	v1.<ticket id>.<stay id>.<entry unix seconds>.<signature>
+//
+// The signature is an HMAC-SHA256 over everything before it, so an exit
+// gate can trust the stay ID in a scanned token without a lookup by plate.
+package ticket
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const version = "v1"
+
+var ErrInvalid = errors.New("invalid ticket")
+
+var idEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+type Ticket struct {
+	ID        string
+	StayID    int
+	EntryTime time.Time
+}
+
+type Signer struct {
+	Secret []byte
+	// Ephemeral is set when no secret was configured and a random one is
+	// used; tickets then only verify on this process until it restarts.
+	Ephemeral bool
+}
+
+// NewFromEnv signs with PDEA_TICKET_SECRET. Every replica must share it.
+func NewFromEnv() (*Signer, error) {
+	if secret := os.Getenv("PDEA_TICKET_SECRET"); secret != "" {
+		return &Signer{Secret: []byte(secret)}, nil
+	}
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return &Signer{Secret: b, Ephemeral: true}, nil
+}
+
+// NewID returns a random ticket ID, short enough to read out or type in.
+func NewID() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return idEncoding.EncodeToString(b), nil
+}
+
+func (s *Signer) sign(payload string) string {
+	mac := hmac.New(sha256.New, s.Secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Token renders t as a signed token. The same ticket always gives the same
+// token, so a lost printout can be reissued.
+func (s *Signer) Token(t Ticket) string {
+	payload := strings.Join([]string{version, t.ID, strconv.Itoa(t.StayID), strconv.FormatInt(t.EntryTime.Unix(), 10)}, ".")
+	return payload + "." + s.sign(payload)
+}
+
+// Verify checks token's signature and returns the ticket it carries.
+func (s *Signer) Verify(token string) (Ticket, error) {
+	i := strings.LastIndexByte(token, '.')
+	if i < 0 {
+		return Ticket{}, ErrInvalid
+	}
+	payload, sig := token[:i], token[i+1:]
+	if !hmac.Equal([]byte(sig), []byte(s.sign(payload))) {
+		return Ticket{}, ErrInvalid
+	}
+	parts := strings.Split(payload, ".")
+	if len(parts) != 4 || parts[0] != version {
+		return Ticket{}, ErrInvalid
+	}
+	stayID, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return Ticket{}, fmt.Errorf("%w: bad stay id", ErrInvalid)
+	}
+	entry, err := strconv.ParseInt(parts[3], 10, 64)
+	if err != nil {
+		return Ticket{}, fmt.Errorf("%w: bad entry time", ErrInvalid)
+	}
+	return Ticket{ID: parts[1], StayID: stayID, EntryTime: time.Unix(entry, 0).UTC()}, nil
+}
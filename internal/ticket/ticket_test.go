@@ -0,0 +1,80 @@
+package ticket
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenRoundTrip(t *testing.T) {
+	s := &Signer{Secret: []byte("secret")}
+	id, err := NewID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Ticket{ID: id, StayID: 42, EntryTime: time.Date(2024, 3, 1, 9, 30, 15, 0, time.UTC)}
+	token := s.Token(want)
+	if token != s.Token(want) {
+		t.Errorf("Token is not stable for the same ticket")
+	}
+	got, err := s.Verify(token)
+	if err != nil {
+		t.Fatalf("Verify(%q): %v", token, err)
+	}
+	if got != want {
+		t.Errorf("Verify(%q) = %+v, want %+v", token, got, want)
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	s := &Signer{Secret: []byte("secret")}
+	entry := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+	token := s.Token(Ticket{ID: "ABCDEFGHIJKLMNOP", StayID: 42, EntryTime: entry})
+	payload, sig := token[:strings.LastIndexByte(token, '.')], token[strings.LastIndexByte(token, '.')+1:]
+	// resign builds tokens the right secret signed but that carry a bad
+	// payload.
+	resign := func(payload string) string { return payload + "." + s.sign(payload) }
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"empty", ""},
+		{"no signature", payload},
+		{"other stay", strings.Replace(payload, ".42.", ".43.", 1) + "." + sig},
+		{"other entry time", strings.Replace(payload, ".1709285400", ".1709281800", 1) + "." + sig},
+		{"other ticket id", strings.Replace(payload, "ABCDEFGHIJKLMNOP", "ABCDEFGHIJKLMNOQ", 1) + "." + sig},
+		{"truncated signature", payload + "." + sig[:len(sig)-1]},
+		{"signature flipped", payload + "." + strings.ToUpper(sig)},
+		{"other secret", (&Signer{Secret: []byte("other")}).Token(Ticket{ID: "ABCDEFGHIJKLMNOP", StayID: 42, EntryTime: entry})},
+		{"other version", resign(strings.Replace(payload, "v1.", "v2.", 1))},
+		{"missing field", resign("v1.ABCDEFGHIJKLMNOP.42")},
+		{"bad stay id", resign("v1.ABCDEFGHIJKLMNOP.x.1709285400")},
+		{"bad entry time", resign("v1.ABCDEFGHIJKLMNOP.42.x")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := s.Verify(tt.token); !errors.Is(err, ErrInvalid) {
+				t.Errorf("Verify(%q) = %+v, %v, want ErrInvalid", tt.token, got, err)
+			}
+		})
+	}
+}
+
+func TestNewID(t *testing.T) {
+	a, err := NewID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a) != 16 || strings.ContainsAny(a, ".=") {
+		t.Errorf("NewID() = %q, want 16 characters without dots or padding", a)
+	}
+	if a == b {
+		t.Errorf("NewID() returned %q twice", a)
+	}
+}
@@ -0,0 +1,125 @@
+package timefmt
+
+import (
+	"database/sql"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	Legacy  = "legacy"
+	RFC3339 = "rfc3339"
+
+	LegacyLayout = "02-01-2006 15:04:05"
+)
+
+var facility = time.Local
+
+// LoadFacilityZone reads the facility time zone from PDEA_TIMEZONE (an IANA
+// name such as "Asia/Kolkata"), falling back to TZ. When neither is set the
+// server local zone is kept, but it has no name the database can use, so
+// MigrateColumns refuses to convert columns with it.
+func LoadFacilityZone() error {
+	env, name := "PDEA_TIMEZONE", os.Getenv("PDEA_TIMEZONE")
+	if name == "" {
+		env, name = "TZ", strings.TrimPrefix(os.Getenv("TZ"), ":")
+	}
+	if name == "" {
+		return nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return fmt.Errorf("invalid %s %q: %w", env, name, err)
+	}
+	facility = loc
+	return nil
+}
+
+func Facility() *time.Location {
+	return facility
+}
+
+// Now returns the current time in UTC, which is how every timestamp is stored.
+func Now() time.Time {
+	return time.Now().UTC()
+}
+
+type Formatter struct {
+	Loc    *time.Location
+	Layout string
+}
+
+func New(format string) Formatter {
+	if strings.EqualFold(format, RFC3339) {
+		return Formatter{Loc: facility, Layout: time.RFC3339}
+	}
+	return Formatter{Loc: facility, Layout: LegacyLayout}
+}
+
+func (f Formatter) Format(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.In(f.Loc).Format(f.Layout)
+}
+
+// FromRequest picks the response format from the time_format query parameter,
+// falling back to a time-format parameter on the Accept header, e.g.
+// "Accept: application/json; time-format=rfc3339". The default is legacy.
+func FromRequest(r *http.Request) Formatter {
+	if f := r.URL.Query().Get("time_format"); f != "" {
+		return New(f)
+	}
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		_, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		if f, ok := params["time-format"]; ok {
+			return New(f)
+		}
+	}
+	return New(Legacy)
+}
+
+// MigrateColumns converts TIMESTAMP columns to TIMESTAMPTZ. Existing values
+// were written as server wall-clock time, so they are interpreted in the
+// facility zone. Columns that are already TIMESTAMPTZ are left alone.
+func MigrateColumns(db *sql.DB, schema, table string, cols ...string) error {
+	for _, col := range cols {
+		var dataType string
+		qr := `select data_type from information_schema.columns where table_schema = $1 and table_name = $2 and column_name = $3;`
+		err := db.QueryRow(qr, schema, table, col).Scan(&dataType)
+		if err != nil {
+			return err
+		}
+		if dataType != "timestamp without time zone" {
+			continue
+		}
+		zone, err := zoneSQL(facility)
+		if err != nil {
+			return err
+		}
+		alter := fmt.Sprintf(`ALTER TABLE %s.%s ALTER COLUMN %s TYPE TIMESTAMPTZ USING %s AT TIME ZONE %s;`,
+			pq.QuoteIdentifier(schema), pq.QuoteIdentifier(table), pq.QuoteIdentifier(col), pq.QuoteIdentifier(col), zone)
+		if _, err = db.Exec(alter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// zoneSQL names loc for AT TIME ZONE. It must be a named IANA zone: a fixed
+// offset would shift the rows from the other side of a DST change.
+func zoneSQL(loc *time.Location) (string, error) {
+	if loc == time.Local || loc.String() == "Local" {
+		return "", fmt.Errorf("converting timestamps needs a named time zone, set PDEA_TIMEZONE or TZ")
+	}
+	return pq.QuoteLiteral(loc.String()), nil
+}
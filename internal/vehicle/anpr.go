@@ -0,0 +1,263 @@
+package vehicle
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"PDEA/internal/anpr"
+	"PDEA/internal/audit"
+	"PDEA/internal/gate"
+	"PDEA/internal/logging"
+	"PDEA/internal/metrics"
+	"PDEA/internal/parking"
+	"PDEA/internal/timefmt"
+)
+
+// recentExit is how long after an exit further reads of the plate at an
+// exit lane are taken as the camera seeing the same vehicle again.
+const recentExit = 2 * time.Minute
+
+// spotTries bounds the spots tried for a read's entry when other gates
+// take the first ones picked.
+const spotTries = 3
+
+var plateReads = metrics.NewCounterVec("pdea_plate_reads_total", "Camera plate reads by outcome (applied, duplicate or review).", "outcome")
+
+// allSpots returns every spot, from the spot service or the cache.
+func allSpots(ctx context.Context) ([]parking.ParkingSpot, error) {
+	if spots != nil {
+		all, err := spots.All(ctx)
+		if err != nil {
+			return nil, fromSpotService(err)
+		}
+		return all, nil
+	}
+	return cache.All(), nil
+}
+
+// pickSpots returns the spots a vehicle a camera saw arrive may be sent to,
+// best first: the spot held for it on the waitlist, then the free spots
+// that are not held for anyone else. Accessible and EV-only spots are left
+// out, since a camera cannot tell whether the vehicle qualifies.
+func pickSpots(ctx context.Context, plate string) ([]string, error) {
+	holds, err := waitlists.Holds()
+	if err != nil {
+		return nil, err
+	}
+	var res []string
+	for spotNumber, holder := range holds {
+		if anpr.Normalize(holder) == plate {
+			res = append(res, spotNumber)
+		}
+	}
+	all, err := allSpots(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range all {
+		if _, held := holds[p.SpotNumber]; p.Available() && !held && !p.Accessible && !p.EVOnly {
+			res = append(res, p.SpotNumber)
+		}
+	}
+	return res, nil
+}
+
+// applyRead turns a read into an entry or exit at its gate. plate is what
+// to act on, the read itself or an attendant's correction; spotNumber, for
+// entries, replaces the spot that would be picked and override is passed
+// on to exits. exact limits exits to stays whose plate is the one read.
+func applyRead(ctx context.Context, rd *anpr.Read, plate, spotNumber, override string, exact bool) error {
+	if rd.Direction == gate.Entry {
+		return enterFromRead(ctx, rd, anpr.Normalize(plate), spotNumber)
+	}
+	return exitFromRead(ctx, rd, plate, override, exact)
+}
+
+func enterFromRead(ctx context.Context, rd *anpr.Read, plate, spotNumber string) error {
+	candidates := []string{spotNumber}
+	if spotNumber == "" {
+		var err error
+		if candidates, err = pickSpots(ctx, plate); err != nil {
+			return err
+		}
+		if len(candidates) == 0 {
+			return errLotFull
+		}
+		candidates = candidates[:min(len(candidates), spotTries)]
+	}
+	var err error
+	for _, sn := range candidates {
+		var v parking.Vehichle
+		v, err = registerEntry(ctx, parking.Vehichle{SpotNumber: sn, License_plate: plate, GateID: rd.GateID})
+		if err == nil {
+			rd.StayID, rd.MatchedPlate, rd.Gate = v.ID, plate, v.Gate
+			return nil
+		}
+		if err != errSpotTaken && err != errSpotHeld {
+			return err
+		}
+	}
+	return err
+}
+
+// exitFromRead ends the open stay whose plate the read matches. A match
+// allowing for OCR errors is only acted on when exact is false, that is once
+// an attendant has looked at the read; until then it gives errFuzzyMatch.
+func exitFromRead(ctx context.Context, rd *anpr.Read, plate, override string, exact bool) error {
+	vDatas, err := openStays()
+	if err != nil {
+		return err
+	}
+	open := make([]string, len(vDatas))
+	for i, d := range vDatas {
+		open[i] = d.License_plate
+	}
+	matched, err := anpr.Match(plate, open)
+	if err == anpr.ErrNoMatch {
+		left, lerr := exitedSince(timefmt.Now().Add(-recentExit))
+		if lerr != nil {
+			return lerr
+		}
+		plates := make([]string, len(left))
+		for i, d := range left {
+			plates[i] = d.License_plate
+		}
+		if _, lerr := anpr.Match(plate, plates); lerr == nil {
+			return errJustLeft
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if exact && anpr.Normalize(matched) != anpr.Normalize(plate) {
+		return fmt.Errorf("%w %s", errFuzzyMatch, matched)
+	}
+	var v parking.Vehichle
+	for _, d := range vDatas {
+		if d.License_plate == matched {
+			v = d
+			break
+		}
+	}
+	Sp, err := findSpot(ctx, v.SpotNumber)
+	if err != nil {
+		return err
+	}
+	if v, err = finishExit(ctx, Sp, v, "plate-read-exit", nil, override, rd.GateID); err != nil {
+		return err
+	}
+	rd.StayID, rd.MatchedPlate, rd.Gate = v.ID, matched, v.Gate
+	return nil
+}
+
+// ingestRead stores a camera's read and acts on it if the camera is
+// confident enough. Other reads, and those that could not be acted on, are
+// queued for review with the reason.
+func ingestRead(ctx context.Context, rd anpr.Read) (anpr.Read, error) {
+	if err := rd.Valid(); err != nil {
+		return rd, err
+	}
+	g, err := gates.ForLane(rd.Lane)
+	if err == gate.ErrNotFound {
+		return rd, errUnknownLane
+	}
+	if err != nil {
+		return rd, err
+	}
+	rd.GateID, rd.Direction = g.ID, g.Direction
+	if rd.ReadAt.IsZero() {
+		rd.ReadAt = timefmt.Now()
+	}
+	rd.Status = anpr.StatusApplied
+	if !reads.Confident(rd) {
+		rd.Status = anpr.StatusReview
+		rd.Reason = fmt.Sprintf("confidence %.2f is below %.2f", rd.Confidence, reads.MinConfidence)
+	} else if err = applyRead(ctx, &rd, rd.Plate, "", "", true); err != nil {
+		if err == errAlreadyPresent || err == errJustLeft || err == errStayClosed {
+			rd.Status = anpr.StatusDuplicate
+		} else {
+			logging.FromContext(ctx).Warn("plate read queued for review", "plate", rd.Plate, "lane", rd.Lane, "error", err)
+			rd.Status, rd.Reason = anpr.StatusReview, err.Error()
+		}
+	}
+	plateReads.Inc(rd.Status)
+	err = reads.Save(&rd)
+	return rd, err
+}
+
+// resolveRead acts on a read waiting for review with an attendant's
+// corrections. An empty plate keeps what the camera read.
+func resolveRead(ctx context.Context, id int, plate, spotNumber, override string) (anpr.Read, error) {
+	before, err := reads.Get(id)
+	if err != nil {
+		return before, err
+	}
+	if before.Status != anpr.StatusReview {
+		return before, anpr.ErrReviewed
+	}
+	if plate == "" {
+		plate = before.Plate
+	}
+	rd := before
+	if err = applyRead(ctx, &rd, plate, spotNumber, override, false); err != nil {
+		return before, err
+	}
+	rd.Status, rd.ReviewedBy = anpr.StatusResolved, audit.ActorFromContext(ctx)
+	if err = reads.Close(rd); err != nil {
+		return rd, err
+	}
+	auditLog.RecordContext(ctx, "resolve", "plate_read", strconv.Itoa(rd.ID), before, rd)
+	return rd, nil
+}
+
+// IngestPlateRead takes a camera's {"plate": "KA01AB1234", "confidence":
+// 0.97, "lane": "north-in", "image_ref": "..."} and answers with the read
+// and what was done with it.
+func IngestPlateRead(w http.ResponseWriter, r *http.Request) {
+	var reqBody anpr.Read
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		http.Error(w, "Invalid request Data", http.StatusBadRequest)
+		return
+	}
+	in := anpr.Read{Plate: reqBody.Plate, Confidence: reqBody.Confidence, Lane: reqBody.Lane, ImageRef: reqBody.ImageRef, ReadAt: reqBody.ReadAt}
+	rd, err := ingestRead(audit.ContextFromRequest(r), in)
+	if err != nil {
+		httpError(w, r, err)
+		return
+	}
+	resJson, _ := json.Marshal(rd)
+	w.WriteHeader(http.StatusCreated)
+	w.Write(resJson)
+}
+
+// ResolvePlateRead takes an optional corrected {"plate": "..."}, an entry's
+// "spot_number" and an exit's "payment_override".
+func ResolvePlateRead(w http.ResponseWriter, r *http.Request) {
+	id, ok := anpr.ReadID(w, r)
+	if !ok {
+		return
+	}
+	var reqBody struct {
+		Plate           string `json:"plate"`
+		SpotNumber      string `json:"spot_number"`
+		PaymentOverride string `json:"payment_override"`
+	}
+	// the body is optional when the read needs no correction
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil && err != io.EOF {
+		http.Error(w, "Invalid request Data", http.StatusBadRequest)
+		return
+	}
+	rd, err := resolveRead(audit.ContextFromRequest(r), id, reqBody.Plate, reqBody.SpotNumber, reqBody.PaymentOverride)
+	if err != nil {
+		httpError(w, r, err)
+		return
+	}
+	resJson, _ := json.Marshal(rd)
+	w.WriteHeader(http.StatusOK)
+	w.Write(resJson)
+}
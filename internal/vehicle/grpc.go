@@ -0,0 +1,184 @@
+package vehicle
+
+import (
+	"context"
+	"errors"
+
+	"PDEA/internal/auth"
+	"PDEA/internal/grpcapi"
+	"PDEA/internal/grpcapi/pdeapb"
+	"PDEA/internal/logging"
+	"PDEA/internal/parking"
+	"PDEA/internal/ratelimit"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const grpcAddr = ":9081"
+
+// grpcPolicy mirrors routePolicy for the same operations.
+var grpcPolicy = auth.Policy{
+	"GRPC /pdea.v1.Vehicles/RegisterEntry":          {auth.RoleGate, auth.RoleAttendant},
+	"GRPC /pdea.v1.Vehicles/RegisterExit":           {auth.RoleGate, auth.RoleAttendant},
+	"GRPC /pdea.v1.Vehicles/RegisterTicketExit":     {auth.RoleGate, auth.RoleAttendant},
+	"GRPC /pdea.v1.Vehicles/RegisterLostTicketExit": {auth.RoleAttendant},
+	"GRPC /pdea.v1.Vehicles/ListRecords":            {auth.RoleAttendant, auth.RoleAnalyst},
+}
+
+// grpcLimits mirrors routeLimits for the same operations.
+var grpcLimits = map[string]ratelimit.Rule{
+	"GRPC /pdea.v1.Vehicles/RegisterEntry":          {Rate: 2, Burst: 5},
+	"GRPC /pdea.v1.Vehicles/RegisterExit":           {Rate: 2, Burst: 5},
+	"GRPC /pdea.v1.Vehicles/RegisterTicketExit":     {Rate: 2, Burst: 5},
+	"GRPC /pdea.v1.Vehicles/RegisterLostTicketExit": {Rate: 1, Burst: 5},
+}
+
+type grpcServer struct {
+	pdeapb.UnimplementedVehiclesServer
+}
+
+// registerGRPC creates the service's gRPC server and registers the Vehicles
+// service on it.
+func registerGRPC() error {
+	srv, err := svc.NewGRPC(grpcAddr, grpcPolicy, grpcLimits)
+	if err != nil {
+		return err
+	}
+	pdeapb.RegisterVehiclesServer(srv, grpcServer{})
+	return nil
+}
+
+func toProto(v parking.Vehichle) *pdeapb.VehicleRecord {
+	return &pdeapb.VehicleRecord{
+		Id:              int32(v.ID),
+		SpotNumber:      v.SpotNumber,
+		LicensePlate:    v.License_plate,
+		EntryTime:       grpcapi.Timestamp(v.EntryTime),
+		ExitTime:        grpcapi.Timestamp(v.ExitTime),
+		HourlyCents:     cents(v.HourlyCents),
+		FeeCents:        cents(v.FeeCents),
+		LostTicketCents: cents(v.LostTicketCents),
+		TicketId:        v.TicketID,
+		TicketToken:     v.TicketToken,
+		PaymentOverride: v.PaymentOverride,
+		DiscountCents:   cents(v.DiscountCents),
+		Discounts:       discountsToProto(v.Discounts),
+		Ev:              v.EV,
+		EnergyCents:     cents(v.EnergyCents),
+		PermitNumber:    v.PermitNumber,
+		PermitWarning:   v.PermitWarning,
+		Gate:            gateToProto(v.Gate),
+	}
+}
+
+func gateToProto(g *parking.GateResult) *pdeapb.GateResult {
+	if g == nil {
+		return nil
+	}
+	return &pdeapb.GateResult{GateId: g.GateID, Status: g.Status, Error: g.Error}
+}
+
+func discountsToProto(ds []parking.AppliedDiscount) []*pdeapb.AppliedDiscount {
+	var res []*pdeapb.AppliedDiscount
+	for _, d := range ds {
+		res = append(res, &pdeapb.AppliedDiscount{Code: d.Code, Kind: d.Kind, Value: int64(d.Value), Cents: int64(d.Cents)})
+	}
+	return res
+}
+
+func cents(v *int) *int64 {
+	if v == nil {
+		return nil
+	}
+	n := int64(*v)
+	return &n
+}
+
+func grpcError(ctx context.Context, err error) error {
+	switch {
+	case err == errSpotNotFound, err == errStayNotFound, err == errGateNotFound:
+		return status.Error(codes.NotFound, err.Error())
+	case err == errBadTicket, err == errWrongGate:
+		return status.Error(codes.InvalidArgument, err.Error())
+	case err == errSpotTaken, err == errSpotHeld, err == errEVOnly, errors.Is(err, errNoPermit), errors.Is(err, errUnpaid):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case err == errNoOverride:
+		return status.Error(codes.PermissionDenied, err.Error())
+	case err == errAlreadyPresent:
+		return status.Error(codes.AlreadyExists, err.Error())
+	case err == errStayClosed:
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, errSpotService):
+		logging.FromContext(ctx).Error("spot service error", "error", err)
+		return status.Error(codes.Unavailable, errSpotService.Error())
+	}
+	logging.FromContext(ctx).Error("grpc call failed", "error", err)
+	return status.Error(codes.Internal, "server error")
+}
+
+func stayArgs(req *pdeapb.StayRequest) error {
+	if req.GetSpotNumber() == "" || req.GetLicensePlate() == "" {
+		return status.Error(codes.InvalidArgument, "spot_number and license_plate are required")
+	}
+	return nil
+}
+
+func (grpcServer) RegisterEntry(ctx context.Context, req *pdeapb.StayRequest) (*pdeapb.VehicleRecord, error) {
+	if err := stayArgs(req); err != nil {
+		return nil, err
+	}
+	v, err := registerEntry(ctx, parking.Vehichle{SpotNumber: req.GetSpotNumber(), License_plate: req.GetLicensePlate(), EV: req.GetEv(), PermitNumber: req.GetPermitNumber(), GateID: req.GetGateId()})
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	return toProto(v), nil
+}
+
+func (grpcServer) RegisterExit(ctx context.Context, req *pdeapb.StayRequest) (*pdeapb.VehicleRecord, error) {
+	if err := stayArgs(req); err != nil {
+		return nil, err
+	}
+	v, err := registerExit(ctx, req.GetSpotNumber(), req.GetLicensePlate(), req.GetPaymentOverride(), req.GetGateId())
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	return toProto(v), nil
+}
+
+func (grpcServer) RegisterTicketExit(ctx context.Context, req *pdeapb.TicketExitRequest) (*pdeapb.VehicleRecord, error) {
+	if req.GetTicketToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, "ticket_token is required")
+	}
+	v, err := registerTicketExit(ctx, req.GetTicketToken(), req.GetPaymentOverride(), req.GetGateId())
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	return toProto(v), nil
+}
+
+func (grpcServer) RegisterLostTicketExit(ctx context.Context, req *pdeapb.LostTicketExitRequest) (*pdeapb.VehicleRecord, error) {
+	if req.GetLicensePlate() == "" {
+		return nil, status.Error(codes.InvalidArgument, "license_plate is required")
+	}
+	v, err := registerLostTicketExit(ctx, req.GetLicensePlate(), req.GetPaymentOverride(), req.GetGateId())
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	return toProto(v), nil
+}
+
+func (grpcServer) ListRecords(ctx context.Context, req *pdeapb.ListRecordsRequest) (*pdeapb.ListRecordsResponse, error) {
+	if (req.GetSpotNumber() == "") == (req.GetLicensePlate() == "") {
+		return nil, status.Error(codes.InvalidArgument, "exactly one of spot_number and license_plate is required")
+	}
+	vDatas, err := findRecords(req.GetSpotNumber(), req.GetLicensePlate())
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	res := &pdeapb.ListRecordsResponse{}
+	for _, v := range vDatas {
+		res.Records = append(res.Records, toProto(v))
+	}
+	return res, nil
+}
@@ -0,0 +1,119 @@
+package vehicle
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"PDEA/internal/grpcapi/grpctest"
+	"PDEA/internal/grpcapi/pdeapb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// dial starts the vehicle service against PDEA_TEST_DATABASE_URL with auth
+// off and spots kept in parking_rec, and connects to its gRPC server.
+func dial(t *testing.T) *grpc.ClientConn {
+	t.Helper()
+	grpctest.Env(t)
+	t.Setenv("PDEA_SPOT_MODE", "sql")
+	s, err := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.DB.Close() })
+	return grpctest.Dial(t, s.GRPC)
+}
+
+// addSpot puts a free spot in parking_rec and the cache.
+func addSpot(t *testing.T, zone string) string {
+	t.Helper()
+	number := grpctest.Unique("G")
+	if _, err := db.Exec(`INSERT INTO parking_rec (spot_number, type, is_available, zone) VALUES ($1, 'compact', true, $2);`, number, zone); err != nil {
+		t.Fatal(err)
+	}
+	cache.Refresh(number)
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM parking_rec WHERE spot_number = $1;`, number)
+	})
+	return number
+}
+
+func TestGRPC(t *testing.T) {
+	vehicles := pdeapb.NewVehiclesClient(dial(t))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	zone := grpctest.Unique("zone-")
+	spotA, spotB := addSpot(t, zone), addSpot(t, zone)
+	plate, other := grpctest.Unique("P"), grpctest.Unique("Q")
+
+	entry, err := vehicles.RegisterEntry(ctx, &pdeapb.StayRequest{SpotNumber: spotA, LicensePlate: plate})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.GetId() == 0 || entry.GetSpotNumber() != spotA || entry.GetLicensePlate() != plate || entry.GetEntryTime() == nil || entry.GetExitTime() != nil {
+		t.Errorf("RegisterEntry = %+v, want an open stay of %s at %s", entry, plate, spotA)
+	}
+	if entry.GetTicketId() == "" || entry.GetTicketToken() == "" {
+		t.Errorf("RegisterEntry = %+v, want a ticket", entry)
+	}
+
+	tests := []struct {
+		name string
+		req  *pdeapb.StayRequest
+		code codes.Code
+	}{
+		{"plate already parked", &pdeapb.StayRequest{SpotNumber: spotB, LicensePlate: plate}, codes.AlreadyExists},
+		{"spot taken", &pdeapb.StayRequest{SpotNumber: spotA, LicensePlate: other}, codes.FailedPrecondition},
+		{"unknown spot", &pdeapb.StayRequest{SpotNumber: grpctest.Unique("X"), LicensePlate: other}, codes.NotFound},
+		{"no plate", &pdeapb.StayRequest{SpotNumber: spotB}, codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run("entry "+tt.name, func(t *testing.T) {
+			if _, err := vehicles.RegisterEntry(ctx, tt.req); status.Code(err) != tt.code {
+				t.Errorf("RegisterEntry(%+v) = %v, want %v", tt.req, err, tt.code)
+			}
+		})
+	}
+
+	list, err := vehicles.ListRecords(ctx, &pdeapb.ListRecordsRequest{LicensePlate: plate})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.GetRecords()) != 1 || list.GetRecords()[0].GetId() != entry.GetId() {
+		t.Fatalf("ListRecords by plate = %+v, want stay %d", list.GetRecords(), entry.GetId())
+	}
+
+	// auth is off, so the override is accepted whatever the fee
+	exit, err := vehicles.RegisterExit(ctx, &pdeapb.StayRequest{SpotNumber: spotA, LicensePlate: plate, PaymentOverride: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exit.GetId() != entry.GetId() || exit.GetExitTime() == nil {
+		t.Errorf("RegisterExit = %+v, want stay %d closed", exit, entry.GetId())
+	}
+	if _, err := vehicles.RegisterExit(ctx, &pdeapb.StayRequest{SpotNumber: spotA, LicensePlate: plate, PaymentOverride: "test"}); status.Code(err) != codes.NotFound {
+		t.Errorf("second RegisterExit = %v, want NotFound", err)
+	}
+
+	list, err = vehicles.ListRecords(ctx, &pdeapb.ListRecordsRequest{SpotNumber: spotA})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.GetRecords()) != 1 || list.GetRecords()[0].GetExitTime() == nil {
+		t.Errorf("ListRecords by spot = %+v, want the closed stay", list.GetRecords())
+	}
+	if _, err := vehicles.ListRecords(ctx, &pdeapb.ListRecordsRequest{SpotNumber: spotA, LicensePlate: plate}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("ListRecords by spot and plate = %v, want InvalidArgument", err)
+	}
+
+	// the spot is free again
+	if _, err := vehicles.RegisterEntry(ctx, &pdeapb.StayRequest{SpotNumber: spotA, LicensePlate: other}); err != nil {
+		t.Errorf("RegisterEntry after the exit: %v", err)
+	}
+	vehicles.RegisterExit(ctx, &pdeapb.StayRequest{SpotNumber: spotA, LicensePlate: other, PaymentOverride: "test"})
+}
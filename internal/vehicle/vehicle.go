@@ -0,0 +1,1438 @@
+// Package vehicle serves vehicle entries, exits and stay records on :8081.
+package vehicle
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"PDEA/internal/anpr"
+	"PDEA/internal/audit"
+	"PDEA/internal/auth"
+	"PDEA/internal/charging"
+	"PDEA/internal/discount"
+	"PDEA/internal/events"
+	"PDEA/internal/gate"
+	"PDEA/internal/logging"
+	"PDEA/internal/metrics"
+	"PDEA/internal/parking"
+	"PDEA/internal/payment"
+	"PDEA/internal/permit"
+	"PDEA/internal/platform"
+	"PDEA/internal/pricing"
+	"PDEA/internal/ratelimit"
+	"PDEA/internal/spotcache"
+	"PDEA/internal/spotclient"
+	"PDEA/internal/ticket"
+	"PDEA/internal/timefmt"
+	"PDEA/internal/waitlist"
+
+	"github.com/gorilla/mux"
+)
+
+var (
+	svc      *platform.Service
+	db       *sql.DB
+	auditLog *audit.Logger
+	// spots is set when PDEA_SPOT_MODE=api; spot lookups and availability
+	// changes then go through the spot service instead of parking_rec.
+	spots *spotclient.Client
+	// cache serves spot lookups from parking_rec otherwise.
+	cache  *spotcache.Cache
+	pricer *pricing.Engine
+	// tickets signs the ticket issued at entry; lostTicketCents is added to
+	// the fee of an exit without one.
+	tickets         *ticket.Signer
+	lostTicketCents int
+	// payments holds what has been paid towards each stay; exits are
+	// refused until the fee is covered.
+	payments *payment.Service
+	// discounts holds the codes redeemed against stays.
+	discounts *discount.Service
+	chargers  *charging.Service
+	permits   *permit.Registry
+	// waitlists queues drivers for a type while it is full and holds freed
+	// spots for them.
+	waitlists *waitlist.Service
+	// gates opens the barrier a vehicle is at once its entry or exit is
+	// recorded.
+	gates *gate.Controller
+	// reads holds the lane cameras' plate reads and the review queue.
+	reads *anpr.Service
+	// warnOnPermit lets vehicles without a valid permit into accessible
+	// spots, flagging the stay instead of refusing the entry.
+	warnOnPermit bool
+
+	vehicleEntries = metrics.NewCounterVec("pdea_vehicle_entries_total", "Registered vehicle entries by spot type.", "type")
+	vehicleExits   = metrics.NewCounterVec("pdea_vehicle_exits_total", "Registered vehicle exits by spot type.", "type")
+	permitMisses   = metrics.NewCounterVec("pdea_accessible_permit_failures_total", "Entries to accessible spots without a valid permit, by outcome (rejected or warned).", "outcome")
+)
+
+var routeLimits = map[string]ratelimit.Rule{
+	"POST /api/vehicle-entries":           {Rate: 2, Burst: 5},
+	"POST /api/vehicle-exits":             {Rate: 2, Burst: 5},
+	"POST /api/vehicle-exits/ticket":      {Rate: 2, Burst: 5},
+	"POST /api/vehicle-exits/lost-ticket": {Rate: 1, Burst: 5},
+	"POST /api/payments":                  {Rate: 2, Burst: 5},
+	"POST /api/discount-redemptions":      {Rate: 2, Burst: 5},
+	"POST /api/charging-sessions":         {Rate: 2, Burst: 5},
+	"POST /api/waitlist":                  {Rate: 1, Burst: 5},
+	"POST /api/plate-reads":               {Rate: 5, Burst: 20},
+}
+
+// schemaVersion is bumped whenever migrate gains a migration; readiness
+// fails if the database reports an older version.
+const schemaVersion = 12
+
+// Admins may call every route; anything not listed here or in
+// platform.CommonPolicy is admin only.
+var routePolicy = auth.Policy{
+	"POST /api/vehicle-entries":                  {auth.RoleGate, auth.RoleAttendant},
+	"POST /api/vehicle-exits":                    {auth.RoleGate, auth.RoleAttendant},
+	"POST /api/vehicle-exits/ticket":             {auth.RoleGate, auth.RoleAttendant},
+	"POST /api/vehicle-exits/lost-ticket":        {auth.RoleAttendant},
+	"GET /api/vehicle-records/{spot_no}":         {auth.RoleAttendant, auth.RoleAnalyst},
+	"GET /api/vehicle-records":                   {auth.RoleAttendant, auth.RoleAnalyst},
+	"GET /api/pricing/rates":                     {auth.RoleAttendant, auth.RoleAnalyst},
+	"GET /api/pricing/rules":                     {auth.RoleAttendant, auth.RoleAnalyst},
+	"GET /api/pricing/decisions":                 {auth.RoleAttendant, auth.RoleAnalyst},
+	"POST /api/payments":                         {auth.RoleGate, auth.RoleAttendant},
+	"POST /api/payments/{id}/authorize":          {auth.RoleGate, auth.RoleAttendant},
+	"POST /api/payments/{id}/capture":            {auth.RoleGate, auth.RoleAttendant},
+	"GET /api/payments":                          {auth.RoleAttendant, auth.RoleAnalyst},
+	"GET /api/payments/{id}":                     {auth.RoleGate, auth.RoleAttendant, auth.RoleAnalyst},
+	"POST /api/discount-redemptions":             {auth.RoleGate, auth.RoleAttendant, auth.RoleMerchant},
+	"GET /api/discount-codes":                    {auth.RoleAttendant, auth.RoleAnalyst},
+	"GET /api/discount-codes/{code}":             {auth.RoleAttendant, auth.RoleAnalyst},
+	"GET /api/discount-codes/{code}/redemptions": {auth.RoleAttendant, auth.RoleAnalyst},
+	"POST /api/charging-sessions":                {auth.RoleGate, auth.RoleAttendant},
+	"POST /api/charging-sessions/{id}/stop":      {auth.RoleGate, auth.RoleAttendant},
+	"POST /api/charging-sessions/{id}/meter":     {auth.RoleGate, auth.RoleAttendant},
+	"GET /api/charging-sessions":                 {auth.RoleAttendant, auth.RoleAnalyst},
+	"GET /api/charging-sessions/{id}":            {auth.RoleGate, auth.RoleAttendant, auth.RoleAnalyst},
+	"GET /api/accessibility-permits":             {auth.RoleAttendant},
+	"GET /api/accessibility-permits/{number}":    {auth.RoleGate, auth.RoleAttendant},
+	"POST /api/waitlist":                         {auth.RoleGate, auth.RoleAttendant},
+	"GET /api/waitlist":                          {auth.RoleAttendant, auth.RoleAnalyst},
+	"GET /api/waitlist/{id}":                     {auth.RoleGate, auth.RoleAttendant},
+	"DELETE /api/waitlist/{id}":                  {auth.RoleGate, auth.RoleAttendant},
+	"GET /api/gates":                             {auth.RoleAttendant, auth.RoleAnalyst},
+	"GET /api/gates/{id}":                        {auth.RoleGate, auth.RoleAttendant, auth.RoleAnalyst},
+	"GET /api/gates/{id}/commands":               {auth.RoleAttendant, auth.RoleAnalyst},
+	"POST /api/gates/{id}/open":                  {auth.RoleAttendant},
+	"POST /api/plate-reads":                      {auth.RoleGate, auth.RoleAttendant},
+	"GET /api/plate-reads":                       {auth.RoleAttendant, auth.RoleAnalyst},
+	"GET /api/plate-reads/{id}":                  {auth.RoleAttendant, auth.RoleAnalyst},
+	"POST /api/plate-reads/{id}/resolve":         {auth.RoleAttendant},
+	"POST /api/plate-reads/{id}/dismiss":         {auth.RoleAttendant},
+}
+
+// New sets up the vehicle service on :8081: the shared platform pieces, the
+// vehicle_records and parking_rec schema, the parking_rec cache, metrics and
+// routes. With PDEA_SPOT_MODE=api, spots are checked and reserved through
+// the spot service instead of parking_rec. The same operations are served
+// over gRPC on :9081.
+//
+// Tickets are signed with PDEA_TICKET_SECRET and a lost ticket costs
+// PDEA_LOST_TICKET_CENTS on top of the stay's fee. Payments go through the
+// provider named by PDEA_PAYMENT_PROVIDER, and a stay paid for may leave
+// within PDEA_PAYMENT_GRACE of the capture without owing more. Charging is
+// billed at PDEA_ENERGY_CENTS_PER_KWH. PDEA_ACCESSIBLE_MODE=warn admits
+// vehicles without a valid permit to accessible spots instead of rejecting
+// them. Waitlist holds last PDEA_WAITLIST_HOLD and drivers are notified as
+// PDEA_WAITLIST_NOTIFY says. Gates are driven through PDEA_GATE_ADAPTER and
+// given PDEA_GATE_TIMEOUT to acknowledge. Camera plate reads below
+// PDEA_ANPR_MIN_CONFIDENCE are queued for review.
+func New(logger *slog.Logger) (*platform.Service, error) {
+	switch mode := os.Getenv("PDEA_SPOT_MODE"); mode {
+	case "", "sql":
+	case "api":
+		var err error
+		spots, err = spotclient.NewFromEnv()
+		if err != nil {
+			return nil, fmt.Errorf("invalid spot client config: %w", err)
+		}
+		logger.Info("using spot service", "url", spots.BaseURL)
+	default:
+		return nil, fmt.Errorf("invalid PDEA_SPOT_MODE %q, want sql or api", mode)
+	}
+	var err error
+	if tickets, err = ticket.NewFromEnv(); err != nil {
+		return nil, fmt.Errorf("setting up ticket signing: %w", err)
+	}
+	if tickets.Ephemeral {
+		logger.Warn("PDEA_TICKET_SECRET is not set, tickets will not verify on other replicas or after a restart")
+	}
+	switch mode := os.Getenv("PDEA_ACCESSIBLE_MODE"); mode {
+	case "", "reject":
+		warnOnPermit = false
+	case "warn":
+		warnOnPermit = true
+	default:
+		return nil, fmt.Errorf("invalid PDEA_ACCESSIBLE_MODE %q, want reject or warn", mode)
+	}
+	lostTicketCents = 0
+	if v := os.Getenv("PDEA_LOST_TICKET_CENTS"); v != "" {
+		if lostTicketCents, err = strconv.Atoi(v); err != nil || lostTicketCents < 0 {
+			return nil, fmt.Errorf("invalid PDEA_LOST_TICKET_CENTS %q", v)
+		}
+	}
+	svc, err = platform.New("vehicle", ":8081", logger)
+	if err != nil {
+		return nil, err
+	}
+	db = svc.DB
+	auditLog = svc.Audit
+	pricer = pricing.New(db)
+	pricer.Audit = auditLog
+	if payments, err = payment.NewFromEnv(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("setting up payments: %w", err)
+	}
+	payments.Audit = auditLog
+	discounts = discount.New(db)
+	discounts.Audit = auditLog
+	if chargers, err = charging.NewFromEnv(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	chargers.Audit = auditLog
+	permits = permit.New(db)
+	permits.Audit = auditLog
+	if waitlists, err = waitlist.NewFromEnv(db, logger); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("setting up waitlist: %w", err)
+	}
+	waitlists.Audit = auditLog
+	if gates, err = gate.NewFromEnv(db, logger); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("setting up gates: %w", err)
+	}
+	gates.Audit = auditLog
+	if reads, err = anpr.NewFromEnv(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	reads.Audit = auditLog
+	if err = migrate(); err == nil {
+		err = svc.Ready(schemaVersion)
+	}
+	if err == nil && spots == nil {
+		cache = spotcache.New(db, platform.DSN(), "parking_rec", "0")
+		cache.Log = logger
+		err = cache.Load()
+	}
+	if err == nil {
+		if spots != nil {
+			svc.Checker.Add("spot-service", false, spots.Check)
+		} else {
+			svc.Workers = append(svc.Workers, cache.Run)
+			svc.Checker.Add("spot-cache", false, cache.Check)
+		}
+		svc.Workers = append(svc.Workers, waitlists.Run)
+		svc.Checker.Add("waitlist", false, waitlists.Check)
+		svc.Checker.Add("gates", false, gates.Check)
+		registerMetrics()
+		svc.Handler, err = registerRoutes()
+	}
+	if err == nil {
+		err = registerGRPC()
+	}
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return svc, nil
+}
+
+func migrate() error {
+	// Define the SQL to create a schema
+	schemaSQL := `CREATE TABLE IF NOT EXISTS vehicle_records (
+id SERIAL PRIMARY KEY,
+spot_number TEXT NOT NULL,
+license_plate TEXT NOT NULL,
+entry_time TIMESTAMPTZ NOT NULL,
+exit_time TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS parking_rec (
+spot_number TEXT PRIMARY KEY,
+type TEXT NOT NULL,
+is_available  BOOLEAN NOT NULL
+);
+
+ALTER TABLE parking_rec ADD COLUMN IF NOT EXISTS zone TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE vehicle_records ADD COLUMN IF NOT EXISTS hourly_cents INTEGER;
+ALTER TABLE vehicle_records ADD COLUMN IF NOT EXISTS fee_cents INTEGER;
+
+ALTER TABLE vehicle_records ADD COLUMN IF NOT EXISTS ticket_id TEXT;
+ALTER TABLE vehicle_records ADD COLUMN IF NOT EXISTS lost_ticket_cents INTEGER;
+CREATE UNIQUE INDEX IF NOT EXISTS vehicle_records_ticket ON vehicle_records (ticket_id);
+
+ALTER TABLE vehicle_records ADD COLUMN IF NOT EXISTS payment_override TEXT;
+
+ALTER TABLE vehicle_records ADD COLUMN IF NOT EXISTS discount_cents INTEGER;
+
+ALTER TABLE parking_rec ADD COLUMN IF NOT EXISTS connector TEXT NOT NULL DEFAULT '';
+ALTER TABLE parking_rec ADD COLUMN IF NOT EXISTS max_kw DOUBLE PRECISION NOT NULL DEFAULT 0;
+ALTER TABLE parking_rec ADD COLUMN IF NOT EXISTS ev_only BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE vehicle_records ADD COLUMN IF NOT EXISTS ev BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE vehicle_records ADD COLUMN IF NOT EXISTS energy_cents INTEGER;
+
+ALTER TABLE parking_rec ADD COLUMN IF NOT EXISTS accessible BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE vehicle_records ADD COLUMN IF NOT EXISTS permit_number TEXT;
+ALTER TABLE vehicle_records ADD COLUMN IF NOT EXISTS permit_warning TEXT;
+
+CREATE INDEX IF NOT EXISTS vehicle_records_open ON vehicle_records (license_plate) WHERE exit_time IS NULL;
+CREATE INDEX IF NOT EXISTS vehicle_records_exit ON vehicle_records (exit_time);
+CREATE INDEX IF NOT EXISTS vehicle_records_plate ON vehicle_records (license_plate);
+CREATE INDEX IF NOT EXISTS vehicle_records_spot ON vehicle_records (spot_number);
+
+SELECT setval('vehicle_records_id_seq', greatest(max(id), (SELECT last_value FROM vehicle_records_id_seq), 1)) FROM vehicle_records;
+`
+	// Execute the SQL statement
+	_, err := db.Exec(schemaSQL)
+	if err != nil {
+		return fmt.Errorf("creating schema: %w", err)
+	}
+
+	err = timefmt.MigrateColumns(db, "public", "vehicle_records", "entry_time", "exit_time")
+	if err != nil {
+		return fmt.Errorf("migrating timestamps: %w", err)
+	}
+	if err = pricer.Migrate(); err != nil {
+		return fmt.Errorf("creating pricing schema: %w", err)
+	}
+	if err = payments.Migrate(); err != nil {
+		return fmt.Errorf("creating payment schema: %w", err)
+	}
+	if err = discounts.Migrate(); err != nil {
+		return fmt.Errorf("creating discount schema: %w", err)
+	}
+	if err = chargers.Migrate(); err != nil {
+		return fmt.Errorf("creating charging schema: %w", err)
+	}
+	if err = permits.Migrate(); err != nil {
+		return fmt.Errorf("creating permit schema: %w", err)
+	}
+	if err = waitlists.Migrate(); err != nil {
+		return fmt.Errorf("creating waitlist schema: %w", err)
+	}
+	if err = gates.Migrate(); err != nil {
+		return fmt.Errorf("creating gate schema: %w", err)
+	}
+	if err = reads.Migrate(); err != nil {
+		return fmt.Errorf("creating plate read schema: %w", err)
+	}
+	return spotcache.Migrate(db, "parking_rec")
+}
+
+func registerMetrics() {
+	if spots != nil {
+		return
+	}
+	metrics.NewGaugeFunc("pdea_spots", "Parking spots by type and state (free or occupied).", []string{"service", "type", "state"}, func() []metrics.Sample {
+		return cache.Samples("vehicle")
+	})
+	metrics.NewGaugeFunc("pdea_accessible_spots", "Accessible parking spots by type and state (free or occupied).", []string{"service", "type", "state"}, func() []metrics.Sample {
+		return cache.AccessibleSamples("vehicle")
+	})
+}
+
+// The operations below are shared by the REST handlers and the gRPC
+// server; each transport maps these errors to its own responses.
+var (
+	errSpotNotFound   = errors.New("parking spot not found")
+	errSpotTaken      = errors.New("parking spot not available")
+	errAlreadyPresent = errors.New("vehicle already present")
+	errStayNotFound   = errors.New("vehicle record not found")
+	errStayClosed     = errors.New("vehicle has already left")
+	errSpotService    = errors.New("spot service unavailable")
+	errBadTicket      = errors.New("invalid ticket")
+	errUnpaid         = errors.New("payment required")
+	errNoOverride     = errors.New("only attendants can override payment")
+	errNothingDue     = errors.New("nothing to pay")
+	errEVOnly         = errors.New("parking spot is for electric vehicles only")
+	errNoPermit       = errors.New("accessible spot needs a valid permit")
+	errSpotHeld       = errors.New("parking spot is held for a waiting vehicle")
+	errSpotsFree      = errors.New("spots of this type are free")
+	errUnknownType    = errors.New("unknown spot type")
+	errGateNotFound   = errors.New("gate not found")
+	errWrongGate      = errors.New("gate is for the other direction")
+	errUnknownLane    = errors.New("no gate at lane")
+	errLotFull        = errors.New("no free spot")
+	errJustLeft       = errors.New("vehicle has just left")
+	errFuzzyMatch     = errors.New("read only approximately matches the open stay of")
+)
+
+// fromSpotService translates spot client errors into the errors above.
+func fromSpotService(err error) error {
+	switch {
+	case errors.Is(err, spotclient.ErrNotFound):
+		return errSpotNotFound
+	case errors.Is(err, spotclient.ErrUnavailable):
+		return errSpotTaken
+	}
+	return fmt.Errorf("%w: %v", errSpotService, err)
+}
+
+func findSpot(ctx context.Context, spotNumber string) (parking.ParkingSpot, error) {
+	if spots != nil {
+		s, err := spots.Get(ctx, spotNumber)
+		if err != nil {
+			return parking.ParkingSpot{}, fromSpotService(err)
+		}
+		return s, nil
+	}
+	s, ok := cache.Get(spotNumber)
+	if !ok {
+		return s, errSpotNotFound
+	}
+	return s, nil
+}
+
+// recordStay appends the events of a stay's entry or exit to the event store
+// in tx, the transaction recording it. They are handed to svc.Publish once
+// tx has committed.
+func recordStay(tx *sql.Tx, evType string, p parking.ParkingSpot, v parking.Vehichle) ([]events.Event, error) {
+	tf := timefmt.New(timefmt.RFC3339)
+	res := parking.ToVehichleRes(v, tf)
+	at := v.EntryTime
+	if !v.ExitTime.IsZero() {
+		at = v.ExitTime
+	}
+	evs := []events.Event{{Type: evType, Time: at, SpotNumber: p.SpotNumber, SpotType: p.Type, Zone: p.Zone, StayID: v.ID, Plate: v.License_plate, Data: res}}
+	// the spot service records its own availability change
+	if spots == nil {
+		evs = append(evs, events.Event{Type: events.SpotAvailability, Time: at, SpotNumber: p.SpotNumber, SpotType: p.Type, Zone: p.Zone, IsAvailable: events.Bool(p.Available())})
+	}
+	for i, ev := range evs {
+		var err error
+		if evs[i], err = svc.Record(tx, ev); err != nil {
+			return nil, err
+		}
+	}
+	return evs, nil
+}
+
+// insertStay writes a new stay and, if it was priced, the decision behind
+// its rate.
+func insertStay(tx *sql.Tx, v parking.Vehichle, price *pricing.Decision) error {
+	qr := `INSERT INTO vehicle_records(id, spot_number, license_plate , entry_time, hourly_cents, ticket_id, ev, permit_number, permit_warning) VALUES($1, $2, $3,$4, $5, $6, $7, $8, $9);`
+	if _, err := tx.Exec(qr, v.ID, v.SpotNumber, v.License_plate, v.EntryTime, v.HourlyCents, v.TicketID, v.EV, nullString(v.PermitNumber), nullString(v.PermitWarning)); err != nil {
+		return err
+	}
+	if price == nil {
+		return nil
+	}
+	d := *price
+	d.StayID = v.ID
+	return pricing.Record(tx, d)
+}
+
+// recordEntry inserts the stay, takes the spot and records the events in
+// one transaction, so an interrupted request cannot leave a record without
+// its spot update. The spot is only taken if it is still free, since the
+// cached state the caller checked may be behind another replica's entry.
+// The events are returned for publishing.
+func recordEntry(v parking.Vehichle, p parking.ParkingSpot, price *pricing.Decision) ([]events.Event, error) {
+	defer cache.Refresh(p.SpotNumber)
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	if err = insertStay(tx, v, price); err != nil {
+		return nil, err
+	}
+	res, err := tx.Exec(`UPDATE parking_rec SET is_available = $1 where spot_number = $2 and is_available;`, p.IsAvailable, p.SpotNumber)
+	if err != nil {
+		return nil, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 0 {
+		return nil, errSpotTaken
+	}
+	if err = checkHold(tx, v); err != nil {
+		return nil, err
+	}
+	evs, err := recordStay(tx, events.VehicleEntry, p, v)
+	if err != nil {
+		return nil, err
+	}
+	return evs, tx.Commit()
+}
+
+// checkHold fails with errSpotHeld if v's spot is held for another plate.
+// The caller checked before, but an exit that freed the spot while the
+// entry waited for it has placed its hold since.
+func checkHold(tx *sql.Tx, v parking.Vehichle) error {
+	holder, err := waitlists.HeldFor(tx, v.SpotNumber)
+	if err != nil {
+		return err
+	}
+	if holder != "" && holder != v.License_plate {
+		return errSpotHeld
+	}
+	return nil
+}
+
+// stayHolder names the stay id as the holder of its spot's reservation at
+// the spot service.
+func stayHolder(id int) string {
+	return "stay:" + strconv.Itoa(id)
+}
+
+// insertReservedStay records an entry whose spot was already reserved at the
+// spot service, handing the spot back if the insert fails. The entry's
+// event is returned for publishing.
+func insertReservedStay(ctx context.Context, v parking.Vehichle, p parking.ParkingSpot, price *pricing.Decision) ([]events.Event, error) {
+	var evs []events.Event
+	tx, err := db.Begin()
+	if err == nil {
+		defer tx.Rollback()
+		if err = insertStay(tx, v, price); err == nil {
+			if err = checkHold(tx, v); err == nil {
+				if evs, err = recordStay(tx, events.VehicleEntry, p, v); err == nil {
+					err = tx.Commit()
+				}
+			}
+		}
+	}
+	if err != nil {
+		if _, rerr := spots.Release(context.WithoutCancel(ctx), v.SpotNumber, stayHolder(v.ID)); rerr != nil {
+			logging.FromContext(ctx).Error("releasing spot after failed entry", "spot_number", v.SpotNumber, "error", rerr)
+		}
+		return nil, err
+	}
+	return evs, nil
+}
+
+// exitViaSpotService closes the stay, holding the spot for the waitlist if
+// anyone waits for it, and then frees the spot. The exit stands even if the
+// release fails; the spot then has to be freed by hand. It returns the
+// exit's events for publishing and the waitlist entry given the spot, if
+// any.
+func exitViaSpotService(ctx context.Context, v parking.Vehichle, p parking.ParkingSpot) ([]events.Event, int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+	if err = closeStay(tx, v); err != nil {
+		return nil, 0, err
+	}
+	held, err := holdFreed(tx, p)
+	if err != nil {
+		return nil, 0, err
+	}
+	evs, err := recordStay(tx, events.VehicleExit, p, v)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, 0, err
+	}
+	if _, err := spots.Release(context.WithoutCancel(ctx), v.SpotNumber, ""); err != nil {
+		logging.FromContext(ctx).Error("releasing spot after exit", "spot_number", v.SpotNumber, "error", err)
+	}
+	return evs, held, nil
+}
+
+// holdFreed holds a spot an exit frees for the oldest driver waiting for
+// its type, in the exit's transaction so that no one else can take the spot
+// first. The waitlist cannot tell who qualifies for accessible and EV-only
+// spots, so they are left free for whoever does.
+func holdFreed(tx *sql.Tx, p parking.ParkingSpot) (int, error) {
+	if p.Accessible || p.EVOnly {
+		return 0, nil
+	}
+	return waitlists.HoldSpot(tx, p.Type, p.SpotNumber)
+}
+
+// openStays returns the stays still in progress.
+func openStays() ([]parking.Vehichle, error) {
+	return queryStays(`where exit_time is null`)
+}
+
+// exitedSince returns the stays that ended after t.
+func exitedSince(t time.Time) ([]parking.Vehichle, error) {
+	return queryStays(`where exit_time > $1`, t)
+}
+
+// queryStays returns the stays matching the where clause cond.
+func queryStays(cond string, args ...any) ([]parking.Vehichle, error) {
+	qr := `select id, spot_number, license_plate , entry_time, exit_time, hourly_cents, fee_cents, lost_ticket_cents, discount_cents, energy_cents, coalesce(ticket_id, ''), coalesce(payment_override, ''), ev, coalesce(permit_number, ''), coalesce(permit_warning, '') from vehicle_records ` + cond + `;`
+	rows, err := db.Query(qr, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []parking.Vehichle
+	for rows.Next() {
+		var v parking.Vehichle
+		var exit sql.NullTime
+		var hourly, fee, lost, disc, energy sql.NullInt64
+		if err := rows.Scan(&v.ID, &v.SpotNumber, &v.License_plate, &v.EntryTime, &exit, &hourly, &fee, &lost, &disc, &energy, &v.TicketID, &v.PaymentOverride, &v.EV, &v.PermitNumber, &v.PermitWarning); err != nil {
+			return nil, err
+		}
+		v.ExitTime = exit.Time
+		v.HourlyCents = intPtr(hourly)
+		v.FeeCents = intPtr(fee)
+		v.LostTicketCents = intPtr(lost)
+		v.DiscountCents = intPtr(disc)
+		v.EnergyCents = intPtr(energy)
+		res = append(res, v)
+	}
+	return res, rows.Err()
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func intPtr(n sql.NullInt64) *int {
+	if !n.Valid {
+		return nil
+	}
+	v := int(n.Int64)
+	return &v
+}
+
+// plateParked reports whether the plate has a stay still in progress.
+func plateParked(plate string) (bool, error) {
+	qr := `select exists(select 1 from vehicle_records where license_plate = $1 and exit_time is null);`
+	var present bool
+	err := db.QueryRow(qr, plate).Scan(&present)
+	return present, err
+}
+
+// nextStayID takes the ID of a new stay from the vehicle_records sequence,
+// so concurrent entries, on this replica or another, never share one.
+func nextStayID() (int, error) {
+	var id int
+	err := db.QueryRow(`select nextval('vehicle_records_id_seq');`).Scan(&id)
+	return id, err
+}
+
+// closeStay records the exit time, what the stay cost, what each discount
+// code took off and why it was let out unpaid, if it was. Charging sessions
+// still running are stopped. errStayClosed means another exit closed the
+// stay first.
+func closeStay(q pricing.Execer, v parking.Vehichle) error {
+	qr := `UPDATE vehicle_records SET exit_time = $1, fee_cents = $2, lost_ticket_cents = $3, discount_cents = $4, payment_override = $5, energy_cents = $6 where id = $7 and exit_time is null`
+	res, err := q.Exec(qr, v.ExitTime, v.FeeCents, v.LostTicketCents, v.DiscountCents, nullString(v.PaymentOverride), v.EnergyCents, v.ID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return errStayClosed
+	}
+	if err := charging.StopStay(q, v.ID, v.ExitTime); err != nil {
+		return err
+	}
+	return discount.Settle(q, v.ID, v.Discounts)
+}
+
+// recordExit closes the stay, frees its spot and records the events in one
+// transaction, holding the spot for the waitlist if anyone waits for it. It
+// returns the events for publishing and the waitlist entry given the spot,
+// if any.
+func recordExit(v parking.Vehichle, p parking.ParkingSpot) ([]events.Event, int, error) {
+	defer cache.Refresh(p.SpotNumber)
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+	if err = closeStay(tx, v); err != nil {
+		return nil, 0, err
+	}
+	if _, err = tx.Exec(`UPDATE parking_rec SET is_available = $1 where spot_number = $2;`, p.IsAvailable, p.SpotNumber); err != nil {
+		return nil, 0, err
+	}
+	held, err := holdFreed(tx, p)
+	if err != nil {
+		return nil, 0, err
+	}
+	evs, err := recordStay(tx, events.VehicleExit, p, v)
+	if err != nil {
+		return nil, 0, err
+	}
+	return evs, held, tx.Commit()
+}
+
+// occupancy returns the current occupancy of spotType.
+func occupancy(ctx context.Context, spotType string) (parking.Occupancy, error) {
+	var occ []parking.Occupancy
+	if spots != nil {
+		var err error
+		if occ, err = spots.Occupancy(ctx); err != nil {
+			return parking.Occupancy{}, fromSpotService(err)
+		}
+	} else {
+		occ = cache.Occupancy()
+	}
+	return parking.TypeOccupancy(occ, spotType), nil
+}
+
+// quote prices a stay starting now at Sp from the current occupancy of its
+// type. It returns nil if the type has no rate; the stay is then unpriced.
+func quote(ctx context.Context, Sp parking.ParkingSpot) (*pricing.Decision, error) {
+	occ, err := occupancy(ctx, Sp.Type)
+	if err != nil {
+		return nil, err
+	}
+	d, ok, err := pricer.Quote(Sp.SpotNumber, occ)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// checkPermit decides whether v may take an accessible spot. Without a
+// valid permit the entry is refused or, with warnOnPermit, let in with the
+// reason recorded on the stay.
+func checkPermit(ctx context.Context, v *parking.Vehichle) error {
+	err := permits.Verify(v.PermitNumber, v.License_plate, timefmt.Now())
+	if err == nil || !permit.Refused(err) {
+		return err
+	}
+	if !warnOnPermit {
+		permitMisses.Inc("rejected")
+		return fmt.Errorf("%w: %v", errNoPermit, err)
+	}
+	permitMisses.Inc("warned")
+	logging.FromContext(ctx).Warn("accessible spot taken without a valid permit", "spot_number", v.SpotNumber, "license_plate", v.License_plate, "reason", err)
+	v.PermitWarning = err.Error()
+	return nil
+}
+
+// gateFor returns the gate a vehicle is at, checking it is one for
+// direction. Calls made without a gate, for instance from an attendant's
+// desk, open nothing and get nil.
+func gateFor(gateID, direction string) (*gate.Gate, error) {
+	if gateID == "" {
+		return nil, nil
+	}
+	g, err := gates.Get(gateID)
+	if err == gate.ErrNotFound {
+		return nil, errGateNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if g.Direction != direction {
+		return nil, errWrongGate
+	}
+	return &g, nil
+}
+
+// openGate opens g for the stay v has just started or ended and sets the
+// outcome on v. A gate that fails to open does not undo the stay; the
+// driver is helped through by an attendant instead.
+func openGate(ctx context.Context, g *gate.Gate, v *parking.Vehichle) {
+	if g == nil {
+		return
+	}
+	res, err := gates.Open(ctx, *g, v.ID)
+	if err != nil {
+		logging.FromContext(ctx).Error("recording gate command failed", "gate_id", g.ID, "error", err)
+	}
+	v.Gate = &res
+}
+
+// registerEntry parks in.License_plate at in.SpotNumber. in.EV and
+// in.PermitNumber are what the vehicle declared at the gate, and
+// in.GateID the gate to open once the entry is recorded.
+func registerEntry(ctx context.Context, in parking.Vehichle) (parking.Vehichle, error) {
+	spotNumber, plate := in.SpotNumber, in.License_plate
+	v := parking.Vehichle{SpotNumber: spotNumber, License_plate: plate, EV: in.EV, PermitNumber: in.PermitNumber, GateID: in.GateID}
+	g, err := gateFor(in.GateID, gate.Entry)
+	if err != nil {
+		return v, err
+	}
+	Sp, err := findSpot(ctx, spotNumber)
+	if err != nil {
+		return v, err
+	}
+	if !Sp.Available() {
+		return v, errSpotTaken
+	}
+	if Sp.EVOnly && !v.EV {
+		return v, errEVOnly
+	}
+	if Sp.Accessible {
+		if err = checkPermit(ctx, &v); err != nil {
+			return v, err
+		}
+	}
+	held, err := waitlists.HeldBy(spotNumber)
+	if err != nil {
+		return v, err
+	}
+	if held != nil && held.LicensePlate != plate {
+		return v, errSpotHeld
+	}
+
+	present, err := plateParked(plate)
+	if err != nil {
+		return v, err
+	}
+	if present {
+		return v, errAlreadyPresent
+	}
+	price, err := quote(ctx, Sp)
+	if err != nil {
+		return v, err
+	}
+	if price != nil {
+		v.HourlyCents = &price.HourlyCents
+	}
+	if v.TicketID, err = ticket.NewID(); err != nil {
+		return v, err
+	}
+	if v.ID, err = nextStayID(); err != nil {
+		return v, err
+	}
+	v.EntryTime = timefmt.Now()
+	Sp.IsAvailable = parking.AvailableString(false)
+	var evs []events.Event
+	if spots != nil {
+		// reserving first means a concurrent entry through another gate
+		// loses at the spot service
+		if _, err = spots.Reserve(ctx, spotNumber, stayHolder(v.ID)); err != nil {
+			if !spotclient.Answered(err) {
+				// the spot may have been reserved before the answer was
+				// lost; only a reservation for this stay is released
+				if _, rerr := spots.Release(context.WithoutCancel(ctx), spotNumber, stayHolder(v.ID)); rerr != nil {
+					logging.FromContext(ctx).Error("releasing spot after failed reservation", "spot_number", spotNumber, "error", rerr)
+				}
+			}
+			return v, fromSpotService(err)
+		}
+		evs, err = insertReservedStay(ctx, v, Sp, price)
+	} else {
+		evs, err = recordEntry(v, Sp, price)
+	}
+	if err != nil {
+		return v, err
+	}
+	auditLog.RecordContext(ctx, "entry", "vehicle_record", strconv.Itoa(v.ID), nil, v)
+	vehicleEntries.Inc(Sp.Type)
+	svc.Publish(evs...)
+	waitlists.Arrived(ctx, plate, spotNumber)
+	v.TicketToken = tickets.Token(ticket.Ticket{ID: v.TicketID, StayID: v.ID, EntryTime: v.EntryTime})
+	openGate(ctx, g, &v)
+	return v, nil
+}
+
+// openStay returns the stay still in progress matching the condition cond,
+// which is ANDed into the where clause.
+func openStay(cond string, args ...any) (parking.Vehichle, error) {
+	vDatas, err := queryStays(`where exit_time is null and `+cond+` order by id limit 1`, args...)
+	if err != nil {
+		return parking.Vehichle{}, err
+	}
+	if len(vDatas) == 0 {
+		return parking.Vehichle{}, errStayNotFound
+	}
+	return vDatas[0], nil
+}
+
+func registerExit(ctx context.Context, spotNumber, plate, override, gateID string) (parking.Vehichle, error) {
+	Sp, err := findSpot(ctx, spotNumber)
+	if err != nil {
+		return parking.Vehichle{}, err
+	}
+	v, err := openStay(`spot_number = $1 and license_plate = $2`, spotNumber, plate)
+	if err != nil {
+		return v, err
+	}
+	return finishExit(ctx, Sp, v, "exit", nil, override, gateID)
+}
+
+// ticketStay returns the stay still in progress a ticket token was issued
+// for.
+func ticketStay(token string) (parking.Vehichle, error) {
+	t, err := tickets.Verify(token)
+	if err != nil {
+		return parking.Vehichle{}, errBadTicket
+	}
+	return openStay(`id = $1 and ticket_id = $2`, t.StayID, t.ID)
+}
+
+// registerTicketExit ends the stay a ticket token was issued for.
+func registerTicketExit(ctx context.Context, token, override, gateID string) (parking.Vehichle, error) {
+	v, err := ticketStay(token)
+	if err != nil {
+		return v, err
+	}
+	Sp, err := findSpot(ctx, v.SpotNumber)
+	if err != nil {
+		return v, err
+	}
+	return finishExit(ctx, Sp, v, "exit", nil, override, gateID)
+}
+
+// registerLostTicketExit ends the plate's stay, wherever it is parked, and
+// charges the lost-ticket fee on top.
+func registerLostTicketExit(ctx context.Context, plate, override, gateID string) (parking.Vehichle, error) {
+	v, err := openStay(`license_plate = $1`, plate)
+	if err != nil {
+		return v, err
+	}
+	Sp, err := findSpot(ctx, v.SpotNumber)
+	if err != nil {
+		return v, err
+	}
+	charge := lostTicketCents
+	return finishExit(ctx, Sp, v, "lost-ticket-exit", &charge, override, gateID)
+}
+
+// priceStay sets what v costs if it leaves at exit: the hourly fee less
+// the discount codes redeemed for it, plus the energy charged so far and
+// the lost-ticket charge if there is one. The fee stays unset for a stay
+// with none of these.
+func priceStay(v *parking.Vehichle, exit time.Time, lostTicket *int) error {
+	v.FeeCents, v.DiscountCents, v.Discounts, v.EnergyCents = nil, nil, nil, nil
+	v.LostTicketCents = lostTicket
+	sessions, err := chargers.ForStay(v.ID)
+	if err != nil {
+		return err
+	}
+	if len(sessions) > 0 {
+		energy := 0
+		for _, se := range sessions {
+			energy += se.Cents
+		}
+		v.EnergyCents = &energy
+	}
+	if v.HourlyCents == nil && lostTicket == nil && v.EnergyCents == nil {
+		return nil
+	}
+	fee := 0
+	if v.HourlyCents != nil {
+		codes, err := discounts.ForStay(v.ID)
+		if err != nil {
+			return err
+		}
+		fee, v.Discounts = discount.Apply(codes, *v.HourlyCents, v.EntryTime, exit)
+		if len(v.Discounts) > 0 {
+			off := 0
+			for _, d := range v.Discounts {
+				off += d.Cents
+			}
+			v.DiscountCents = &off
+		}
+	}
+	if lostTicket != nil {
+		fee += *lostTicket
+	}
+	if v.EnergyCents != nil {
+		fee += *v.EnergyCents
+	}
+	v.FeeCents = &fee
+	return nil
+}
+
+// graceDue reprices a stay that still owes due at exit as of when its last
+// captured payment was quoted, if the capture was at most the payment grace
+// period ago, and returns what is due then. A driver who paid at the pay
+// station is not charged the hour that started on the way to the gate.
+func graceDue(v *parking.Vehichle, due int, lostTicket *int) (int, error) {
+	in, err := payments.LastCapture(v.ID)
+	if err == payment.ErrNotFound {
+		return due, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if v.ExitTime.Sub(in.UpdatedAt) > payments.Grace {
+		return due, nil
+	}
+	if err = priceStay(v, in.CreatedAt, lostTicket); err != nil {
+		return 0, err
+	}
+	return amountDue(*v, v.FeeCents)
+}
+
+// amountDue is the part of fee not yet covered by captured payments.
+func amountDue(v parking.Vehichle, fee *int) (int, error) {
+	if fee == nil || *fee == 0 {
+		return 0, nil
+	}
+	paid, err := payments.Paid(v.ID)
+	if err != nil {
+		return 0, err
+	}
+	return max(*fee-paid, 0), nil
+}
+
+// canOverride reports whether the caller may let an unpaid stay out.
+func canOverride(ctx context.Context) bool {
+	if svc.Auth.Disabled {
+		return true
+	}
+	p, ok := auth.FromContext(ctx)
+	return ok && (p.Role == auth.RoleAttendant || p.Role == auth.RoleAdmin)
+}
+
+// finishExit closes the stay and frees its spot once the fee is paid, then
+// opens the exit gate if one is named. An attendant can let an unpaid stay
+// out by giving an override reason, which is kept with the record.
+func finishExit(ctx context.Context, Sp parking.ParkingSpot, v parking.Vehichle, action string, lostTicket *int, override, gateID string) (parking.Vehichle, error) {
+	before := v
+	g, err := gateFor(gateID, gate.Exit)
+	if err != nil {
+		return before, err
+	}
+	v.ExitTime = timefmt.Now()
+	v.GateID = gateID
+	if err = priceStay(&v, v.ExitTime, lostTicket); err != nil {
+		return before, err
+	}
+	due, err := amountDue(v, v.FeeCents)
+	if err == nil && due > 0 {
+		due, err = graceDue(&v, due, lostTicket)
+	}
+	if err != nil {
+		return before, err
+	}
+	if due > 0 {
+		if override == "" {
+			return before, fmt.Errorf("%w: %d cents due", errUnpaid, due)
+		}
+		if !canOverride(ctx) {
+			return before, errNoOverride
+		}
+		v.PaymentOverride = override
+		action += "-override"
+	}
+	Sp.IsAvailable = parking.AvailableString(true)
+	var evs []events.Event
+	var held int
+	if spots != nil {
+		evs, held, err = exitViaSpotService(ctx, v, Sp)
+	} else {
+		evs, held, err = recordExit(v, Sp)
+	}
+	if err != nil {
+		return v, err
+	}
+	auditLog.RecordContext(ctx, action, "vehicle_record", strconv.Itoa(v.ID), before, v)
+	vehicleExits.Inc(Sp.Type)
+	svc.Publish(evs...)
+	waitlists.Offered(ctx, held)
+	openGate(ctx, g, &v)
+	return v, nil
+}
+
+// joinWaitlist queues plate for the next freed spot of spotType. Only full
+// types have a waitlist: spots that are free and not held for another
+// driver should be taken instead.
+func joinWaitlist(ctx context.Context, spotType, plate, contact string) (waitlist.Entry, error) {
+	occ, err := occupancy(ctx, spotType)
+	if err != nil {
+		return waitlist.Entry{}, err
+	}
+	if occ.Total == 0 {
+		return waitlist.Entry{}, errUnknownType
+	}
+	held, err := waitlists.HeldCount(spotType)
+	if err != nil {
+		return waitlist.Entry{}, err
+	}
+	if occ.Free-held > 0 {
+		return waitlist.Entry{}, errSpotsFree
+	}
+	present, err := plateParked(plate)
+	if err != nil {
+		return waitlist.Entry{}, err
+	}
+	if present {
+		return waitlist.Entry{}, errAlreadyPresent
+	}
+	return waitlists.Join(ctx, spotType, plate, contact)
+}
+
+// stayByRef returns the stay in progress named by a ticket token or, if
+// token is empty, by its ID.
+func stayByRef(stayID int, token string) (parking.Vehichle, error) {
+	if token != "" {
+		return ticketStay(token)
+	}
+	return openStay(`id = $1`, stayID)
+}
+
+// redeemDiscount applies a discount code to a stay in progress and returns
+// the stay priced as if it left now.
+func redeemDiscount(ctx context.Context, code string, stayID int, token string) (parking.Vehichle, error) {
+	v, err := stayByRef(stayID, token)
+	if err != nil {
+		return v, err
+	}
+	if v.HourlyCents == nil {
+		return v, errNothingDue
+	}
+	Sp, err := findSpot(ctx, v.SpotNumber)
+	if err != nil {
+		return v, err
+	}
+	r, err := discounts.Redeem(code, v.ID, Sp.Type)
+	if err != nil {
+		return v, err
+	}
+	auditLog.RecordContext(ctx, "redeem", "discount_code", code, nil, r)
+	err = priceStay(&v, timefmt.Now(), nil)
+	return v, err
+}
+
+// startCharging opens a charging session for a stay in progress at its
+// spot's charger.
+func startCharging(ctx context.Context, stayID int, token string) (charging.Session, error) {
+	v, err := stayByRef(stayID, token)
+	if err != nil {
+		return charging.Session{}, err
+	}
+	Sp, err := findSpot(ctx, v.SpotNumber)
+	if err != nil {
+		return charging.Session{}, err
+	}
+	se, err := chargers.Start(v.ID, Sp)
+	if err != nil {
+		return se, err
+	}
+	auditLog.RecordContext(ctx, "start", "charging_session", strconv.Itoa(se.ID), nil, se)
+	return se, nil
+}
+
+// createPayment opens an intent for what a stay would owe if it left now,
+// less what has already been paid. The stay is named by its ticket token or
+// its ID; lostTicket adds the lost-ticket charge.
+func createPayment(ctx context.Context, stayID int, token string, lostTicket bool) (payment.Intent, error) {
+	v, err := stayByRef(stayID, token)
+	if err != nil {
+		return payment.Intent{}, err
+	}
+	var charge *int
+	if lostTicket {
+		charge = &lostTicketCents
+	}
+	if err = priceStay(&v, timefmt.Now(), charge); err != nil {
+		return payment.Intent{}, err
+	}
+	due, err := amountDue(v, v.FeeCents)
+	if err != nil {
+		return payment.Intent{}, err
+	}
+	if due == 0 {
+		return payment.Intent{}, errNothingDue
+	}
+	return payments.Create(ctx, v.ID, due)
+}
+
+// findRecords returns the stays matching spot number or plate; exactly one
+// of them is set.
+func findRecords(spotNumber, plate string) ([]parking.Vehichle, error) {
+	var res []parking.Vehichle
+	var err error
+	if plate != "" {
+		res, err = queryStays(`where license_plate = $1 order by id`, plate)
+	} else {
+		res, err = queryStays(`where spot_number = $1 order by id`, spotNumber)
+	}
+	if res == nil {
+		res = []parking.Vehichle{}
+	}
+	return res, err
+}
+
+func httpError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case err == errSpotNotFound:
+		http.Error(w, "Parking spot not found", http.StatusNotFound)
+	case err == errSpotTaken:
+		http.Error(w, "Parking spot not available", http.StatusNotFound)
+	case err == errAlreadyPresent:
+		http.Error(w, "Vehicle already present", http.StatusConflict)
+	case err == errStayNotFound:
+		http.Error(w, "Vehicle record not found", http.StatusNotFound)
+	case err == errStayClosed:
+		http.Error(w, "Vehicle has already left", http.StatusConflict)
+	case err == errBadTicket:
+		http.Error(w, "Invalid ticket", http.StatusBadRequest)
+	case errors.Is(err, errUnpaid):
+		http.Error(w, err.Error(), http.StatusPaymentRequired)
+	case err == errNoOverride:
+		http.Error(w, "Only attendants can override payment", http.StatusForbidden)
+	case err == errNothingDue:
+		http.Error(w, "Nothing to pay", http.StatusConflict)
+	case err == errEVOnly:
+		http.Error(w, "Parking spot is for electric vehicles only", http.StatusConflict)
+	case errors.Is(err, errNoPermit):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case err == errSpotHeld:
+		http.Error(w, "Parking spot is held for a waiting vehicle", http.StatusConflict)
+	case err == errSpotsFree:
+		http.Error(w, "Spots of this type are free", http.StatusConflict)
+	case err == errUnknownType:
+		http.Error(w, "Unknown spot type", http.StatusNotFound)
+	case err == errGateNotFound:
+		http.Error(w, "Gate not found", http.StatusNotFound)
+	case err == errWrongGate:
+		http.Error(w, "Gate is for the other direction", http.StatusBadRequest)
+	case err == errUnknownLane:
+		http.Error(w, "No gate at lane", http.StatusBadRequest)
+	case err == errLotFull, err == errJustLeft:
+		http.Error(w, err.Error(), http.StatusConflict)
+	case err == anpr.ErrNoMatch:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case err == anpr.ErrAmbiguous:
+		http.Error(w, err.Error(), http.StatusConflict)
+	case err == anpr.ErrNotFound, err == anpr.ErrReviewed, errors.Is(err, anpr.ErrInvalid):
+		anpr.WriteError(w, r, err)
+	case err == waitlist.ErrWaiting:
+		waitlist.WriteError(w, r, err)
+	case err == charging.ErrNoCharger, err == charging.ErrActive:
+		charging.WriteError(w, r, err)
+	case err == discount.ErrNotFound:
+		http.Error(w, "Discount code not found", http.StatusNotFound)
+	case err == discount.ErrExpired, err == discount.ErrIneligible, err == discount.ErrExhausted, err == discount.ErrRedeemed:
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, errSpotService):
+		logging.FromContext(r.Context()).Error("spot service error", "error", err)
+		http.Error(w, "Spot service unavailable", http.StatusServiceUnavailable)
+	default:
+		logging.FromContext(r.Context()).Error("server error", "error", err)
+		http.Error(w, "Server error", http.StatusInternalServerError)
+	}
+}
+
+func RegisterEntry(w http.ResponseWriter, r *http.Request) {
+	var reqBody parking.Vehichle
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		logging.FromContext(r.Context()).Warn("invalid request body", "error", err)
+		http.Error(w, "Invalid request Data", http.StatusBadRequest)
+		return
+	}
+	v, err := registerEntry(audit.ContextFromRequest(r), reqBody)
+	if err != nil {
+		httpError(w, r, err)
+		return
+	}
+	resJson, _ := json.Marshal(parking.ToVehichleRes(v, timefmt.FromRequest(r)))
+	w.WriteHeader(http.StatusCreated)
+	w.Write(resJson)
+}
+func RegisterExit(w http.ResponseWriter, r *http.Request) {
+	var reqBody parking.Vehichle
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		logging.FromContext(r.Context()).Warn("invalid request body", "error", err)
+		http.Error(w, "Invalid request Data", http.StatusBadRequest)
+		return
+	}
+	v, err := registerExit(audit.ContextFromRequest(r), reqBody.SpotNumber, reqBody.License_plate, reqBody.PaymentOverride, reqBody.GateID)
+	if err != nil {
+		httpError(w, r, err)
+		return
+	}
+	resJson, _ := json.Marshal(parking.ToVehichleRes(v, timefmt.FromRequest(r)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(resJson)
+}
+func RegisterTicketExit(w http.ResponseWriter, r *http.Request) {
+	var reqBody struct {
+		TicketToken     string `json:"ticket_token"`
+		PaymentOverride string `json:"payment_override"`
+		GateID          string `json:"gate_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil || reqBody.TicketToken == "" {
+		http.Error(w, "Invalid request Data", http.StatusBadRequest)
+		return
+	}
+	v, err := registerTicketExit(audit.ContextFromRequest(r), reqBody.TicketToken, reqBody.PaymentOverride, reqBody.GateID)
+	if err != nil {
+		httpError(w, r, err)
+		return
+	}
+	resJson, _ := json.Marshal(parking.ToVehichleRes(v, timefmt.FromRequest(r)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(resJson)
+}
+func RegisterLostTicketExit(w http.ResponseWriter, r *http.Request) {
+	var reqBody parking.Vehichle
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil || reqBody.License_plate == "" {
+		http.Error(w, "Invalid request Data", http.StatusBadRequest)
+		return
+	}
+	v, err := registerLostTicketExit(audit.ContextFromRequest(r), reqBody.License_plate, reqBody.PaymentOverride, reqBody.GateID)
+	if err != nil {
+		httpError(w, r, err)
+		return
+	}
+	resJson, _ := json.Marshal(parking.ToVehichleRes(v, timefmt.FromRequest(r)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(resJson)
+}
+
+// CreatePayment takes {"stay_id": 12} or {"ticket_token": "..."} and an
+// optional "lost_ticket": true, and answers with the new pending intent.
+func CreatePayment(w http.ResponseWriter, r *http.Request) {
+	var reqBody struct {
+		StayID      int    `json:"stay_id"`
+		TicketToken string `json:"ticket_token"`
+		LostTicket  bool   `json:"lost_ticket"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil || (reqBody.StayID == 0 && reqBody.TicketToken == "") {
+		http.Error(w, "Invalid request Data, stay_id or ticket_token is required", http.StatusBadRequest)
+		return
+	}
+	in, err := createPayment(audit.ContextFromRequest(r), reqBody.StayID, reqBody.TicketToken, reqBody.LostTicket)
+	if err != nil {
+		httpError(w, r, err)
+		return
+	}
+	resJson, _ := json.Marshal(in)
+	w.WriteHeader(http.StatusCreated)
+	w.Write(resJson)
+}
+
+// RedeemDiscount takes {"code": "CAFE10"} and {"stay_id": 12} or
+// {"ticket_token": "..."}. It answers with the stay and the fee it would
+// pay if it left now, broken down by discount.
+func RedeemDiscount(w http.ResponseWriter, r *http.Request) {
+	var reqBody struct {
+		Code        string `json:"code"`
+		StayID      int    `json:"stay_id"`
+		TicketToken string `json:"ticket_token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil || reqBody.Code == "" || (reqBody.StayID == 0 && reqBody.TicketToken == "") {
+		http.Error(w, "Invalid request Data, code and stay_id or ticket_token are required", http.StatusBadRequest)
+		return
+	}
+	v, err := redeemDiscount(audit.ContextFromRequest(r), reqBody.Code, reqBody.StayID, reqBody.TicketToken)
+	if err != nil {
+		httpError(w, r, err)
+		return
+	}
+	resJson, _ := json.Marshal(parking.ToVehichleRes(v, timefmt.FromRequest(r)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(resJson)
+}
+
+// StartCharging takes {"stay_id": 12} or {"ticket_token": "..."} and
+// answers with the new session.
+func StartCharging(w http.ResponseWriter, r *http.Request) {
+	var reqBody struct {
+		StayID      int    `json:"stay_id"`
+		TicketToken string `json:"ticket_token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil || (reqBody.StayID == 0 && reqBody.TicketToken == "") {
+		http.Error(w, "Invalid request Data, stay_id or ticket_token is required", http.StatusBadRequest)
+		return
+	}
+	se, err := startCharging(audit.ContextFromRequest(r), reqBody.StayID, reqBody.TicketToken)
+	if err != nil {
+		httpError(w, r, err)
+		return
+	}
+	resJson, _ := json.Marshal(se)
+	w.WriteHeader(http.StatusCreated)
+	w.Write(resJson)
+}
+
+// JoinWaitlist takes {"type": "compact", "license_plate": "...",
+// "contact": "..."} and answers with the new entry and its position.
+func JoinWaitlist(w http.ResponseWriter, r *http.Request) {
+	var reqBody struct {
+		Type         string `json:"type"`
+		LicensePlate string `json:"license_plate"`
+		Contact      string `json:"contact"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil || reqBody.Type == "" || reqBody.LicensePlate == "" {
+		http.Error(w, "Invalid request Data, type and license_plate are required", http.StatusBadRequest)
+		return
+	}
+	e, err := joinWaitlist(audit.ContextFromRequest(r), reqBody.Type, reqBody.LicensePlate, reqBody.Contact)
+	if err != nil {
+		httpError(w, r, err)
+		return
+	}
+	resJson, _ := json.Marshal(e)
+	w.WriteHeader(http.StatusCreated)
+	w.Write(resJson)
+}
+func writeRecords(w http.ResponseWriter, r *http.Request, vDatas []parking.Vehichle) {
+	tf := timefmt.FromRequest(r)
+	res := []parking.VehichleRes{}
+	for _, v := range vDatas {
+		res = append(res, parking.ToVehichleRes(v, tf))
+	}
+	resJson, _ := json.Marshal(res)
+	w.WriteHeader(http.StatusOK)
+	w.Write(resJson)
+}
+func GetVRecordsBySpotNo(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Path[len("/api/vehicle-records/"):]
+	vDatas, err := findRecords(id, "")
+	if err != nil {
+		httpError(w, r, err)
+		return
+	}
+	writeRecords(w, r, vDatas)
+}
+func GetVRecordsByPlate(w http.ResponseWriter, r *http.Request) {
+	plate := r.URL.Query().Get("license_plate")
+	if plate == "" {
+		http.Error(w, "license_plate is required", http.StatusBadRequest)
+		return
+	}
+	vDatas, err := findRecords("", plate)
+	if err != nil {
+		httpError(w, r, err)
+		return
+	}
+	writeRecords(w, r, vDatas)
+}
+func registerRoutes() (*mux.Router, error) {
+	router, err := svc.Router(routePolicy, routeLimits)
+	if err != nil {
+		return nil, err
+	}
+	router.HandleFunc("/api/vehicle-entries", RegisterEntry).Methods("POST")
+	router.HandleFunc("/api/vehicle-exits", RegisterExit).Methods("POST")
+	router.HandleFunc("/api/vehicle-exits/ticket", RegisterTicketExit).Methods("POST")
+	router.HandleFunc("/api/vehicle-exits/lost-ticket", RegisterLostTicketExit).Methods("POST")
+	pricer.RegisterRoutes(router)
+	router.HandleFunc("/api/payments", CreatePayment).Methods("POST")
+	payments.RegisterRoutes(router)
+	router.HandleFunc("/api/discount-redemptions", RedeemDiscount).Methods("POST")
+	discounts.RegisterRoutes(router)
+	router.HandleFunc("/api/charging-sessions", StartCharging).Methods("POST")
+	chargers.RegisterRoutes(router)
+	permits.RegisterRoutes(router)
+	router.HandleFunc("/api/waitlist", JoinWaitlist).Methods("POST")
+	waitlists.RegisterRoutes(router)
+	gates.RegisterRoutes(router)
+	router.HandleFunc("/api/plate-reads", IngestPlateRead).Methods("POST")
+	router.HandleFunc("/api/plate-reads/{id}/resolve", ResolvePlateRead).Methods("POST")
+	reads.RegisterRoutes(router)
+	if cache != nil {
+		cache.RegisterRoutes(router)
+	}
+	router.HandleFunc("/api/vehicle-records", GetVRecordsByPlate).Methods("GET")
+	router.HandleFunc("/api/vehicle-records/{spot_no}", GetVRecordsBySpotNo).Methods("GET")
+	return router, nil
+}
@@ -0,0 +1,62 @@
+package vehicle
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"PDEA/internal/parking"
+	"PDEA/internal/permit"
+	"PDEA/internal/testdb"
+)
+
+func TestCheckPermit(t *testing.T) {
+	tdb := testdb.Open(t, "vehicle_permit_test")
+	if _, err := tdb.Exec(`DROP TABLE IF EXISTS accessibility_permits;`); err != nil {
+		t.Fatal(err)
+	}
+	saved, savedWarn := permits, warnOnPermit
+	t.Cleanup(func() { permits, warnOnPermit = saved, savedWarn })
+	permits = permit.New(tdb)
+	if err := permits.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	expired := time.Now().Add(-time.Hour)
+	for _, p := range []permit.Permit{
+		{Number: "P-OK", Plate: "ABC123"},
+		{Number: "P-OLD", ExpiresAt: &expired},
+	} {
+		if _, err := permits.Put(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		warn    bool
+		permit  string
+		err     error
+		warning string
+	}{
+		{"valid", false, "P-OK", nil, ""},
+		{"valid in warn mode", true, "P-OK", nil, ""},
+		{"expired rejected", false, "P-OLD", errNoPermit, ""},
+		{"missing rejected", false, "", errNoPermit, ""},
+		{"expired warned", true, "P-OLD", nil, permit.ErrExpired.Error()},
+		{"missing warned", true, "", nil, permit.ErrMissing.Error()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnOnPermit = tt.warn
+			v := parking.Vehichle{SpotNumber: "H1", License_plate: "ABC123", PermitNumber: tt.permit}
+			err := checkPermit(context.Background(), &v)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("checkPermit = %v, want %v", err, tt.err)
+			}
+			if v.PermitWarning != tt.warning {
+				t.Errorf("PermitWarning = %q, want %q", v.PermitWarning, tt.warning)
+			}
+		})
+	}
+}
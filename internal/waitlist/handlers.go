@@ -0,0 +1,85 @@
+package waitlist
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"PDEA/internal/audit"
+	"PDEA/internal/logging"
+
+	"github.com/gorilla/mux"
+)
+
+// RegisterRoutes adds the listing, lookup and cancel endpoints. Joining is
+// left to the vehicle service, which knows whether the lot is full.
+func (s *Service) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/waitlist", s.ListHandler).Methods("GET")
+	router.HandleFunc("/api/waitlist/{id}", s.GetHandler).Methods("GET")
+	router.HandleFunc("/api/waitlist/{id}", s.CancelHandler).Methods("DELETE")
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	resJson, _ := json.Marshal(v)
+	w.WriteHeader(status)
+	w.Write(resJson)
+}
+
+// WriteError maps the package's errors to responses.
+func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	switch err {
+	case ErrNotFound:
+		http.Error(w, "Waitlist entry not found", http.StatusNotFound)
+	case ErrWaiting, ErrClosed:
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		logging.FromContext(r.Context()).Error("waitlist request failed", "error", err)
+		http.Error(w, "Server error", http.StatusInternalServerError)
+	}
+}
+
+func entryID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid id", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+// ListHandler lists active entries in queue order, of one type with
+// ?spot_type=.
+func (s *Service) ListHandler(w http.ResponseWriter, r *http.Request) {
+	res, err := s.Active(r.URL.Query().Get("spot_type"))
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *Service) GetHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := entryID(w, r)
+	if !ok {
+		return
+	}
+	e, err := s.Get(id)
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, e)
+}
+
+func (s *Service) CancelHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := entryID(w, r)
+	if !ok {
+		return
+	}
+	e, err := s.Cancel(audit.ContextFromRequest(r), id)
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, e)
+}
@@ -0,0 +1,84 @@
+package waitlist
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	KindHold    = "hold"
+	KindExpired = "expired"
+)
+
+// Notification tells a waiting driver that a spot is held for them, or
+// that the hold ran out.
+type Notification struct {
+	Kind    string    `json:"kind"`
+	Entry   Entry     `json:"entry"`
+	Message string    `json:"message"`
+	At      time.Time `json:"at"`
+}
+
+// Notifier delivers notifications to drivers, for instance by SMS or push
+// using Entry.Contact.
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// LogNotifier writes notifications to the service log.
+type LogNotifier struct {
+	Log *slog.Logger
+}
+
+func (l LogNotifier) Notify(ctx context.Context, n Notification) error {
+	l.Log.Info("waitlist notification", "kind", n.Kind, "id", n.Entry.ID, "contact", n.Entry.Contact, "message", n.Message)
+	return nil
+}
+
+// FileNotifier appends notifications to Path as JSON lines, which tests and
+// local setups can read back.
+type FileNotifier struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+func (f *FileNotifier) Notify(ctx context.Context, n Notification) error {
+	if n.At.IsZero() {
+		n.At = time.Now().UTC()
+	}
+	data, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	file, err := os.OpenFile(f.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err = file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// NotifierFromEnv picks the notifier named by PDEA_WAITLIST_NOTIFY: "log"
+// (the default) or "file:<path>".
+func NotifierFromEnv(logger *slog.Logger) (Notifier, error) {
+	v := os.Getenv("PDEA_WAITLIST_NOTIFY")
+	switch {
+	case v == "" || v == "log":
+		return LogNotifier{Log: logger}, nil
+	case strings.HasPrefix(v, "file:") && len(v) > len("file:"):
+		return &FileNotifier{Path: strings.TrimPrefix(v, "file:")}, nil
+	}
+	return nil, fmt.Errorf("invalid PDEA_WAITLIST_NOTIFY %q", v)
+}
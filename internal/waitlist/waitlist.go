@@ -0,0 +1,413 @@
+// Package waitlist queues drivers for a spot type while the lot is full.
+// Entries are served first come, first served: when a spot of the type is
+// freed, the oldest waiting entry gets a hold on it for Hold, during which
+// only that plate may enter it. Holds that run out are expired and the spot
+// is offered to the next entry. Drivers are told through a Notifier.
+package waitlist
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"PDEA/internal/audit"
+	"PDEA/internal/metrics"
+)
+
+const (
+	StatusWaiting   = "waiting"
+	StatusHeld      = "held"
+	StatusFulfilled = "fulfilled"
+	StatusExpired   = "expired"
+	StatusCancelled = "cancelled"
+)
+
+var (
+	ErrNotFound = errors.New("waitlist entry not found")
+	ErrWaiting  = errors.New("vehicle is already on the waitlist")
+	ErrClosed   = errors.New("waitlist entry is no longer active")
+)
+
+var holds = metrics.NewCounterVec("pdea_waitlist_holds_total", "Waitlist holds by outcome (offered, fulfilled or expired).", "outcome")
+
+type Entry struct {
+	ID           int        `json:"id"`
+	SpotType     string     `json:"spot_type"`
+	LicensePlate string     `json:"license_plate"`
+	Contact      string     `json:"contact,omitempty"`
+	Status       string     `json:"status"`
+	HeldSpot     string     `json:"held_spot,omitempty"`
+	HoldExpires  *time.Time `json:"hold_expires_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	// Position counts from 1 for waiting entries; it is unset otherwise.
+	Position int `json:"position,omitempty"`
+}
+
+// active reports whether the entry still waits for or holds a spot.
+func (e Entry) active() bool {
+	return e.Status == StatusWaiting || e.Status == StatusHeld
+}
+
+type Service struct {
+	DB       *sql.DB
+	Notifier Notifier
+	// Hold is how long a freed spot is kept for the driver it was offered
+	// to; CheckInterval is how often expired holds are looked for.
+	Hold          time.Duration
+	CheckInterval time.Duration
+	Audit         *audit.Logger
+	Log           *slog.Logger
+
+	lastCheck atomic.Int64
+}
+
+func New(db *sql.DB, notifier Notifier) *Service {
+	return &Service{
+		DB:            db,
+		Notifier:      notifier,
+		Hold:          10 * time.Minute,
+		CheckInterval: 15 * time.Second,
+		Log:           slog.Default(),
+	}
+}
+
+// NewFromEnv reads the hold time from PDEA_WAITLIST_HOLD and the notifier
+// from PDEA_WAITLIST_NOTIFY (see NotifierFromEnv).
+func NewFromEnv(db *sql.DB, logger *slog.Logger) (*Service, error) {
+	n, err := NotifierFromEnv(logger)
+	if err != nil {
+		return nil, err
+	}
+	s := New(db, n)
+	s.Log = logger
+	if v := os.Getenv("PDEA_WAITLIST_HOLD"); v != "" {
+		if s.Hold, err = time.ParseDuration(v); err != nil || s.Hold <= 0 {
+			return nil, fmt.Errorf("invalid PDEA_WAITLIST_HOLD %q", v)
+		}
+	}
+	return s, nil
+}
+
+func (s *Service) Migrate() error {
+	schemaSQL := `
+CREATE TABLE IF NOT EXISTS waitlist_entries (
+id SERIAL PRIMARY KEY,
+spot_type TEXT NOT NULL,
+license_plate TEXT NOT NULL,
+contact TEXT NOT NULL DEFAULT '',
+status TEXT NOT NULL,
+held_spot TEXT NOT NULL DEFAULT '',
+hold_expires_at TIMESTAMPTZ,
+created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS waitlist_entries_queue ON waitlist_entries (spot_type, status, id);
+CREATE UNIQUE INDEX IF NOT EXISTS waitlist_entries_plate ON waitlist_entries (license_plate) WHERE status IN ('waiting', 'held');
+`
+	_, err := s.DB.Exec(schemaSQL)
+	return err
+}
+
+const entryColumns = `e.id, e.spot_type, e.license_plate, e.contact, e.status, e.held_spot, e.hold_expires_at, e.created_at,
+	case when e.status = 'waiting' then (select count(*) from waitlist_entries w where w.spot_type = e.spot_type and w.status = 'waiting' and w.id <= e.id) else 0 end`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanEntry(row scanner) (Entry, error) {
+	var e Entry
+	var expires sql.NullTime
+	err := row.Scan(&e.ID, &e.SpotType, &e.LicensePlate, &e.Contact, &e.Status, &e.HeldSpot, &expires, &e.CreatedAt, &e.Position)
+	if err == sql.ErrNoRows {
+		return e, ErrNotFound
+	}
+	if expires.Valid {
+		e.HoldExpires = &expires.Time
+	}
+	return e, err
+}
+
+func (s *Service) query(where string, args ...any) ([]Entry, error) {
+	rows, err := s.DB.Query(`select `+entryColumns+` from waitlist_entries e `+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := []Entry{}
+	for rows.Next() {
+		e, err := scanEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, e)
+	}
+	return res, rows.Err()
+}
+
+// Join puts plate at the back of the queue for spotType.
+func (s *Service) Join(ctx context.Context, spotType, plate, contact string) (Entry, error) {
+	qr := `INSERT INTO waitlist_entries(spot_type, license_plate, contact, status, created_at) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (license_plate) WHERE status IN ('waiting', 'held') DO NOTHING returning id`
+	var id int
+	err := s.DB.QueryRow(qr, spotType, plate, contact, StatusWaiting, time.Now().UTC()).Scan(&id)
+	if err == sql.ErrNoRows {
+		return Entry{}, ErrWaiting
+	}
+	if err != nil {
+		return Entry{}, err
+	}
+	e, err := s.Get(id)
+	if err != nil {
+		return e, err
+	}
+	s.Audit.RecordContext(ctx, "join", "waitlist_entry", fmt.Sprint(e.ID), nil, e)
+	return e, nil
+}
+
+func (s *Service) Get(id int) (Entry, error) {
+	return scanEntry(s.DB.QueryRow(`select `+entryColumns+` from waitlist_entries e where e.id = $1`, id))
+}
+
+// Active lists the entries waiting for or holding a spot, optionally of one
+// type, in queue order.
+func (s *Service) Active(spotType string) ([]Entry, error) {
+	return s.query(`where e.status in ('waiting', 'held') and ($1 = '' or e.spot_type = $1) order by e.id`, spotType)
+}
+
+// HeldCount returns how many spots of spotType are being held.
+func (s *Service) HeldCount(spotType string) (int, error) {
+	var n int
+	err := s.DB.QueryRow(`select count(*) from waitlist_entries where spot_type = $1 and status = $2`, spotType, StatusHeld).Scan(&n)
+	return n, err
+}
+
+// Holds returns the plate each held spot is kept for, by spot number.
+func (s *Service) Holds() (map[string]string, error) {
+	rows, err := s.DB.Query(`select held_spot, license_plate from waitlist_entries where status = $1`, StatusHeld)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := map[string]string{}
+	for rows.Next() {
+		var spot, plate string
+		if err := rows.Scan(&spot, &plate); err != nil {
+			return nil, err
+		}
+		res[spot] = plate
+	}
+	return res, rows.Err()
+}
+
+// HeldBy returns the entry holding spotNumber, or nil if it is not held.
+func (s *Service) HeldBy(spotNumber string) (*Entry, error) {
+	e, err := scanEntry(s.DB.QueryRow(`select `+entryColumns+` from waitlist_entries e where e.status = $1 and e.held_spot = $2`, StatusHeld, spotNumber))
+	if err == ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// HeldFor returns the plate spotNumber is held for, or "" if it is not
+// held. Unlike HeldBy it can run in the caller's transaction.
+func (s *Service) HeldFor(q Queryer, spotNumber string) (string, error) {
+	var plate string
+	err := q.QueryRow(`select license_plate from waitlist_entries where status = $1 and held_spot = $2`, StatusHeld, spotNumber).Scan(&plate)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return plate, err
+}
+
+// Cancel takes an entry off the list. A spot it held is offered on.
+func (s *Service) Cancel(ctx context.Context, id int) (Entry, error) {
+	before, err := s.Get(id)
+	if err != nil {
+		return before, err
+	}
+	if !before.active() {
+		return before, ErrClosed
+	}
+	e, err := s.close(id, StatusCancelled)
+	if err != nil {
+		return e, err
+	}
+	s.Audit.RecordContext(ctx, "cancel", "waitlist_entry", fmt.Sprint(id), before, e)
+	if before.HeldSpot != "" {
+		s.Offer(ctx, before.SpotType, before.HeldSpot)
+	}
+	return e, nil
+}
+
+// close moves an active entry to status; ErrClosed means another request
+// got there first.
+func (s *Service) close(id int, status string) (Entry, error) {
+	qr := `UPDATE waitlist_entries SET status = $1, hold_expires_at = null where id = $2 and status in ('waiting', 'held')`
+	res, err := s.DB.Exec(qr, status, id)
+	if err != nil {
+		return Entry{}, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return Entry{}, err
+	} else if n == 0 {
+		return Entry{}, ErrClosed
+	}
+	return s.Get(id)
+}
+
+// Queryer is satisfied by *sql.DB and *sql.Tx.
+type Queryer interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// HoldSpot holds spotNumber for the oldest entry waiting for spotType and
+// returns the entry's ID, or 0 if no one is waiting. Run it in the
+// transaction that frees the spot, so the hold is in place before anyone
+// else can take it, and call Offered once that has committed.
+func (s *Service) HoldSpot(q Queryer, spotType, spotNumber string) (int, error) {
+	// SKIP LOCKED lets concurrent offers of two spots go to two entries
+	qr := `UPDATE waitlist_entries SET status = $1, held_spot = $2, hold_expires_at = $3 where id = (
+		select id from waitlist_entries where spot_type = $4 and status = $5 order by id limit 1 for update skip locked)
+		returning id`
+	var id int
+	err := q.QueryRow(qr, StatusHeld, spotNumber, time.Now().UTC().Add(s.Hold), spotType, StatusWaiting).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return id, err
+}
+
+// Offer holds spotNumber, which must already be free of other drivers,
+// for the oldest entry waiting for spotType and notifies its driver.
+// Failures are logged: the spot is then simply free for anyone.
+func (s *Service) Offer(ctx context.Context, spotType, spotNumber string) {
+	id, err := s.HoldSpot(s.DB, spotType, spotNumber)
+	if err != nil {
+		s.Log.Error("offering spot to waitlist failed", "spot_number", spotNumber, "error", err)
+		return
+	}
+	s.Offered(ctx, id)
+}
+
+// Offered notifies the driver of the entry HoldSpot returned. An id of 0 is
+// ignored.
+func (s *Service) Offered(ctx context.Context, id int) {
+	if id == 0 {
+		return
+	}
+	e, err := s.Get(id)
+	if err != nil {
+		s.Log.Error("waitlist lookup failed", "id", id, "error", err)
+		return
+	}
+	holds.Inc("offered")
+	s.Audit.RecordContext(ctx, "hold", "waitlist_entry", fmt.Sprint(e.ID), nil, e)
+	s.notify(ctx, Notification{Kind: KindHold, Entry: e,
+		Message: fmt.Sprintf("Spot %s is held for %s until %s.", e.HeldSpot, e.LicensePlate, e.HoldExpires.Format(time.RFC3339))})
+}
+
+// Arrived marks plate's entry fulfilled once it has parked at spotNumber.
+// If it held a different spot, that one is offered on.
+func (s *Service) Arrived(ctx context.Context, plate, spotNumber string) {
+	es, err := s.query(`where e.license_plate = $1 and e.status in ('waiting', 'held')`, plate)
+	if err != nil || len(es) == 0 {
+		if err != nil {
+			s.Log.Error("waitlist lookup failed", "license_plate", plate, "error", err)
+		}
+		return
+	}
+	before := es[0]
+	e, err := s.close(before.ID, StatusFulfilled)
+	if err != nil {
+		if err != ErrClosed {
+			s.Log.Error("closing waitlist entry failed", "id", before.ID, "error", err)
+		}
+		return
+	}
+	if before.Status == StatusHeld {
+		holds.Inc("fulfilled")
+	}
+	s.Audit.RecordContext(ctx, "fulfil", "waitlist_entry", fmt.Sprint(e.ID), before, e)
+	if before.HeldSpot != "" && before.HeldSpot != spotNumber {
+		s.Offer(ctx, before.SpotType, before.HeldSpot)
+	}
+}
+
+// expire ends holds that have run out and offers their spots on.
+func (s *Service) expire(ctx context.Context) error {
+	qr := `UPDATE waitlist_entries SET status = $1 where status = $2 and hold_expires_at <= $3 returning id`
+	rows, err := s.DB.Query(qr, StatusExpired, StatusHeld, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	for _, id := range ids {
+		e, err := s.Get(id)
+		if err != nil {
+			return err
+		}
+		holds.Inc("expired")
+		s.Audit.RecordContext(ctx, "expire", "waitlist_entry", fmt.Sprint(id), nil, e)
+		s.notify(ctx, Notification{Kind: KindExpired, Entry: e,
+			Message: fmt.Sprintf("The hold on spot %s for %s has expired.", e.HeldSpot, e.LicensePlate)})
+		s.Offer(ctx, e.SpotType, e.HeldSpot)
+	}
+	return nil
+}
+
+func (s *Service) notify(ctx context.Context, n Notification) {
+	if err := s.Notifier.Notify(ctx, n); err != nil {
+		s.Log.Error("waitlist notification failed", "id", n.Entry.ID, "kind", n.Kind, "error", err)
+	}
+}
+
+// Run expires holds every CheckInterval until ctx is cancelled.
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.CheckInterval)
+	defer ticker.Stop()
+	for {
+		if err := s.expire(ctx); err != nil {
+			s.Log.Error("expiring waitlist holds failed", "error", err)
+		} else {
+			s.lastCheck.Store(time.Now().UnixNano())
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check reports whether the expiry loop has run recently.
+func (s *Service) Check(ctx context.Context) error {
+	last := s.lastCheck.Load()
+	if last == 0 {
+		return fmt.Errorf("waitlist has not completed an expiry check")
+	}
+	if age := time.Since(time.Unix(0, last)); age > 5*s.CheckInterval+time.Minute {
+		return fmt.Errorf("waitlist last checked holds %s ago", age.Round(time.Second))
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package waitlist
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"PDEA/internal/testdb"
+)
+
+// recorder is a Notifier that keeps what it was sent.
+type recorder struct {
+	mu   sync.Mutex
+	sent []Notification
+}
+
+func (r *recorder) Notify(ctx context.Context, n Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent = append(r.sent, n)
+	return nil
+}
+
+func testService(t *testing.T) (*Service, *recorder) {
+	t.Helper()
+	db := testdb.Open(t, "waitlist_test")
+	if _, err := db.Exec(`DROP TABLE IF EXISTS waitlist_entries;`); err != nil {
+		t.Fatal(err)
+	}
+	rec := &recorder{}
+	s := New(db, rec)
+	s.Log = slog.New(slog.NewTextHandler(io.Discard, nil))
+	if err := s.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	return s, rec
+}
+
+func TestHoldSpot(t *testing.T) {
+	s, _ := testService(t)
+	ctx := context.Background()
+	var ids []int
+	for _, j := range []struct{ spotType, plate string }{
+		{"compact", "AAA1"},
+		{"large", "BBB2"},
+		{"compact", "CCC3"},
+	} {
+		e, err := s.Join(ctx, j.spotType, j.plate, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, e.ID)
+	}
+	if _, err := s.Join(ctx, "large", "AAA1", ""); err != ErrWaiting {
+		t.Errorf("second Join for a waiting plate = %v, want ErrWaiting", err)
+	}
+
+	tests := []struct {
+		spotType, spot string
+		want           int
+	}{
+		{"compact", "C1", ids[0]},
+		{"compact", "C2", ids[2]},
+		{"compact", "C3", 0},
+		{"large", "L1", ids[1]},
+		{"ev", "E1", 0},
+	}
+	for _, tt := range tests {
+		got, err := s.HoldSpot(s.DB, tt.spotType, tt.spot)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("HoldSpot(%s, %s) = %d, want %d", tt.spotType, tt.spot, got, tt.want)
+		}
+	}
+	e, err := s.HeldBy("C2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e == nil || e.LicensePlate != "CCC3" || e.Status != StatusHeld || e.HoldExpires == nil {
+		t.Errorf("HeldBy(C2) = %+v, want a hold for CCC3", e)
+	}
+	if n, err := s.HeldCount("compact"); err != nil || n != 2 {
+		t.Errorf("HeldCount(compact) = %d, %v, want 2", n, err)
+	}
+}
+
+func TestExpire(t *testing.T) {
+	s, rec := testService(t)
+	ctx := context.Background()
+	first, err := s.Join(ctx, "compact", "AAA1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	next, err := s.Join(ctx, "compact", "BBB2", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Hold = -time.Second // the hold has run out as soon as it is placed
+	s.Offer(ctx, "compact", "C1")
+	s.Hold = time.Hour
+	if err := s.expire(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		id     int
+		status string
+		spot   string
+	}{
+		{first.ID, StatusExpired, "C1"},
+		{next.ID, StatusHeld, "C1"},
+	}
+	for _, tt := range tests {
+		e, err := s.Get(tt.id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e.Status != tt.status || e.HeldSpot != tt.spot {
+			t.Errorf("entry %d = %s at %q, want %s at %q", tt.id, e.Status, e.HeldSpot, tt.status, tt.spot)
+		}
+	}
+	var kinds []string
+	for _, n := range rec.sent {
+		kinds = append(kinds, n.Kind+" "+n.Entry.LicensePlate)
+	}
+	want := []string{"hold AAA1", "expired AAA1", "hold BBB2"}
+	if len(kinds) != len(want) {
+		t.Fatalf("notifications = %v, want %v", kinds, want)
+	}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Errorf("notifications = %v, want %v", kinds, want)
+			break
+		}
+	}
+
+	// a second run finds nothing more to expire
+	if err := s.expire(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.sent) != len(want) {
+		t.Errorf("second expire sent %d more notifications", len(rec.sent)-len(want))
+	}
+}
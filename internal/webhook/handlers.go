@@ -0,0 +1,135 @@
+package webhook
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"PDEA/internal/logging"
+
+	"github.com/gorilla/mux"
+)
+
+func (d *Dispatcher) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/webhooks", d.CreateSubscriptionHandler).Methods("POST")
+	router.HandleFunc("/api/webhooks", d.ListSubscriptionsHandler).Methods("GET")
+	router.HandleFunc("/api/webhooks/deliveries", d.ListDeliveriesHandler).Methods("GET")
+	router.HandleFunc("/api/webhooks/deliveries/{id}", d.GetDeliveryHandler).Methods("GET")
+	router.HandleFunc("/api/webhooks/deliveries/{id}/replay", d.ReplayHandler).Methods("POST")
+	router.HandleFunc("/api/webhooks/{id}", d.DeleteSubscriptionHandler).Methods("DELETE")
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	resJson, _ := json.Marshal(v)
+	w.WriteHeader(status)
+	w.Write(resJson)
+}
+
+func (d *Dispatcher) CreateSubscriptionHandler(w http.ResponseWriter, r *http.Request) {
+	var reqBody Subscription
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	u, err := url.Parse(reqBody.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		http.Error(w, "Invalid webhook url", http.StatusBadRequest)
+		return
+	}
+	s, err := d.CreateSubscription(reqBody)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("create webhook failed", "error", err)
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+	created := s
+	created.Secret = ""
+	d.Audit.Record(r, "create", "webhook_subscription", strconv.Itoa(s.ID), nil, created)
+	writeJSON(w, http.StatusCreated, s)
+}
+
+func (d *Dispatcher) ListSubscriptionsHandler(w http.ResponseWriter, r *http.Request) {
+	subs, err := d.subscriptions(false)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("list webhooks failed", "error", err)
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+	for i := range subs {
+		subs[i].Secret = ""
+	}
+	writeJSON(w, http.StatusOK, subs)
+}
+
+func (d *Dispatcher) DeleteSubscriptionHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid id", http.StatusBadRequest)
+		return
+	}
+	found, err := d.DeleteSubscription(id)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("delete webhook failed", "error", err)
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return
+	}
+	d.Audit.Record(r, "delete", "webhook_subscription", strconv.Itoa(id), nil, nil)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (d *Dispatcher) ListDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	subID, _ := strconv.Atoi(r.URL.Query().Get("subscription_id"))
+	res, err := d.Deliveries(r.URL.Query().Get("status"), subID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("list deliveries failed", "error", err)
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (d *Dispatcher) GetDeliveryHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid id", http.StatusBadRequest)
+		return
+	}
+	dl, err := d.Delivery(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Delivery not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logging.FromContext(r.Context()).Error("get delivery failed", "error", err)
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, dl)
+}
+
+func (d *Dispatcher) ReplayHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid id", http.StatusBadRequest)
+		return
+	}
+	found, err := d.Replay(id)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("replay delivery failed", "error", err)
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		http.Error(w, "Delivery not found", http.StatusNotFound)
+		return
+	}
+	d.Audit.Record(r, "replay", "webhook_delivery", strconv.Itoa(id), nil, nil)
+	w.WriteHeader(http.StatusAccepted)
+}
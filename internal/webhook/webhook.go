@@ -0,0 +1,470 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"PDEA/internal/audit"
+	"PDEA/internal/events"
+)
+
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusFailed    = "failed"
+
+	SignatureHeader = "X-PDEA-Signature"
+)
+
+type Subscription struct {
+	ID         int       `json:"id"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	Secret     string    `json:"secret,omitempty"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type Delivery struct {
+	ID             int        `json:"id"`
+	SubscriptionID int        `json:"subscription_id"`
+	EventType      string     `json:"event_type"`
+	Payload        string     `json:"payload"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	LastStatusCode int        `json:"last_status_code,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	Log            []Attempt  `json:"log,omitempty"`
+}
+
+type Attempt struct {
+	AttemptedAt time.Time `json:"attempted_at"`
+	StatusCode  int       `json:"status_code,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	DurationMs  int64     `json:"duration_ms"`
+}
+
+// Dispatcher persists deliveries in webhook_deliveries and sends them from a
+// polling loop, so pending deliveries survive a restart. Several processes
+// can share the queue; a row is claimed with FOR UPDATE SKIP LOCKED and
+// leased for one attempt, and its outcome is only recorded under that lease.
+type Dispatcher struct {
+	DB           *sql.DB
+	Client       *http.Client
+	MaxAttempts  int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+	PollInterval time.Duration
+	BatchSize    int
+	Audit        *audit.Logger
+	Log          *slog.Logger
+
+	lastPoll atomic.Int64
+}
+
+func NewDispatcher(db *sql.DB) *Dispatcher {
+	return &Dispatcher{
+		DB:           db,
+		Client:       &http.Client{Timeout: 10 * time.Second},
+		MaxAttempts:  8,
+		BaseBackoff:  5 * time.Second,
+		MaxBackoff:   time.Hour,
+		PollInterval: 2 * time.Second,
+		BatchSize:    20,
+		Log:          slog.Default(),
+	}
+}
+
+func (d *Dispatcher) Migrate() error {
+	schemaSQL := `
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+id SERIAL PRIMARY KEY,
+url TEXT NOT NULL,
+event_types TEXT NOT NULL,
+secret TEXT NOT NULL,
+active BOOLEAN NOT NULL DEFAULT TRUE,
+created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+id SERIAL PRIMARY KEY,
+subscription_id INT NOT NULL REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+event_type TEXT NOT NULL,
+payload TEXT NOT NULL,
+status TEXT NOT NULL,
+attempts INT NOT NULL DEFAULT 0,
+next_attempt_at TIMESTAMPTZ NOT NULL,
+last_status_code INT NOT NULL DEFAULT 0,
+last_error TEXT NOT NULL DEFAULT '',
+created_at TIMESTAMPTZ NOT NULL,
+delivered_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due ON webhook_deliveries (status, next_attempt_at);
+
+ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS lease_token TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS webhook_attempts (
+id SERIAL PRIMARY KEY,
+delivery_id INT NOT NULL REFERENCES webhook_deliveries(id) ON DELETE CASCADE,
+attempted_at TIMESTAMPTZ NOT NULL,
+status_code INT NOT NULL DEFAULT 0,
+error TEXT NOT NULL DEFAULT '',
+duration_ms BIGINT NOT NULL
+);
+`
+	_, err := d.DB.Exec(schemaSQL)
+	return err
+}
+
+func matches(eventTypes []string, evType string) bool {
+	for _, t := range eventTypes {
+		if t == "*" || t == evType {
+			return true
+		}
+	}
+	return false
+}
+
+// Enqueue stores one pending delivery per active subscription interested in ev.
+func (d *Dispatcher) Enqueue(ev events.Event) error {
+	return enqueue(d.DB, ev)
+}
+
+// EnqueueTx is Enqueue in tx, the transaction recording ev, so the
+// deliveries are committed with the change or not at all.
+func (d *Dispatcher) EnqueueTx(tx *sql.Tx, ev events.Event) error {
+	return enqueue(tx, ev)
+}
+
+func enqueue(q querier, ev events.Event) error {
+	subs, err := listSubscriptions(q, true)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	qr := `INSERT INTO webhook_deliveries (subscription_id, event_type, payload, status, next_attempt_at, created_at) VALUES ($1, $2, $3, $4, $5, $5);`
+	for _, s := range subs {
+		if !matches(s.EventTypes, ev.Type) {
+			continue
+		}
+		if _, err := q.Exec(qr, s.ID, ev.Type, string(payload), StatusPending, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.PollInterval)
+	defer ticker.Stop()
+	for {
+		if err := d.deliverDue(ctx); err != nil {
+			d.Log.Error("webhook delivery failed", "error", err)
+		} else {
+			d.lastPoll.Store(time.Now().UnixNano())
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check reports whether the delivery loop has completed a poll recently.
+func (d *Dispatcher) Check(ctx context.Context) error {
+	last := d.lastPoll.Load()
+	if last == 0 {
+		return fmt.Errorf("webhook dispatcher has not completed a poll")
+	}
+	if age := time.Since(time.Unix(0, last)); age > 5*d.PollInterval+time.Minute {
+		return fmt.Errorf("webhook dispatcher last polled %s ago", age.Round(time.Second))
+	}
+	return nil
+}
+
+type claimed struct {
+	Delivery
+	url    string
+	secret string
+	// lease identifies this claim; the result is only recorded while the
+	// row still carries it.
+	lease string
+}
+
+// attemptTimeout bounds one delivery attempt, Client.Timeout unless unset.
+func (d *Dispatcher) attemptTimeout() time.Duration {
+	if d.Client.Timeout > 0 {
+		return d.Client.Timeout
+	}
+	return 10 * time.Second
+}
+
+// claim leases the next due delivery, if any, for one attempt. The lease
+// outlasts the attempt, so no other dispatcher sends the row meanwhile.
+func (d *Dispatcher) claim() (claimed, bool, error) {
+	qr := `
+UPDATE webhook_deliveries wd SET next_attempt_at = now() + make_interval(secs => $1), lease_token = $2
+FROM webhook_subscriptions ws
+WHERE wd.subscription_id = ws.id AND wd.id = (
+	SELECT id FROM webhook_deliveries
+	WHERE status = 'pending' AND next_attempt_at <= now()
+	ORDER BY id LIMIT 1 FOR UPDATE SKIP LOCKED)
+RETURNING wd.id, wd.subscription_id, wd.event_type, wd.payload, wd.attempts, ws.url, ws.secret;`
+	c := claimed{lease: newSecret()}
+	lease := d.attemptTimeout() + 30*time.Second
+	err := d.DB.QueryRow(qr, lease.Seconds(), c.lease).Scan(&c.ID, &c.SubscriptionID, &c.EventType, &c.Payload, &c.Attempts, &c.url, &c.secret)
+	if err == sql.ErrNoRows {
+		return c, false, nil
+	}
+	return c, err == nil, err
+}
+
+// deliverDue sends up to BatchSize due deliveries, claiming them one at a
+// time so a lease never waits on the rest of the batch.
+func (d *Dispatcher) deliverDue(ctx context.Context) error {
+	for i := 0; i < d.BatchSize && ctx.Err() == nil; i++ {
+		c, ok, err := d.claim()
+		if err != nil || !ok {
+			return err
+		}
+		d.attempt(ctx, c)
+	}
+	return nil
+}
+
+func Sign(secret string, ts int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", ts)
+	mac.Write(body)
+	return fmt.Sprintf("t=%d,v1=%s", ts, hex.EncodeToString(mac.Sum(nil)))
+}
+
+// Verify checks a signature header produced by Sign; receivers can use it
+// directly or reimplement the HMAC-SHA256 over "<timestamp>.<body>".
+func Verify(secret, header string, body []byte) bool {
+	var ts int64
+	for _, part := range strings.Split(header, ",") {
+		if v, ok := strings.CutPrefix(part, "t="); ok {
+			ts, _ = strconv.ParseInt(v, 10, 64)
+		}
+	}
+	return hmac.Equal([]byte(Sign(secret, ts, body)), []byte(header))
+}
+
+func (d *Dispatcher) attempt(ctx context.Context, c claimed) {
+	start := time.Now()
+	body := []byte(c.Payload)
+	var statusCode int
+	reqCtx, cancel := context.WithTimeout(ctx, d.attemptTimeout())
+	defer cancel()
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err == nil {
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-PDEA-Event", c.EventType)
+		req.Header.Set("X-PDEA-Delivery", strconv.Itoa(c.ID))
+		req.Header.Set(SignatureHeader, Sign(c.secret, start.Unix(), body))
+		var resp *http.Response
+		resp, err = d.Client.Do(req)
+		if err == nil {
+			statusCode = resp.StatusCode
+			resp.Body.Close()
+			if statusCode < 200 || statusCode > 299 {
+				err = fmt.Errorf("receiver returned %d", statusCode)
+			}
+		}
+	}
+	elapsed := time.Since(start)
+	errText := ""
+	if err != nil {
+		errText = err.Error()
+	}
+	_, logErr := d.DB.Exec(`INSERT INTO webhook_attempts (delivery_id, attempted_at, status_code, error, duration_ms) VALUES ($1, $2, $3, $4, $5);`,
+		c.ID, start.UTC(), statusCode, errText, elapsed.Milliseconds())
+	if logErr != nil {
+		d.Log.Error("webhook attempt log failed", "error", logErr)
+	}
+
+	attempts := c.Attempts + 1
+	status := StatusPending
+	var deliveredAt *time.Time
+	next := time.Now().UTC().Add(d.backoff(attempts))
+	if err == nil {
+		status = StatusDelivered
+		now := time.Now().UTC()
+		deliveredAt = &now
+	} else if attempts >= d.MaxAttempts {
+		status = StatusFailed
+	}
+	qr := `UPDATE webhook_deliveries SET status = $1, attempts = $2, next_attempt_at = $3, last_status_code = $4, last_error = $5, delivered_at = $6, lease_token = ''
+where id = $7 and lease_token = $8;`
+	res, err := d.DB.Exec(qr, status, attempts, next, statusCode, errText, deliveredAt, c.ID, c.lease)
+	if err != nil {
+		d.Log.Error("webhook delivery update failed", "error", err)
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		// replayed, or the lease ran out and another dispatcher claimed it
+		d.Log.Warn("webhook delivery lease lost", "delivery", c.ID)
+	}
+}
+
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	b := d.BaseBackoff
+	for i := 1; i < attempts; i++ {
+		b *= 2
+		if b >= d.MaxBackoff {
+			return d.MaxBackoff
+		}
+	}
+	return b
+}
+
+func newSecret() string {
+	buf := make([]byte, 24)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// querier is what subscriptions and enqueue need from a *sql.DB or *sql.Tx.
+type querier interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+func (d *Dispatcher) subscriptions(activeOnly bool) ([]Subscription, error) {
+	return listSubscriptions(d.DB, activeOnly)
+}
+
+func listSubscriptions(q querier, activeOnly bool) ([]Subscription, error) {
+	qr := `select id, url, event_types, secret, active, created_at from webhook_subscriptions order by id;`
+	rows, err := q.Query(qr)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []Subscription
+	for rows.Next() {
+		var s Subscription
+		var types string
+		if err := rows.Scan(&s.ID, &s.URL, &types, &s.Secret, &s.Active, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		if activeOnly && !s.Active {
+			continue
+		}
+		s.EventTypes = strings.Split(types, ",")
+		res = append(res, s)
+	}
+	return res, rows.Err()
+}
+
+func (d *Dispatcher) CreateSubscription(s Subscription) (Subscription, error) {
+	if s.Secret == "" {
+		s.Secret = newSecret()
+	}
+	if len(s.EventTypes) == 0 {
+		s.EventTypes = []string{"*"}
+	}
+	s.Active = true
+	s.CreatedAt = time.Now().UTC()
+	qr := `INSERT INTO webhook_subscriptions (url, event_types, secret, active, created_at) VALUES ($1, $2, $3, $4, $5) RETURNING id;`
+	err := d.DB.QueryRow(qr, s.URL, strings.Join(s.EventTypes, ","), s.Secret, s.Active, s.CreatedAt).Scan(&s.ID)
+	return s, err
+}
+
+func (d *Dispatcher) DeleteSubscription(id int) (bool, error) {
+	res, err := d.DB.Exec(`DELETE FROM webhook_subscriptions where id = $1;`, id)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+func (d *Dispatcher) Deliveries(status string, subscriptionID int) ([]Delivery, error) {
+	qr := `select id, subscription_id, event_type, payload, status, attempts, next_attempt_at, last_status_code, last_error, created_at, delivered_at
+from webhook_deliveries where ($1 = '' or status = $1) and ($2 = 0 or subscription_id = $2) order by id desc limit 500;`
+	rows, err := d.DB.Query(qr, status, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []Delivery
+	for rows.Next() {
+		dl, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, dl)
+	}
+	return res, rows.Err()
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanDelivery(row scanner) (Delivery, error) {
+	var dl Delivery
+	var deliveredAt sql.NullTime
+	err := row.Scan(&dl.ID, &dl.SubscriptionID, &dl.EventType, &dl.Payload, &dl.Status, &dl.Attempts, &dl.NextAttemptAt, &dl.LastStatusCode, &dl.LastError, &dl.CreatedAt, &deliveredAt)
+	if deliveredAt.Valid {
+		dl.DeliveredAt = &deliveredAt.Time
+	}
+	return dl, err
+}
+
+func (d *Dispatcher) Delivery(id int) (Delivery, error) {
+	qr := `select id, subscription_id, event_type, payload, status, attempts, next_attempt_at, last_status_code, last_error, created_at, delivered_at
+from webhook_deliveries where id = $1;`
+	dl, err := scanDelivery(d.DB.QueryRow(qr, id))
+	if err != nil {
+		return dl, err
+	}
+	rows, err := d.DB.Query(`select attempted_at, status_code, error, duration_ms from webhook_attempts where delivery_id = $1 order by id;`, id)
+	if err != nil {
+		return dl, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var a Attempt
+		if err := rows.Scan(&a.AttemptedAt, &a.StatusCode, &a.Error, &a.DurationMs); err != nil {
+			return dl, err
+		}
+		dl.Log = append(dl.Log, a)
+	}
+	return dl, rows.Err()
+}
+
+// Replay puts a delivery back on the queue as if it had never been tried.
+func (d *Dispatcher) Replay(id int) (bool, error) {
+	qr := `UPDATE webhook_deliveries SET status = $1, attempts = 0, next_attempt_at = $2, delivered_at = NULL, lease_token = '' where id = $3;`
+	res, err := d.DB.Exec(qr, StatusPending, time.Now().UTC(), id)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
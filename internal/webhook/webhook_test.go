@@ -0,0 +1,361 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"PDEA/internal/events"
+	"PDEA/internal/testdb"
+)
+
+func TestSignVerify(t *testing.T) {
+	body := []byte(`{"id":7,"type":"vehicle.entry"}`)
+	header := Sign("secret", 1709285400, body)
+	if want := "t=1709285400,v1="; header[:len(want)] != want {
+		t.Fatalf("Sign = %q, want prefix %q", header, want)
+	}
+
+	tests := []struct {
+		name   string
+		secret string
+		header string
+		body   []byte
+		want   bool
+	}{
+		{"valid", "secret", header, body, true},
+		{"other secret", "other", header, body, false},
+		{"other body", "secret", header, []byte(`{"id":8,"type":"vehicle.entry"}`), false},
+		{"other timestamp", "secret", "t=1709285401" + header[len("t=1709285400"):], body, false},
+		{"no timestamp", "secret", header[len("t=1709285400,"):], body, false},
+		{"no signature", "secret", "t=1709285400", body, false},
+		{"empty", "secret", "", body, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Verify(tt.secret, tt.header, tt.body); got != tt.want {
+				t.Errorf("Verify(%q, %q) = %v, want %v", tt.secret, tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	d := &Dispatcher{BaseBackoff: 5 * time.Second, MaxBackoff: time.Minute}
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 5 * time.Second},
+		{2, 10 * time.Second},
+		{3, 20 * time.Second},
+		{4, 40 * time.Second},
+		{5, time.Minute},
+		{20, time.Minute},
+	}
+	for _, tt := range tests {
+		if got := d.backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+// testDispatcher returns a dispatcher on an empty copy of the webhook tables
+// in the webhook_test schema of PDEA_TEST_DATABASE_URL. The tables are
+// wiped, so never point it at a database that matters.
+func testDispatcher(t *testing.T) *Dispatcher {
+	t.Helper()
+	db := testdb.Open(t, "webhook_test")
+	if _, err := db.Exec(`DROP TABLE IF EXISTS webhook_attempts, webhook_deliveries, webhook_subscriptions;`); err != nil {
+		t.Fatal(err)
+	}
+	d := NewDispatcher(db)
+	if err := d.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+// receiver is a webhook endpoint that answers with the queued status codes,
+// then 200, and keeps what it was sent.
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	got      []*http.Request
+	bodies   [][]byte
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.got = append(rc.got, r)
+	rc.bodies = append(rc.bodies, body)
+	status := http.StatusOK
+	if len(rc.statuses) > 0 {
+		status, rc.statuses = rc.statuses[0], rc.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func (rc *receiver) request(i int) (*http.Request, []byte) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.got[i], rc.bodies[i]
+}
+
+func (rc *receiver) calls() int {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return len(rc.got)
+}
+
+func TestDeliverRetryAndReplay(t *testing.T) {
+	d := testDispatcher(t)
+	d.BaseBackoff = 10 * time.Minute
+	d.MaxBackoff = time.Hour
+	rc := &receiver{statuses: []int{http.StatusInternalServerError}}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+	d.Client = srv.Client()
+
+	sub, err := d.CreateSubscription(Subscription{URL: srv.URL, EventTypes: []string{events.VehicleEntry}, Active: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.CreateSubscription(Subscription{URL: srv.URL, EventTypes: []string{events.SpotCreated}, Active: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Enqueue(events.Event{ID: 7, Type: events.VehicleEntry, Time: time.Now().UTC(), Plate: "ABC123"}); err != nil {
+		t.Fatal(err)
+	}
+	queued, err := d.Deliveries(StatusPending, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queued) != 1 || queued[0].SubscriptionID != sub.ID {
+		t.Fatalf("Enqueue queued %+v, want one delivery for subscription %d", queued, sub.ID)
+	}
+	id := queued[0].ID
+	ctx := context.Background()
+
+	// The first attempt gets a 500 and is pushed back by the base backoff.
+	before := time.Now()
+	if err := d.deliverDue(ctx); err != nil {
+		t.Fatal(err)
+	}
+	after := time.Now()
+	if n := rc.calls(); n != 1 {
+		t.Fatalf("receiver called %d times, want 1", n)
+	}
+	r, body := rc.request(0)
+	if !Verify(sub.Secret, r.Header.Get(SignatureHeader), body) {
+		t.Errorf("signature %q does not verify with the subscription secret", r.Header.Get(SignatureHeader))
+	}
+	if Verify("other", r.Header.Get(SignatureHeader), body) {
+		t.Errorf("signature %q verifies with another secret", r.Header.Get(SignatureHeader))
+	}
+	if got := r.Header.Get("X-PDEA-Event"); got != events.VehicleEntry {
+		t.Errorf("X-PDEA-Event = %q, want %q", got, events.VehicleEntry)
+	}
+	if got := r.Header.Get("X-PDEA-Delivery"); got != strconv.Itoa(id) {
+		t.Errorf("X-PDEA-Delivery = %q, want %d", got, id)
+	}
+	dl, err := d.Delivery(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dl.Status != StatusPending || dl.Attempts != 1 || dl.LastStatusCode != http.StatusInternalServerError {
+		t.Errorf("after a 500: status %q, attempts %d, last status %d, want pending, 1, 500", dl.Status, dl.Attempts, dl.LastStatusCode)
+	}
+	if next := dl.NextAttemptAt; next.Before(before.Add(d.BaseBackoff).Add(-time.Second)) || next.After(after.Add(d.BaseBackoff).Add(time.Second)) {
+		t.Errorf("next attempt at %v, want about %v after the attempt", next, d.BaseBackoff)
+	}
+	if len(dl.Log) != 1 || dl.Log[0].StatusCode != http.StatusInternalServerError || dl.Log[0].Error == "" {
+		t.Errorf("attempts log = %+v, want one failed attempt with status 500", dl.Log)
+	}
+
+	// Not due yet, so another poll leaves it alone.
+	if err := d.deliverDue(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := rc.calls(); n != 1 {
+		t.Fatalf("receiver called %d times before the backoff elapsed, want 1", n)
+	}
+
+	if _, err := d.DB.Exec(`UPDATE webhook_deliveries SET next_attempt_at = now() WHERE id = $1;`, id); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.deliverDue(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := rc.calls(); n != 2 {
+		t.Fatalf("receiver called %d times after the backoff, want 2", n)
+	}
+	dl, err = d.Delivery(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dl.Status != StatusDelivered || dl.Attempts != 2 || dl.DeliveredAt == nil {
+		t.Errorf("after a 200: status %q, attempts %d, delivered at %v, want delivered, 2, set", dl.Status, dl.Attempts, dl.DeliveredAt)
+	}
+	if len(dl.Log) != 2 || dl.Log[1].StatusCode != http.StatusOK || dl.Log[1].Error != "" {
+		t.Errorf("attempts log = %+v, want a successful second attempt", dl.Log)
+	}
+
+	// Replay puts the delivered row back on the queue and it is sent again.
+	ok, err := d.Replay(id)
+	if err != nil || !ok {
+		t.Fatalf("Replay(%d) = %v, %v, want true", id, ok, err)
+	}
+	dl, err = d.Delivery(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dl.Status != StatusPending || dl.Attempts != 0 || dl.DeliveredAt != nil {
+		t.Errorf("after replay: status %q, attempts %d, delivered at %v, want pending, 0, unset", dl.Status, dl.Attempts, dl.DeliveredAt)
+	}
+	if err := d.deliverDue(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := rc.calls(); n != 3 {
+		t.Fatalf("receiver called %d times after replay, want 3", n)
+	}
+	if _, replayed := rc.request(2); string(replayed) != string(body) {
+		t.Errorf("replayed body = %s, want %s", replayed, body)
+	}
+	dl, err = d.Delivery(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dl.Status != StatusDelivered || dl.Attempts != 1 || len(dl.Log) != 3 {
+		t.Errorf("after redelivery: status %q, attempts %d, %d log entries, want delivered, 1, 3", dl.Status, dl.Attempts, len(dl.Log))
+	}
+
+	if ok, err := d.Replay(id + 1000); err != nil || ok {
+		t.Errorf("Replay of a missing delivery = %v, %v, want false", ok, err)
+	}
+}
+
+func TestDeliverGivesUp(t *testing.T) {
+	d := testDispatcher(t)
+	d.MaxAttempts = 2
+	rc := &receiver{statuses: []int{http.StatusInternalServerError, http.StatusBadGateway}}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+	d.Client = srv.Client()
+
+	if _, err := d.CreateSubscription(Subscription{URL: srv.URL, Active: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Enqueue(events.Event{ID: 1, Type: events.SpotCreated, SpotNumber: "A1"}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := d.DB.Exec(`UPDATE webhook_deliveries SET next_attempt_at = now();`); err != nil {
+			t.Fatal(err)
+		}
+		if err := d.deliverDue(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := rc.calls(); n != 2 {
+		t.Errorf("receiver called %d times, want MaxAttempts (2)", n)
+	}
+	failed, err := d.Deliveries(StatusFailed, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failed) != 1 || failed[0].Attempts != 2 || failed[0].LastStatusCode != http.StatusBadGateway {
+		t.Errorf("failed deliveries = %+v, want one after 2 attempts ending in 502", failed)
+	}
+}
+
+func TestLostLeaseIsNotRecorded(t *testing.T) {
+	d := testDispatcher(t)
+	rc := &receiver{}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+	d.Client = srv.Client()
+
+	if _, err := d.CreateSubscription(Subscription{URL: srv.URL, Active: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Enqueue(events.Event{ID: 1, Type: events.SpotCreated, SpotNumber: "A1"}); err != nil {
+		t.Fatal(err)
+	}
+	first, ok, err := d.claim()
+	if err != nil || !ok {
+		t.Fatalf("claim = %v, %v, want a delivery", ok, err)
+	}
+	if _, ok, err := d.claim(); err != nil || ok {
+		t.Fatalf("second claim while leased = %v, %v, want nothing", ok, err)
+	}
+	var secs float64
+	if err := d.DB.QueryRow(`SELECT extract(epoch from next_attempt_at - now())::float8 FROM webhook_deliveries WHERE id = $1;`, first.ID).Scan(&secs); err != nil {
+		t.Fatal(err)
+	}
+	if lease := time.Duration(secs * float64(time.Second)); lease < d.attemptTimeout() {
+		t.Errorf("lease of %v, want longer than one attempt (%v)", lease, d.attemptTimeout())
+	}
+
+	// The lease runs out and another dispatcher takes the row over.
+	if _, err := d.DB.Exec(`UPDATE webhook_deliveries SET next_attempt_at = now() WHERE id = $1;`, first.ID); err != nil {
+		t.Fatal(err)
+	}
+	second, ok, err := d.claim()
+	if err != nil || !ok || second.ID != first.ID {
+		t.Fatalf("claim after the lease ran out = %+v, %v, %v, want delivery %d", second, ok, err, first.ID)
+	}
+	d.attempt(context.Background(), first)
+	dl, err := d.Delivery(first.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dl.Status != StatusPending || dl.Attempts != 0 {
+		t.Errorf("after an attempt under a lost lease: status %q, attempts %d, want pending, 0", dl.Status, dl.Attempts)
+	}
+	d.attempt(context.Background(), second)
+	if dl, err = d.Delivery(first.ID); err != nil {
+		t.Fatal(err)
+	}
+	if dl.Status != StatusDelivered || dl.Attempts != 1 || len(dl.Log) != 2 {
+		t.Errorf("after the current lease's attempt: status %q, attempts %d, %d log entries, want delivered, 1, 2", dl.Status, dl.Attempts, len(dl.Log))
+	}
+}
+
+func TestEnqueueTx(t *testing.T) {
+	d := testDispatcher(t)
+	if _, err := d.CreateSubscription(Subscription{URL: "http://127.0.0.1:1", Active: true}); err != nil {
+		t.Fatal(err)
+	}
+	for _, commit := range []bool{false, true} {
+		tx, err := d.DB.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := d.EnqueueTx(tx, events.Event{ID: 1, Type: events.VehicleExit}); err != nil {
+			t.Fatal(err)
+		}
+		if commit {
+			err = tx.Commit()
+		} else {
+			err = tx.Rollback()
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	queued, err := d.Deliveries(StatusPending, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queued) != 1 {
+		t.Errorf("deliveries after a rolled back and a committed enqueue = %d, want 1", len(queued))
+	}
+}
@@ -0,0 +1,608 @@
+// pdeactl is an operator client for the spot (8080) and vehicle (8081)
+// services. It talks to the REST API only, so it needs no database access.
+//
+//	pdeactl [flags] spots list
+//	pdeactl [flags] spots get <id>
+//	pdeactl [flags] spots create -number A1 -type Compact [-available true] [-zone Z] [-connector CCS2 -max-kw 50] [-ev-only] [-accessible]
+//	pdeactl [flags] spots update <id> [-number A1] [-type Large] [-available false] [-zone Z] [-connector CCS2] [-max-kw 50] [-ev-only=false] [-accessible=false]
+//	pdeactl [flags] spots delete <id>
+//	pdeactl [flags] entry -spot A1 -plate KA01AB1234 [-ev] [-permit P-1234] [-gate N-IN]
+//	pdeactl [flags] exit -spot A1 -plate KA01AB1234 [-override reason] [-gate N-OUT]
+//	pdeactl [flags] exit -ticket <token> [-override reason] [-gate N-OUT]
+//	pdeactl [flags] lost-ticket -plate KA01AB1234 [-override reason] [-gate N-OUT]
+//	pdeactl [flags] pay (-stay 12 | -ticket <token>) [-lost-ticket] -method card
+//	pdeactl [flags] redeem -code CAFE10 (-stay 12 | -ticket <token>)
+//	pdeactl [flags] charge start (-stay 12 | -ticket <token>)
+//	pdeactl [flags] charge stop <session id>
+//	pdeactl [flags] charge meter <session id> -wh 7500
+//	pdeactl [flags] waitlist join -type Compact -plate KA01AB1234 [-contact +911234567890]
+//	pdeactl [flags] waitlist list [-type Compact]
+//	pdeactl [flags] waitlist get <entry id>
+//	pdeactl [flags] waitlist cancel <entry id>
+//	pdeactl [flags] gate open <gate id>
+//	pdeactl [flags] reads list [-status review]
+//	pdeactl [flags] reads resolve <read id> [-plate KA01AB1234] [-spot A1] [-override reason]
+//	pdeactl [flags] reads dismiss <read id>
+//	pdeactl [flags] records (-spot A1 | -plate KA01AB1234)
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"PDEA/internal/parking"
+)
+
+type client struct {
+	spotURL    string
+	vehicleURL string
+	apiKey     string
+	http       *http.Client
+}
+
+// do sends body as JSON and decodes a 2xx response into out. Non-2xx
+// responses become errors carrying the server's message.
+func (c *client) do(method, base, path string, body, out interface{}) error {
+	var rd io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		rd = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, strings.TrimRight(base, "/")+path, rd)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+	res, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("%s %s: %s: %s", method, path, res.Status, strings.TrimSpace(string(data)))
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+func main() {
+	fs := flag.NewFlagSet("pdeactl", flag.ExitOnError)
+	spotURL := fs.String("spot-url", envOr("PDEA_SPOT_URL", "http://localhost:8080"), "base URL of the spot service")
+	vehicleURL := fs.String("vehicle-url", envOr("PDEA_VEHICLE_URL", "http://localhost:8081"), "base URL of the vehicle service")
+	apiKey := fs.String("api-key", os.Getenv("PDEA_API_KEY"), "API key sent as X-API-Key")
+	output := fs.String("o", "table", "output format: table or json")
+	timeout := fs.Duration("timeout", 10*time.Second, "per-request timeout")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: pdeactl [flags] <spots|entry|exit|lost-ticket|pay|redeem|charge|waitlist|gate|reads|records> ...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(os.Args[1:])
+	if *output != "table" && *output != "json" {
+		fmt.Fprintln(os.Stderr, "-o must be table or json")
+		os.Exit(2)
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	c := &client{spotURL: *spotURL, vehicleURL: *vehicleURL, apiKey: *apiKey, http: &http.Client{Timeout: *timeout}}
+	args := fs.Args()
+	var out interface{}
+	var err error
+	switch args[0] {
+	case "spots":
+		out, err = runSpots(c, args[1:])
+	case "entry":
+		out, err = runStay(c, "/api/vehicle-entries", args[1:])
+	case "exit":
+		out, err = runStay(c, "/api/vehicle-exits", args[1:])
+	case "lost-ticket":
+		out, err = runLostTicket(c, args[1:])
+	case "pay":
+		out, err = runPay(c, args[1:])
+	case "redeem":
+		out, err = runRedeem(c, args[1:])
+	case "charge":
+		out, err = runCharge(c, args[1:])
+	case "waitlist":
+		out, err = runWaitlist(c, args[1:])
+	case "gate":
+		out, err = runGate(c, args[1:])
+	case "reads":
+		out, err = runReads(c, args[1:])
+	case "records":
+		out, err = runRecords(c, args[1:])
+	default:
+		err = fmt.Errorf("unknown command %q", args[0])
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+	if *output == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(out)
+		return
+	}
+	printTable(os.Stdout, out)
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+func runSpots(c *client, args []string) (interface{}, error) {
+	if len(args) == 0 {
+		return nil, errors.New("spots needs one of: list, get, create, update, delete")
+	}
+	switch args[0] {
+	case "list":
+		var spots []parking.ParkingSpot
+		err := c.do("GET", c.spotURL, "/api/parking-spots/all", nil, &spots)
+		return spots, err
+	case "get":
+		id, err := idArg(args[1:])
+		if err != nil {
+			return nil, err
+		}
+		var spot parking.ParkingSpot
+		err = c.do("GET", c.spotURL, "/api/parking-spots/"+id, nil, &spot)
+		return spot, err
+	case "create":
+		fs := flag.NewFlagSet("spots create", flag.ExitOnError)
+		number := fs.String("number", "", "spot number")
+		typ := fs.String("type", "", "Compact, Standard or Large")
+		available := fs.String("available", "true", "whether the spot is free")
+		zone := fs.String("zone", "", "zone")
+		connector := fs.String("connector", "", "charger connector, e.g. CCS2")
+		maxKW := fs.Float64("max-kw", 0, "charger power in kW")
+		evOnly := fs.Bool("ev-only", false, "only admit electric vehicles")
+		accessible := fs.Bool("accessible", false, "keep for vehicles with an accessibility permit")
+		fs.Parse(args[1:])
+		if *number == "" || *typ == "" {
+			return nil, errors.New("spots create needs -number and -type")
+		}
+		spot := parking.ParkingSpot{SpotNumber: *number, Type: *typ, IsAvailable: *available, Zone: *zone, Connector: *connector, MaxKW: *maxKW, EVOnly: *evOnly, Accessible: *accessible}
+		err := c.do("POST", c.spotURL, "/api/parking-spots", spot, &spot)
+		return spot, err
+	case "update":
+		id, err := idArg(args[1:2])
+		if err != nil {
+			return nil, err
+		}
+		// The API replaces every field, so start from the current spot and
+		// only overwrite what was passed.
+		var spot parking.ParkingSpot
+		if err = c.do("GET", c.spotURL, "/api/parking-spots/"+id, nil, &spot); err != nil {
+			return nil, err
+		}
+		fs := flag.NewFlagSet("spots update", flag.ExitOnError)
+		fs.StringVar(&spot.SpotNumber, "number", spot.SpotNumber, "spot number")
+		fs.StringVar(&spot.Type, "type", spot.Type, "Compact, Standard or Large")
+		fs.StringVar(&spot.IsAvailable, "available", spot.IsAvailable, "whether the spot is free")
+		fs.StringVar(&spot.Zone, "zone", spot.Zone, "zone")
+		fs.StringVar(&spot.Connector, "connector", spot.Connector, "charger connector, empty for none")
+		fs.Float64Var(&spot.MaxKW, "max-kw", spot.MaxKW, "charger power in kW")
+		fs.BoolVar(&spot.EVOnly, "ev-only", spot.EVOnly, "only admit electric vehicles")
+		fs.BoolVar(&spot.Accessible, "accessible", spot.Accessible, "keep for vehicles with an accessibility permit")
+		fs.Parse(args[2:])
+		err = c.do("PUT", c.spotURL, "/api/parking-spots/"+id, spot, &spot)
+		return spot, err
+	case "delete":
+		id, err := idArg(args[1:])
+		if err != nil {
+			return nil, err
+		}
+		var res struct{ Message string }
+		if err = c.do("DELETE", c.spotURL, "/api/parking-spots/"+id, nil, nil); err != nil {
+			return nil, err
+		}
+		res.Message = "deleted spot " + id
+		return res, nil
+	}
+	return nil, fmt.Errorf("unknown spots command %q", args[0])
+}
+
+func idArg(args []string) (string, error) {
+	if len(args) != 1 || args[0] == "" {
+		return "", errors.New("expected a spot id")
+	}
+	return url.PathEscape(args[0]), nil
+}
+
+func runStay(c *client, path string, args []string) (interface{}, error) {
+	fs := flag.NewFlagSet(strings.TrimPrefix(path, "/api/"), flag.ExitOnError)
+	spot := fs.String("spot", "", "spot number")
+	plate := fs.String("plate", "", "license plate")
+	var token, override *string
+	ev, permit := new(bool), new(string)
+	if path == "/api/vehicle-entries" {
+		ev = fs.Bool("ev", false, "the vehicle is electric")
+		permit = fs.String("permit", "", "accessibility permit number")
+	}
+	if path == "/api/vehicle-exits" {
+		token = fs.String("ticket", "", "ticket token, instead of -spot and -plate")
+		override = fs.String("override", "", "reason for letting an unpaid stay out (attendants only)")
+	}
+	gateID := fs.String("gate", "", "gate to open once the call succeeds")
+	fs.Parse(args)
+	var rec parking.VehichleRes
+	if token != nil && *token != "" {
+		body := map[string]string{"ticket_token": *token, "payment_override": *override, "gate_id": *gateID}
+		err := c.do("POST", c.vehicleURL, path+"/ticket", body, &rec)
+		return rec, err
+	}
+	if *spot == "" || *plate == "" {
+		return nil, errors.New("-spot and -plate are required")
+	}
+	rec = parking.VehichleRes{SpotNumber: *spot, License_plate: *plate, EV: *ev, PermitNumber: *permit, GateID: *gateID}
+	if override != nil {
+		rec.PaymentOverride = *override
+	}
+	err := c.do("POST", c.vehicleURL, path, rec, &rec)
+	return rec, err
+}
+
+func runLostTicket(c *client, args []string) (interface{}, error) {
+	fs := flag.NewFlagSet("lost-ticket", flag.ExitOnError)
+	plate := fs.String("plate", "", "license plate")
+	override := fs.String("override", "", "reason for letting an unpaid stay out")
+	gateID := fs.String("gate", "", "gate to open once the exit is recorded")
+	fs.Parse(args)
+	if *plate == "" {
+		return nil, errors.New("-plate is required")
+	}
+	rec := parking.VehichleRes{License_plate: *plate, PaymentOverride: *override, GateID: *gateID}
+	err := c.do("POST", c.vehicleURL, "/api/vehicle-exits/lost-ticket", rec, &rec)
+	return rec, err
+}
+
+// intent is the part of a payment intent pdeactl shows.
+type intent struct {
+	ID          int    `json:"id"`
+	StayID      int    `json:"stay_id"`
+	AmountCents int    `json:"amount_cents"`
+	Currency    string `json:"currency"`
+	Status      string `json:"status"`
+	Error       string `json:"error,omitempty"`
+}
+
+// runPay creates an intent for what the stay owes, then authorizes and
+// captures it in one go.
+func runPay(c *client, args []string) (interface{}, error) {
+	fs := flag.NewFlagSet("pay", flag.ExitOnError)
+	stay := fs.Int("stay", 0, "stay (vehicle record) id")
+	token := fs.String("ticket", "", "ticket token, instead of -stay")
+	lost := fs.Bool("lost-ticket", false, "include the lost-ticket charge")
+	method := fs.String("method", "", "payment method passed to the provider")
+	fs.Parse(args)
+	if (*stay == 0) == (*token == "") || *method == "" {
+		return nil, errors.New("pay needs -method and exactly one of -stay or -ticket")
+	}
+	body := map[string]interface{}{"stay_id": *stay, "ticket_token": *token, "lost_ticket": *lost}
+	var in intent
+	if err := c.do("POST", c.vehicleURL, "/api/payments", body, &in); err != nil {
+		return nil, err
+	}
+	path := fmt.Sprintf("/api/payments/%d", in.ID)
+	if err := c.do("POST", c.vehicleURL, path+"/authorize", map[string]string{"method": *method}, &in); err != nil {
+		return nil, err
+	}
+	err := c.do("POST", c.vehicleURL, path+"/capture", nil, &in)
+	return in, err
+}
+
+// runRedeem applies a discount code and shows what the stay would pay if
+// it left now.
+func runRedeem(c *client, args []string) (interface{}, error) {
+	fs := flag.NewFlagSet("redeem", flag.ExitOnError)
+	code := fs.String("code", "", "discount code")
+	stay := fs.Int("stay", 0, "stay (vehicle record) id")
+	token := fs.String("ticket", "", "ticket token, instead of -stay")
+	fs.Parse(args)
+	if *code == "" || (*stay == 0) == (*token == "") {
+		return nil, errors.New("redeem needs -code and exactly one of -stay or -ticket")
+	}
+	body := map[string]interface{}{"code": *code, "stay_id": *stay, "ticket_token": *token}
+	var rec parking.VehichleRes
+	err := c.do("POST", c.vehicleURL, "/api/discount-redemptions", body, &rec)
+	return rec, err
+}
+
+// session is the part of a charging session pdeactl shows.
+type session struct {
+	ID         int        `json:"id"`
+	StayID     int        `json:"stay_id"`
+	SpotNumber string     `json:"spot_number"`
+	Connector  string     `json:"connector"`
+	StartedAt  time.Time  `json:"started_at"`
+	StoppedAt  *time.Time `json:"stopped_at,omitempty"`
+	EnergyWh   int        `json:"energy_wh"`
+	Cents      int        `json:"cents"`
+}
+
+// runCharge starts and stops charging sessions. "meter" reports a reading
+// the way a charger does, to simulate one.
+func runCharge(c *client, args []string) (interface{}, error) {
+	if len(args) == 0 {
+		return nil, errors.New("charge needs start, stop or meter")
+	}
+	var se session
+	switch args[0] {
+	case "start":
+		fs := flag.NewFlagSet("charge start", flag.ExitOnError)
+		stay := fs.Int("stay", 0, "stay (vehicle record) id")
+		token := fs.String("ticket", "", "ticket token, instead of -stay")
+		fs.Parse(args[1:])
+		if (*stay == 0) == (*token == "") {
+			return nil, errors.New("charge start needs exactly one of -stay or -ticket")
+		}
+		body := map[string]interface{}{"stay_id": *stay, "ticket_token": *token}
+		err := c.do("POST", c.vehicleURL, "/api/charging-sessions", body, &se)
+		return se, err
+	case "stop", "meter":
+		if len(args) < 2 || args[1] == "" {
+			return nil, errors.New("expected a session id")
+		}
+		path := "/api/charging-sessions/" + url.PathEscape(args[1]) + "/" + args[0]
+		var body interface{}
+		if args[0] == "meter" {
+			fs := flag.NewFlagSet("charge meter", flag.ExitOnError)
+			wh := fs.Int("wh", -1, "energy delivered since the session started, in Wh")
+			fs.Parse(args[2:])
+			if *wh < 0 {
+				return nil, errors.New("charge meter needs -wh")
+			}
+			body = map[string]int{"energy_wh": *wh}
+		}
+		err := c.do("POST", c.vehicleURL, path, body, &se)
+		return se, err
+	}
+	return nil, fmt.Errorf("unknown charge command %q", args[0])
+}
+
+// waitEntry is the part of a waitlist entry pdeactl shows.
+type waitEntry struct {
+	ID           int        `json:"id"`
+	SpotType     string     `json:"spot_type"`
+	LicensePlate string     `json:"license_plate"`
+	Status       string     `json:"status"`
+	Position     int        `json:"position,omitempty"`
+	HeldSpot     string     `json:"held_spot,omitempty"`
+	HoldExpires  *time.Time `json:"hold_expires_at,omitempty"`
+}
+
+func runWaitlist(c *client, args []string) (interface{}, error) {
+	if len(args) == 0 {
+		return nil, errors.New("waitlist needs join, list, get or cancel")
+	}
+	switch args[0] {
+	case "join":
+		fs := flag.NewFlagSet("waitlist join", flag.ExitOnError)
+		spotType := fs.String("type", "", "spot type to wait for")
+		plate := fs.String("plate", "", "license plate")
+		contact := fs.String("contact", "", "where to notify the driver")
+		fs.Parse(args[1:])
+		if *spotType == "" || *plate == "" {
+			return nil, errors.New("waitlist join needs -type and -plate")
+		}
+		body := map[string]string{"type": *spotType, "license_plate": *plate, "contact": *contact}
+		var e waitEntry
+		err := c.do("POST", c.vehicleURL, "/api/waitlist", body, &e)
+		return e, err
+	case "list":
+		fs := flag.NewFlagSet("waitlist list", flag.ExitOnError)
+		spotType := fs.String("type", "", "only entries for this spot type")
+		fs.Parse(args[1:])
+		var es []waitEntry
+		err := c.do("GET", c.vehicleURL, "/api/waitlist?spot_type="+url.QueryEscape(*spotType), nil, &es)
+		return es, err
+	case "get", "cancel":
+		if len(args) != 2 || args[1] == "" {
+			return nil, errors.New("expected a waitlist entry id")
+		}
+		method := "GET"
+		if args[0] == "cancel" {
+			method = "DELETE"
+		}
+		var e waitEntry
+		err := c.do(method, c.vehicleURL, "/api/waitlist/"+url.PathEscape(args[1]), nil, &e)
+		return e, err
+	}
+	return nil, fmt.Errorf("unknown waitlist command %q", args[0])
+}
+
+// runGate opens a gate by hand.
+func runGate(c *client, args []string) (interface{}, error) {
+	if len(args) != 2 || args[0] != "open" || args[1] == "" {
+		return nil, errors.New("usage: gate open <gate id>")
+	}
+	var res parking.GateResult
+	err := c.do("POST", c.vehicleURL, "/api/gates/"+url.PathEscape(args[1])+"/open", nil, &res)
+	return res, err
+}
+
+// plateRead is the part of a camera plate read pdeactl shows.
+type plateRead struct {
+	ID           int     `json:"id"`
+	Plate        string  `json:"plate"`
+	Confidence   float64 `json:"confidence"`
+	Lane         string  `json:"lane"`
+	Direction    string  `json:"direction"`
+	Status       string  `json:"status"`
+	Reason       string  `json:"reason,omitempty"`
+	MatchedPlate string  `json:"matched_plate,omitempty"`
+	StayID       int     `json:"stay_id,omitempty"`
+	ImageRef     string  `json:"image_ref,omitempty"`
+}
+
+// runReads works the review queue of camera plate reads.
+func runReads(c *client, args []string) (interface{}, error) {
+	if len(args) == 0 {
+		return nil, errors.New("reads needs list, resolve or dismiss")
+	}
+	switch args[0] {
+	case "list":
+		fs := flag.NewFlagSet("reads list", flag.ExitOnError)
+		status := fs.String("status", "review", "only reads with this status, empty for all")
+		fs.Parse(args[1:])
+		var rs []plateRead
+		err := c.do("GET", c.vehicleURL, "/api/plate-reads?status="+url.QueryEscape(*status), nil, &rs)
+		return rs, err
+	case "resolve", "dismiss":
+		if len(args) < 2 || args[1] == "" {
+			return nil, errors.New("expected a read id")
+		}
+		path := "/api/plate-reads/" + url.PathEscape(args[1]) + "/" + args[0]
+		var body interface{}
+		if args[0] == "resolve" {
+			fs := flag.NewFlagSet("reads resolve", flag.ExitOnError)
+			plate := fs.String("plate", "", "corrected plate, if the camera misread it")
+			spot := fs.String("spot", "", "spot for an entry, instead of the one picked")
+			override := fs.String("override", "", "reason for letting an unpaid stay out")
+			fs.Parse(args[2:])
+			body = map[string]string{"plate": *plate, "spot_number": *spot, "payment_override": *override}
+		}
+		var rd plateRead
+		err := c.do("POST", c.vehicleURL, path, body, &rd)
+		return rd, err
+	}
+	return nil, fmt.Errorf("unknown reads command %q", args[0])
+}
+
+func runRecords(c *client, args []string) (interface{}, error) {
+	fs := flag.NewFlagSet("records", flag.ExitOnError)
+	spot := fs.String("spot", "", "spot number")
+	plate := fs.String("plate", "", "license plate")
+	fs.Parse(args)
+	var path string
+	switch {
+	case *spot != "" && *plate == "":
+		path = "/api/vehicle-records/" + url.PathEscape(*spot)
+	case *plate != "" && *spot == "":
+		path = "/api/vehicle-records?license_plate=" + url.QueryEscape(*plate)
+	default:
+		return nil, errors.New("records needs exactly one of -spot or -plate")
+	}
+	var recs []parking.VehichleRes
+	err := c.do("GET", c.vehicleURL, path, nil, &recs)
+	return recs, err
+}
+
+func printTable(w io.Writer, out interface{}) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	defer tw.Flush()
+	switch v := out.(type) {
+	case []parking.ParkingSpot:
+		fmt.Fprintln(tw, "ID\tSPOT\tTYPE\tAVAILABLE\tZONE\tCHARGER\tEV ONLY\tACCESSIBLE")
+		for _, s := range v {
+			charger := ""
+			if s.HasCharger() {
+				charger = fmt.Sprintf("%s %gkW", s.Connector, s.MaxKW)
+			}
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%t\t%t\n", s.ID, s.SpotNumber, s.Type, s.IsAvailable, s.Zone, charger, s.EVOnly, s.Accessible)
+		}
+	case parking.ParkingSpot:
+		printTable(w, []parking.ParkingSpot{v})
+	case []parking.VehichleRes:
+		fmt.Fprintln(tw, "ID\tSPOT\tPLATE\tTICKET\tENTRY\tEXIT\tRATE/H\tFEE")
+		for _, r := range v {
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", r.ID, r.SpotNumber, r.License_plate, r.TicketID, r.EntryTime, r.ExitTime, money(r.HourlyCents), money(r.FeeCents))
+		}
+	case parking.VehichleRes:
+		printTable(w, []parking.VehichleRes{v})
+		if v.TicketToken != "" {
+			fmt.Fprintln(w, "ticket token:", v.TicketToken)
+		}
+		if v.PermitWarning != "" {
+			fmt.Fprintln(w, "permit warning:", v.PermitWarning)
+		}
+		if v.Gate != nil {
+			printTable(w, *v.Gate)
+		}
+		for _, d := range v.Discounts {
+			fmt.Fprintf(w, "discount %s (%d %s): -%s\n", d.Code, d.Value, d.Kind, money(&d.Cents))
+		}
+	case session:
+		fmt.Fprintln(tw, "SESSION\tSTAY\tSPOT\tCONNECTOR\tSTARTED\tSTOPPED\tKWH\tCOST")
+		stopped := ""
+		if v.StoppedAt != nil {
+			stopped = v.StoppedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(tw, "%d\t%d\t%s\t%s\t%s\t%s\t%.3f\t%s\n", v.ID, v.StayID, v.SpotNumber, v.Connector, v.StartedAt.Format(time.RFC3339), stopped, float64(v.EnergyWh)/1000, money(&v.Cents))
+	case []waitEntry:
+		fmt.Fprintln(tw, "ENTRY\tTYPE\tPLATE\tSTATUS\tPOSITION\tHELD SPOT\tHELD UNTIL")
+		for _, e := range v {
+			pos, until := "", ""
+			if e.Position > 0 {
+				pos = fmt.Sprint(e.Position)
+			}
+			if e.HoldExpires != nil {
+				until = e.HoldExpires.Format(time.RFC3339)
+			}
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n", e.ID, e.SpotType, e.LicensePlate, e.Status, pos, e.HeldSpot, until)
+		}
+	case waitEntry:
+		printTable(w, []waitEntry{v})
+	case []plateRead:
+		fmt.Fprintln(tw, "READ\tPLATE\tCONFIDENCE\tLANE\tDIRECTION\tSTATUS\tSTAY\tREASON")
+		for _, r := range v {
+			stay := ""
+			if r.StayID != 0 {
+				stay = fmt.Sprint(r.StayID)
+			}
+			fmt.Fprintf(tw, "%d\t%s\t%.2f\t%s\t%s\t%s\t%s\t%s\n", r.ID, r.Plate, r.Confidence, r.Lane, r.Direction, r.Status, stay, r.Reason)
+		}
+	case plateRead:
+		printTable(w, []plateRead{v})
+	case parking.GateResult:
+		if v.Error != "" {
+			fmt.Fprintf(w, "gate %s: %s (%s)\n", v.GateID, v.Status, v.Error)
+		} else {
+			fmt.Fprintf(w, "gate %s: %s\n", v.GateID, v.Status)
+		}
+	case intent:
+		fmt.Fprintln(tw, "PAYMENT\tSTAY\tAMOUNT\tSTATUS")
+		fmt.Fprintf(tw, "%d\t%d\t%s %s\t%s\n", v.ID, v.StayID, money(&v.AmountCents), v.Currency, v.Status)
+	case struct{ Message string }:
+		fmt.Fprintln(tw, v.Message)
+	}
+}
+
+// money renders cents with two decimals, or nothing for unpriced stays.
+func money(cents *int) string {
+	if cents == nil {
+		return ""
+	}
+	return fmt.Sprintf("%d.%02d", *cents/100, *cents%100)
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"PDEA/internal/events"
+	"PDEA/internal/eventstore"
+	"PDEA/internal/platform"
+)
+
+// rebuild drops the event-sourced projections and replays parking_events to
+// recreate them. With -seed it first imports the current table state as
+// events, which is needed once on databases that predate the event log.
+// Spots are seeded from the table the configured PDEA_SPOT_MODE keeps them
+// in: parking_spots, the spot service's, with api and parking_rec with sql.
+func rebuild(args []string) error {
+	fs := flag.NewFlagSet("rebuild", flag.ExitOnError)
+	seed := fs.Bool("seed", false, "import current spots and open stays when the event log is empty")
+	fs.Parse(args)
+
+	db, err := platform.OpenDB("rebuild", slog.Default())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	store := eventstore.New(db)
+	if err = store.Migrate(); err != nil {
+		return fmt.Errorf("creating event store schema: %w", err)
+	}
+	if *seed {
+		table, err := spotTable(os.Getenv("PDEA_SPOT_MODE"))
+		if err != nil {
+			return err
+		}
+		n, err := seedEvents(db, store, table)
+		if err != nil {
+			return fmt.Errorf("seeding events: %w", err)
+		}
+		fmt.Printf("seeded %d events\n", n)
+	}
+	n, err := store.Rebuild()
+	if err != nil {
+		return fmt.Errorf("rebuilding projections: %w", err)
+	}
+	fmt.Printf("replayed %d events\n", n)
+	return nil
+}
+
+// spotTable returns the table spots are kept in under the spot mode.
+func spotTable(mode string) (string, error) {
+	switch mode {
+	case "", "sql":
+		return "parking_rec", nil
+	case "api":
+		return "parking_spots", nil
+	}
+	return "", fmt.Errorf("invalid PDEA_SPOT_MODE %q, want sql or api", mode)
+}
+
+// seedEvents records a creation for every spot in spotTable and an entry
+// for every stay in progress.
+func seedEvents(db *sql.DB, store *eventstore.Store, spotTable string) (int, error) {
+	var count int
+	if err := db.QueryRow(`select count(*) from parking_events;`).Scan(&count); err != nil {
+		return 0, err
+	}
+	if count > 0 {
+		return 0, fmt.Errorf("event log already has %d events", count)
+	}
+	var evs []events.Event
+	now := time.Now().UTC()
+
+	rows, err := db.Query(`select spot_number, type, zone, is_available from ` + spotTable + ` where spot_number is not null;`)
+	if err != nil {
+		return 0, err
+	}
+	for rows.Next() {
+		var ev events.Event
+		var avail bool
+		if err := rows.Scan(&ev.SpotNumber, &ev.SpotType, &ev.Zone, &avail); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ev.Type = events.SpotCreated
+		ev.IsAvailable = events.Bool(avail)
+		ev.Time = now
+		evs = append(evs, ev)
+	}
+	rows.Close()
+
+	rows, err = db.Query(`select id, spot_number, license_plate, entry_time from vehicle_records where exit_time is null order by id;`)
+	if err != nil {
+		return 0, err
+	}
+	for rows.Next() {
+		ev := events.Event{Type: events.VehicleEntry}
+		if err := rows.Scan(&ev.StayID, &ev.SpotNumber, &ev.Plate, &ev.Time); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		evs = append(evs, ev)
+	}
+	rows.Close()
+
+	for _, ev := range evs {
+		if _, err := store.Append(ev); err != nil {
+			return 0, err
+		}
+	}
+	return len(evs), nil
+}